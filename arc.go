@@ -87,13 +87,13 @@ func (c *ARCCache) Get(key interface{}) (interface{}, bool) {
 	return nil, false
 }
 
-// Add adds a value to the cache.
-func (c *ARCCache) Add(key, value interface{}) {
-	c.AddEx(key, value, 0)
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *ARCCache) Add(key, value interface{}) (evicted bool) {
+	return c.AddEx(key, value, 0)
 }
 
-// AddEx adds a value to the cache.
-func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
+// AddEx adds a value to the cache.  Returns true if an eviction occurred.
+func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) (evicted bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -102,13 +102,13 @@ func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
 	if c.t1.Contains(key) {
 		c.t1.Remove(key)
 		c.t2.AddEx(key, value, expire)
-		return
+		return false
 	}
 
 	// Check if the value is already in T2 (frequent) and update it
 	if c.t2.Contains(key) {
 		c.t2.AddEx(key, value, expire)
-		return
+		return false
 	}
 
 	// Check if this value was recently evicted as part of the
@@ -130,6 +130,7 @@ func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
 		// Potentially need to make room in the cache
 		if c.t1.Len()+c.t2.Len() >= c.size {
 			c.replace(false)
+			evicted = true
 		}
 
 		// Remove from B1
@@ -137,7 +138,7 @@ func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
 
 		// Add the key to the frequently used list
 		c.t2.AddEx(key, value, expire)
-		return
+		return evicted
 	}
 
 	// Check if this value was recently evicted as part of the
@@ -159,6 +160,7 @@ func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
 		// Potentially need to make room in the cache
 		if c.t1.Len()+c.t2.Len() >= c.size {
 			c.replace(true)
+			evicted = true
 		}
 
 		// Remove from B2
@@ -166,12 +168,13 @@ func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
 
 		// Add the key to the frequntly used list
 		c.t2.AddEx(key, value, expire)
-		return
+		return evicted
 	}
 
 	// Potentially need to make room in the cache
 	if c.t1.Len()+c.t2.Len() >= c.size {
 		c.replace(false)
+		evicted = true
 	}
 
 	// Keep the size of the ghost buffers trim
@@ -184,7 +187,7 @@ func (c *ARCCache) AddEx(key, value interface{}, expire time.Duration) {
 
 	// Add to the recently seen list
 	c.t1.AddEx(key, value, expire)
-	return
+	return evicted
 }
 
 // replace is used to adaptively evict from either T1 or T2
@@ -220,22 +223,22 @@ func (c *ARCCache) Keys() []interface{} {
 	return append(k1, k2...)
 }
 
-// Remove is used to purge a key from the cache
-func (c *ARCCache) Remove(key interface{}) {
+// Remove is used to purge a key from the cache, returning if the key
+// was cached.
+func (c *ARCCache) Remove(key interface{}) (present bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	if c.t1.Remove(key) {
-		return
+		return true
 	}
 	if c.t2.Remove(key) {
-		return
+		return true
 	}
 	if c.b1.Remove(key) {
-		return
-	}
-	if c.b2.Remove(key) {
-		return
+		return false
 	}
+	c.b2.Remove(key)
+	return false
 }
 
 // Purge is used to clear the cache
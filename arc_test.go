@@ -375,3 +375,28 @@ func TestARC_Peek(t *testing.T) {
 		t.Errorf("should not have updated recent-ness of 1")
 	}
 }
+
+// TestARC_SwapForLRU checks that ARC and LRU share the same surface
+func TestARC_SwapForLRU(t *testing.T) {
+	type cache interface {
+		Add(key, value interface{}) bool
+		AddEx(key, value interface{}, expire time.Duration) bool
+		Get(key interface{}) (interface{}, bool)
+		Contains(key interface{}) bool
+		Peek(key interface{}) (interface{}, bool)
+		Remove(key interface{}) bool
+		Purge()
+		Keys() []interface{}
+		Len() int
+	}
+	a, _ := NewARC(2)
+	l, _ := New(2)
+	for _, c := range []cache{a, l} {
+		if c.Add(1, 1) || c.Add(2, 2) || !c.Add(3, 3) {
+			t.Fatalf("%T: bad eviction report", c)
+		}
+		if !c.Remove(3) || c.Remove(3) {
+			t.Fatalf("%T: bad remove", c)
+		}
+	}
+}
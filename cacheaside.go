@@ -0,0 +1,202 @@
+package lru
+
+import (
+	"sync"
+	"time"
+)
+
+// WritePolicy controls how a CacheAside updates the cache after a write
+// to the backing store.
+type WritePolicy int
+
+const (
+	// WriteInvalidate removes the key from the cache after the write, so
+	// the next read loads the new value from the backing store.
+	WriteInvalidate WritePolicy = iota
+
+	// WriteUpdate stores the value returned by the write in the cache.
+	WriteUpdate
+
+	// WriteDelayedDelete removes the key after the write and once more
+	// after the configured delay, discarding stale values that a
+	// concurrent read may have put back in between.
+	WriteDelayedDelete
+)
+
+// LoaderFunc loads the value of a key from the backing store.
+type LoaderFunc func(key interface{}) (interface{}, error)
+
+// CacheAside binds a Cache to a loader and codifies the cache-aside
+// pattern: reads go through the cache and fall back to the loader, and
+// writes go to the backing store first and are then reflected in the
+// cache according to a WritePolicy.
+type CacheAside struct {
+	cache  *Cache
+	load   LoaderFunc
+	policy WritePolicy
+	delay  time.Duration
+
+	lock sync.Mutex
+	warm *warmup
+}
+
+// warmupSample is the number of lookups a warm-up measures the hit
+// ratio over before the cache may be considered warm, so that a few
+// early hits do not end it.
+const warmupSample = 100
+
+// warmup tracks the cold-start bypass of a CacheAside.
+type warmup struct {
+	bypass  float64 // fraction of the reads bypassed on a cold cache
+	target  float64 // hit ratio at which the cache is considered warm
+	hits    uint64
+	lookups uint64
+	debt    float64 // accumulated fraction of reads owed to the loader
+	cold    bool
+}
+
+// NewCacheAside creates a CacheAside on top of the given cache. delay is
+// only used by the WriteDelayedDelete policy.
+func NewCacheAside(cache *Cache, load LoaderFunc, policy WritePolicy, delay time.Duration) *CacheAside {
+	return &CacheAside{
+		cache:  cache,
+		load:   load,
+		policy: policy,
+		delay:  delay,
+	}
+}
+
+// Get looks up a key's value from the cache, loading and storing it on
+// a miss.
+func (c *CacheAside) Get(key interface{}) (interface{}, error) {
+	if c.bypassed() {
+		return c.loadAndStore(key)
+	}
+	val, ok := c.cache.Get(key)
+	c.record(ok)
+	if ok {
+		return val, nil
+	}
+	return c.loadAndStore(key)
+}
+
+// loadAndStore loads the value of a key and stores it in the cache.
+func (c *CacheAside) loadAndStore(key interface{}) (interface{}, error) {
+	val, err := c.load(key)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Add(key, val)
+	return val, nil
+}
+
+// Write runs fn, which is expected to write the key to the backing store
+// and return the value written, then updates the cache according to the
+// configured policy. If fn fails, the key is invalidated regardless of
+// the policy since the state of the backing store is unknown.
+func (c *CacheAside) Write(key interface{}, fn func() (interface{}, error)) error {
+	val, err := fn()
+	if err != nil {
+		c.cache.Remove(key)
+		return err
+	}
+
+	switch c.policy {
+	case WriteUpdate:
+		c.cache.Add(key, val)
+	case WriteDelayedDelete:
+		c.cache.RemoveDelayed(key, c.delay)
+	default:
+		c.cache.Remove(key)
+	}
+	return nil
+}
+
+// Invalidate removes the key from the cache.
+func (c *CacheAside) Invalidate(key interface{}) {
+	c.cache.Remove(key)
+}
+
+// Purge clears the cache and restarts the warm-up, if enabled.
+func (c *CacheAside) Purge() {
+	c.cache.Purge()
+	c.lock.Lock()
+	if c.warm != nil {
+		c.warm.restart()
+	}
+	c.lock.Unlock()
+}
+
+// SetWarmup enables the cold-start bypass mode and starts a warm-up:
+// until the hit ratio of the cache reaches target over at least 100
+// lookups, a fraction of the reads goes straight to the loader, starting at bypass and ramping down
+// to zero as the hit ratio improves. Bypassed reads still store the
+// loaded value, warming the cache. bypass must be below 1, otherwise the
+// cache would never be read. A warm-up is restarted by Purge.
+func (c *CacheAside) SetWarmup(bypass, target float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.warm = &warmup{bypass: bypass, target: target}
+	c.warm.restart()
+}
+
+// Warming reports whether the cache is still warming up.
+func (c *CacheAside) Warming() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.warm != nil && c.warm.cold
+}
+
+// bypassed reports whether the next read should skip the cache.
+func (c *CacheAside) bypassed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	w := c.warm
+	if w == nil || !w.cold {
+		return false
+	}
+	w.debt += w.fraction()
+	if w.debt >= 1 {
+		w.debt--
+		return true
+	}
+	return false
+}
+
+// record accounts a cache lookup during the warm-up.
+func (c *CacheAside) record(hit bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	w := c.warm
+	if w == nil || !w.cold {
+		return
+	}
+	w.lookups++
+	if hit {
+		w.hits++
+	}
+	if w.lookups >= warmupSample && w.fraction() <= 0 {
+		w.cold = false
+	}
+}
+
+// restart starts the warm-up over.
+func (w *warmup) restart() {
+	w.hits, w.lookups, w.debt = 0, 0, 0
+	w.cold = w.bypass > 0 && w.target > 0
+}
+
+// fraction returns the fraction of the reads to bypass, which decreases
+// linearly from bypass on a cold cache to zero once target is reached.
+// Until warmupSample lookups were made, the missing ones count as misses.
+func (w *warmup) fraction() float64 {
+	lookups := w.lookups
+	if lookups < warmupSample {
+		lookups = warmupSample
+	}
+	ratio := float64(w.hits) / float64(lookups)
+	if ratio >= w.target {
+		return 0
+	}
+	return w.bypass * (1 - ratio/w.target)
+}
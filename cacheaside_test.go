@@ -0,0 +1,162 @@
+package lru
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCacheAside_Get(t *testing.T) {
+	l, _ := New(2)
+	loads := 0
+	ca := NewCacheAside(l, func(key interface{}) (interface{}, error) {
+		loads++
+		return key, nil
+	}, WriteInvalidate, 0)
+
+	for i := 0; i < 2; i++ {
+		v, err := ca.Get(1)
+		if err != nil || v != 1 {
+			t.Fatalf("bad: %v, %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("bad loads: %d", loads)
+	}
+}
+
+func TestCacheAside_Write(t *testing.T) {
+	store := map[interface{}]interface{}{1: "a"}
+	load := func(key interface{}) (interface{}, error) {
+		return store[key], nil
+	}
+	write := func(v interface{}) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			store[1] = v
+			return v, nil
+		}
+	}
+
+	l, _ := New(2)
+	ca := NewCacheAside(l, load, WriteInvalidate, 0)
+	ca.Get(1)
+	if err := ca.Write(1, write("b")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if l.Contains(1) {
+		t.Fatalf("should be invalidated")
+	}
+	if v, _ := ca.Get(1); v != "b" {
+		t.Fatalf("bad: %v", v)
+	}
+
+	l, _ = New(2)
+	ca = NewCacheAside(l, load, WriteUpdate, 0)
+	ca.Write(1, write("c"))
+	if v, ok := l.Peek(1); !ok || v != "c" {
+		t.Fatalf("bad: %v", v)
+	}
+
+	if err := ca.Write(1, func() (interface{}, error) {
+		return nil, errors.New("failed")
+	}); err == nil {
+		t.Fatalf("should fail")
+	}
+	if l.Contains(1) {
+		t.Fatalf("should be invalidated on failure")
+	}
+}
+
+func TestCacheAside_DelayedDelete(t *testing.T) {
+	l, _ := New(2)
+	ca := NewCacheAside(l, nil, WriteDelayedDelete, 50*time.Millisecond)
+	ca.Write(1, func() (interface{}, error) {
+		return 1, nil
+	})
+	if l.Contains(1) {
+		t.Fatalf("should be removed")
+	}
+
+	// A stale read repopulates the key before the second delete
+	l.Add(1, 0)
+	time.Sleep(100 * time.Millisecond)
+	if l.Contains(1) {
+		t.Fatalf("should be removed again")
+	}
+}
+
+func TestCacheAside_ReentrantLoader(t *testing.T) {
+	l, _ := New(4)
+	var ca *CacheAside
+	ca = NewCacheAside(l, func(key interface{}) (interface{}, error) {
+		// Loading a key may load its dependencies through the same cache
+		if n := key.(int); n > 0 {
+			v, err := ca.Get(n - 1)
+			if err != nil {
+				return nil, err
+			}
+			return v.(int) + n, nil
+		}
+		l.Remove(-1)
+		return 0, nil
+	}, WriteInvalidate, 0)
+
+	v, err := ca.Get(3)
+	if err != nil || v != 6 {
+		t.Fatalf("bad value: %v %v", v, err)
+	}
+	if l.Len() != 4 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestCacheAside_Warmup(t *testing.T) {
+	l, _ := New(4)
+	loads := 0
+	ca := NewCacheAside(l, func(key interface{}) (interface{}, error) {
+		loads++
+		return key, nil
+	}, WriteInvalidate, 0)
+	ca.SetWarmup(0.5, 0.5)
+	if !ca.Warming() {
+		t.Fatalf("should be warming")
+	}
+
+	// The second read goes to the loader although the key is cached
+	for i := 0; i < 2; i++ {
+		ca.Get(0)
+	}
+	if loads != 2 {
+		t.Fatalf("bad loads: %d", loads)
+	}
+
+	// Early hits are too few a sample to end the warm-up
+	for i := 0; i < 10; i++ {
+		ca.Get(0)
+	}
+	if !ca.Warming() {
+		t.Fatalf("should still be warming")
+	}
+
+	for i := 0; i < 1000 && ca.Warming(); i++ {
+		ca.Get(i % 4)
+	}
+	if ca.Warming() {
+		t.Fatalf("should be warm")
+	}
+	for i := 0; i < 4; i++ {
+		ca.Get(i)
+	}
+	loads = 0
+	for i := 0; i < 8; i++ {
+		ca.Get(i % 4)
+	}
+	if loads != 0 {
+		t.Fatalf("bad loads once warm: %d", loads)
+	}
+
+	ca.Purge()
+	if !ca.Warming() || l.Len() != 0 {
+		t.Fatalf("purge should restart the warm-up")
+	}
+}
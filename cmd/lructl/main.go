@@ -0,0 +1,223 @@
+// Command lructl inspects and edits the snapshot files written by
+// simplelru.LRU.SaveTo and lru.Cache.SaveTo, so that warm-up artifacts
+// can be checked and fixed without writing a program.
+//
+// Usage:
+//
+//	lructl list FILE               show the keys, TTLs and sizes
+//	lructl grep PATTERN FILE       show the keys matching a regexp
+//	lructl diff FILE1 FILE2        compare the entries of two snapshots
+//	lructl rm FILE KEY...          delete keys
+//	lructl ttl FILE TTL [KEY...]   set the TTL of keys, or of every key;
+//	                               a TTL of 0 never expires
+//
+// Keys are matched by their printed form. Only the types gob knows
+// without registration can be decoded, such as strings, numbers and
+// byte slices.
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+var errUsage = errors.New("usage: lructl list|grep|diff|rm|ttl ...")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, time.Now()); err != nil {
+		fmt.Fprintln(os.Stderr, "lructl:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command in args at now, writing its output to out
+func run(args []string, out io.Writer, now time.Time) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch cmd, args := args[0], args[1:]; {
+	case cmd == "list" && len(args) == 1:
+		records, err := load(args[0])
+		if err != nil {
+			return err
+		}
+		return list(out, records, now, nil)
+	case cmd == "grep" && len(args) == 2:
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return err
+		}
+		records, err := load(args[1])
+		if err != nil {
+			return err
+		}
+		return list(out, records, now, re)
+	case cmd == "diff" && len(args) == 2:
+		a, err := load(args[0])
+		if err != nil {
+			return err
+		}
+		b, err := load(args[1])
+		if err != nil {
+			return err
+		}
+		diff(out, a, b)
+		return nil
+	case cmd == "rm" && len(args) >= 2:
+		records, err := load(args[0])
+		if err != nil {
+			return err
+		}
+		keys := keySet(args[1:])
+		kept := records[:0]
+		for _, r := range records {
+			if !keys[keyString(r.Key)] {
+				kept = append(kept, r)
+			}
+		}
+		fmt.Fprintf(out, "removed %d keys\n", len(records)-len(kept))
+		return save(args[0], kept)
+	case cmd == "ttl" && len(args) >= 2:
+		records, err := load(args[0])
+		if err != nil {
+			return err
+		}
+		ttl, err := time.ParseDuration(args[1])
+		if err != nil {
+			return err
+		}
+		keys := keySet(args[2:])
+		n := 0
+		for i, r := range records {
+			if len(keys) > 0 && !keys[keyString(r.Key)] {
+				continue
+			}
+			records[i].Expire = nil
+			if ttl > 0 {
+				expire := now.Add(ttl)
+				records[i].Expire = &expire
+			}
+			n++
+		}
+		fmt.Fprintf(out, "updated %d keys\n", n)
+		return save(args[0], records)
+	}
+	return errUsage
+}
+
+// list prints the records whose key matches re, or all of them
+func list(out io.Writer, records []simplelru.Record, now time.Time, re *regexp.Regexp) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTTL\tSIZE\tTYPE")
+	for _, r := range records {
+		key := keyString(r.Key)
+		if re != nil && !re.MatchString(key) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", key, ttlString(r.Expire, now), simplelru.EstimateSize(r.Value), typeString(r.Value))
+	}
+	return w.Flush()
+}
+
+// diff prints the keys only in a with -, only in b with +, and the keys
+// whose value or expire time differ with ~
+func diff(out io.Writer, a, b []simplelru.Record) {
+	index := make(map[string]simplelru.Record, len(b))
+	for _, r := range b {
+		index[keyString(r.Key)] = r
+	}
+	seen := make(map[string]bool, len(a))
+	for _, r := range a {
+		key := keyString(r.Key)
+		seen[key] = true
+		o, ok := index[key]
+		switch {
+		case !ok:
+			fmt.Fprintf(out, "- %s\n", key)
+		case !reflect.DeepEqual(r.Value, o.Value) || !equalExpire(r.Expire, o.Expire):
+			fmt.Fprintf(out, "~ %s\n", key)
+		}
+	}
+	for _, r := range b {
+		if key := keyString(r.Key); !seen[key] {
+			fmt.Fprintf(out, "+ %s\n", key)
+		}
+	}
+}
+
+func load(path string) ([]simplelru.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []simplelru.Record
+	if err := gob.NewDecoder(f).Decode(&records); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return records, nil
+}
+
+// save replaces the snapshot at path, writing a temporary file first so
+// that a failure leaves the original intact
+func save(path string, records []simplelru.Record) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".lructl-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := gob.NewEncoder(f).Encode(records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+func keyString(key interface{}) string {
+	return fmt.Sprint(key)
+}
+
+func typeString(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+func ttlString(expire *time.Time, now time.Time) string {
+	if expire == nil {
+		return "-"
+	}
+	if !expire.After(now) {
+		return "expired"
+	}
+	return expire.Sub(now).Round(time.Second).String()
+}
+
+func equalExpire(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func writeSnapshot(t *testing.T, dir, name string, fill func(l *simplelru.LRU)) string {
+	t.Helper()
+	l, err := simplelru.NewLRU(16, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	fill(l)
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer f.Close()
+	if err := l.SaveTo(f); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return path
+}
+
+func runOK(t *testing.T, now time.Time, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := run(args, &out, now); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestLructl(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	a := writeSnapshot(t, dir, "a", func(l *simplelru.LRU) {
+		l.Add("user:1", "alice")
+		l.AddEx("user:2", "bob", time.Hour)
+		l.Add("session:1", []byte("token"))
+	})
+	b := writeSnapshot(t, dir, "b", func(l *simplelru.LRU) {
+		l.Add("user:1", "alice")
+		l.AddEx("user:2", "robert", time.Hour)
+		l.Add("user:3", "carol")
+	})
+
+	out := runOK(t, now, "list", a)
+	for _, want := range []string{"user:1", "user:2", "session:1", "[]uint8", "1h0m0s"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("list missing %q:\n%s", want, out)
+		}
+	}
+
+	out = runOK(t, now, "grep", "^user:", a)
+	if strings.Contains(out, "session:1") || !strings.Contains(out, "user:2") {
+		t.Fatalf("bad grep:\n%s", out)
+	}
+
+	out = runOK(t, now, "diff", a, b)
+	if out != "~ user:2\n- session:1\n+ user:3\n" {
+		t.Fatalf("bad diff:\n%s", out)
+	}
+
+	runOK(t, now, "rm", a, "session:1", "missing")
+	runOK(t, now, "ttl", a, "30m", "user:1")
+	runOK(t, now, "ttl", a, "0", "user:2")
+	records, err := load(a)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("bad records: %v", records)
+	}
+	for _, r := range records {
+		switch r.Key {
+		case "user:1":
+			if r.Expire == nil || !r.Expire.Equal(now.Add(30*time.Minute)) {
+				t.Fatalf("bad expire: %v", r.Expire)
+			}
+		case "user:2":
+			if r.Expire != nil {
+				t.Fatalf("bad expire: %v", r.Expire)
+			}
+		default:
+			t.Fatalf("bad key: %v", r.Key)
+		}
+	}
+
+	if err := run([]string{"list"}, &bytes.Buffer{}, now); err != errUsage {
+		t.Fatalf("bad err: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+// Package compat provides adapters exposing the method sets of other
+// popular Go caches on top of the lru package, easing migrations for
+// code that hides its cache behind its own interface.
+package compat
+
+import (
+	"errors"
+	"time"
+
+	lru "github.com/hnlq715/golang-lru"
+)
+
+// KeyNotFoundError is returned by GCache when a key is absent, like the
+// error of the same name in github.com/bluele/gcache.
+var KeyNotFoundError = errors.New("Key not found.")
+
+// LoaderFunc loads the value of a missing key, like gcache's LoaderFunc.
+type LoaderFunc func(key interface{}) (interface{}, error)
+
+// GCache implements the methods of github.com/bluele/gcache's Cache
+// interface on top of an lru.Cache.
+type GCache struct {
+	c      *lru.Cache
+	loader LoaderFunc
+}
+
+// NewGCache creates a GCache of the given size. An optional loader is
+// used by Get to fill missing keys.
+func NewGCache(size int, loader LoaderFunc) (*GCache, error) {
+	c, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &GCache{c: c, loader: loader}, nil
+}
+
+// Set inserts or updates the value of a key.
+func (g *GCache) Set(key, value interface{}) error {
+	g.c.Add(key, value)
+	return nil
+}
+
+// SetWithExpire inserts or updates the value of a key with expiration.
+func (g *GCache) SetWithExpire(key, value interface{}, expiration time.Duration) error {
+	g.c.AddEx(key, value, expiration)
+	return nil
+}
+
+// Get returns the value of a key, calling the loader on a miss if one
+// was configured.
+func (g *GCache) Get(key interface{}) (interface{}, error) {
+	if v, ok := g.c.Get(key); ok {
+		return v, nil
+	}
+	if g.loader == nil {
+		return nil, KeyNotFoundError
+	}
+	v, err := g.loader(key)
+	if err != nil {
+		return nil, err
+	}
+	g.c.Add(key, v)
+	return v, nil
+}
+
+// GetIFPresent returns the value of a key without calling the loader.
+func (g *GCache) GetIFPresent(key interface{}) (interface{}, error) {
+	if v, ok := g.c.Get(key); ok {
+		return v, nil
+	}
+	return nil, KeyNotFoundError
+}
+
+// GetALL returns all the live entries of the cache. Expired entries are
+// never returned, so checkExpired is ignored.
+func (g *GCache) GetALL(checkExpired bool) map[interface{}]interface{} {
+	m := make(map[interface{}]interface{})
+	for _, key := range g.c.Keys() {
+		if v, ok := g.c.Peek(key); ok {
+			m[key] = v
+		}
+	}
+	return m
+}
+
+// Remove removes a key, returning if it was contained.
+func (g *GCache) Remove(key interface{}) bool {
+	ok := g.c.Contains(key)
+	g.c.Remove(key)
+	return ok
+}
+
+// Purge removes all the entries.
+func (g *GCache) Purge() {
+	g.c.Purge()
+}
+
+// Keys returns the keys of the cache, skipping the expired ones if
+// checkExpired is true.
+func (g *GCache) Keys(checkExpired bool) []interface{} {
+	keys := g.c.Keys()
+	if !checkExpired {
+		return keys
+	}
+	live := keys[:0]
+	for _, key := range keys {
+		if g.c.Contains(key) {
+			live = append(live, key)
+		}
+	}
+	return live
+}
+
+// Len returns the number of entries, skipping the expired ones if
+// checkExpired is true.
+func (g *GCache) Len(checkExpired bool) int {
+	if checkExpired {
+		return len(g.Keys(true))
+	}
+	return g.c.Len()
+}
+
+// Has returns if a live entry exists for the key.
+func (g *GCache) Has(key interface{}) bool {
+	return g.c.Contains(key)
+}
+
+// HitCount returns the number of successful lookups.
+func (g *GCache) HitCount() uint64 {
+	hits, _ := g.c.Lookups()
+	return hits
+}
+
+// MissCount returns the number of failed lookups.
+func (g *GCache) MissCount() uint64 {
+	_, misses := g.c.Lookups()
+	return misses
+}
+
+// LookupCount returns the number of lookups.
+func (g *GCache) LookupCount() uint64 {
+	hits, misses := g.c.Lookups()
+	return hits + misses
+}
+
+// HitRate returns the ratio of successful lookups.
+func (g *GCache) HitRate() float64 {
+	hits, misses := g.c.Lookups()
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
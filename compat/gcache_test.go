@@ -0,0 +1,74 @@
+package compat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGCache(t *testing.T) {
+	g, err := NewGCache(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := g.Get(1); err != KeyNotFoundError {
+		t.Fatalf("bad err: %v", err)
+	}
+	g.Set(1, 1)
+	g.SetWithExpire(2, 2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if v, err := g.Get(1); err != nil || v != 1 {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+	if _, err := g.GetIFPresent(2); err != KeyNotFoundError {
+		t.Fatalf("2 should be expired")
+	}
+	if g.Len(false) != 2 || g.Len(true) != 1 {
+		t.Fatalf("bad len: %v, %v", g.Len(false), g.Len(true))
+	}
+	if all := g.GetALL(true); len(all) != 1 || all[1] != 1 {
+		t.Fatalf("bad all: %v", all)
+	}
+	if !g.Has(1) || g.Has(2) {
+		t.Fatalf("bad has")
+	}
+	if g.HitCount() != 1 || g.MissCount() != 2 || g.LookupCount() != 3 {
+		t.Fatalf("bad counts: %v, %v", g.HitCount(), g.MissCount())
+	}
+	if r := g.HitRate(); r < 0.33 || r > 0.34 {
+		t.Fatalf("bad hit rate: %v", r)
+	}
+
+	if !g.Remove(1) || g.Remove(1) {
+		t.Fatalf("bad remove")
+	}
+	g.Purge()
+	if g.Len(false) != 0 {
+		t.Fatalf("bad len: %v", g.Len(false))
+	}
+}
+
+func TestGCache_Loader(t *testing.T) {
+	fail := errors.New("fail")
+	g, _ := NewGCache(2, func(key interface{}) (interface{}, error) {
+		if key == 0 {
+			return nil, fail
+		}
+		return key.(int) * 2, nil
+	})
+
+	if v, err := g.Get(2); err != nil || v != 4 {
+		t.Fatalf("bad: %v, %v", v, err)
+	}
+	if !g.Has(2) {
+		t.Fatalf("loaded value should be stored")
+	}
+	if _, err := g.Get(0); err != fail {
+		t.Fatalf("bad err: %v", err)
+	}
+	if _, err := g.GetIFPresent(3); err != KeyNotFoundError {
+		t.Fatalf("GetIFPresent should not load")
+	}
+}
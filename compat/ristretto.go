@@ -0,0 +1,68 @@
+package compat
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hnlq715/golang-lru"
+)
+
+// Ristretto implements the basic methods of github.com/dgraph-io/ristretto's
+// Cache on top of an lru.Cache. Capacity is counted in entries, so the
+// cost passed to Set is ignored, and writes are applied synchronously,
+// which makes Wait a no-op.
+type Ristretto struct {
+	c      *lru.Cache
+	closed int32
+}
+
+// NewRistretto creates a Ristretto holding up to size entries.
+func NewRistretto(size int) (*Ristretto, error) {
+	c, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &Ristretto{c: c}, nil
+}
+
+// Get returns the value of a key.
+func (r *Ristretto) Get(key interface{}) (interface{}, bool) {
+	return r.c.Get(key)
+}
+
+// Set inserts or updates the value of a key. It only fails once the
+// cache is closed.
+func (r *Ristretto) Set(key, value interface{}, cost int64) bool {
+	return r.SetWithTTL(key, value, cost, 0)
+}
+
+// SetWithTTL inserts or updates the value of a key with a TTL. It only
+// fails once the cache is closed.
+func (r *Ristretto) SetWithTTL(key, value interface{}, cost int64, ttl time.Duration) bool {
+	if atomic.LoadInt32(&r.closed) == 1 {
+		return false
+	}
+	r.c.AddEx(key, value, ttl)
+	return true
+}
+
+// Del removes a key.
+func (r *Ristretto) Del(key interface{}) {
+	r.c.Remove(key)
+}
+
+// Clear removes all the entries.
+func (r *Ristretto) Clear() {
+	r.c.Purge()
+}
+
+// Wait returns once all the previous writes are applied, which they
+// always are.
+func (r *Ristretto) Wait() {}
+
+// Close stops the cache from accepting new entries.
+func (r *Ristretto) Close() {
+	atomic.StoreInt32(&r.closed, 1)
+	r.c.Shutdown(context.Background())
+}
@@ -0,0 +1,44 @@
+package compat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRistretto(t *testing.T) {
+	r, err := NewRistretto(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !r.Set(1, 1, 100) || !r.SetWithTTL(2, 2, 1, time.Nanosecond) {
+		t.Fatalf("set should succeed")
+	}
+	r.Wait()
+	time.Sleep(time.Millisecond)
+
+	if v, ok := r.Get(1); !ok || v != 1 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if _, ok := r.Get(2); ok {
+		t.Fatalf("2 should be expired")
+	}
+
+	r.Del(1)
+	if _, ok := r.Get(1); ok {
+		t.Fatalf("1 should be deleted")
+	}
+	r.Set(3, 3, 1)
+	r.Clear()
+	if _, ok := r.Get(3); ok {
+		t.Fatalf("should be cleared")
+	}
+
+	r.Close()
+	if r.Set(4, 4, 1) {
+		t.Fatalf("set should fail after close")
+	}
+	if _, ok := r.Get(4); ok {
+		t.Fatalf("closed cache should not accept entries")
+	}
+}
@@ -0,0 +1,214 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLoaderPanic is returned to the callers waiting for a load of
+// GetOrCompute whose loader panicked.
+var ErrLoaderPanic = errors.New("lru: loader panicked")
+
+// call is a load in flight of GetOrCompute, shared by the callers
+// missing the same key.
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+
+	// waiters counts the callers sharing the load, and cancel cancels
+	// the context of a load started by GetOrComputeCtx once they all
+	// gave up
+	waiters int
+	cancel  context.CancelFunc
+
+	// slots bounds the loads of the class of the key, see LimitLoaders
+	slots chan struct{}
+
+	// panicked is set if the loader panicked, with the value it panicked
+	// with, which finishCall recovers from
+	panicked bool
+	panicVal interface{}
+}
+
+// GetOrCompute looks up a key's value from the cache, calling loader on
+// a miss and storing the value it returns. Concurrent misses of the same
+// key wait for a single call of loader and share its result. Errors are
+// returned to all the callers waiting for the load and are not cached.
+func (c *Cache) GetOrCompute(key interface{}, loader func() (interface{}, error)) (interface{}, error) {
+	return c.GetOrComputeEx(key, 0, loader)
+}
+
+// GetOrComputeEx is like GetOrCompute but stores the loaded value with
+// expire.
+func (c *Cache) GetOrComputeEx(key interface{}, expire time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	c.lock.Lock()
+	if value, ok := c.lru.Get(key); ok {
+		c.unlock()
+		return value, nil
+	}
+	if cl, ok := c.calls[key]; ok {
+		// This caller never gives up, so the load is never cancelled
+		cl.waiters++
+		c.unlock()
+		<-cl.done
+		return cl.value, cl.err
+	}
+	cl := c.startCall(key)
+	c.unlock()
+	c.finishCall(context.Background(), key, expire, cl, loader)
+	if cl.panicked {
+		// The caller running the loader gets its panic back
+		panic(cl.panicVal)
+	}
+	return cl.value, cl.err
+}
+
+// GetOrComputeCtx is like GetOrCompute for loaders honoring a context.
+// A caller whose ctx is done stops waiting and gets ctx.Err(), while the
+// load goes on for the other callers of the same key. The context passed
+// to loader carries the values of the ctx of the first caller, and is
+// cancelled when all the callers waiting for the load gave up, so the
+// deadline of one caller never fails the load for the others. The loader
+// runs in its own goroutine: if it panics, the caller that started the
+// load panics with the same value, the others get ErrLoaderPanic.
+func (c *Cache) GetOrComputeCtx(ctx context.Context, key interface{}, loader func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return c.GetOrComputeCtxEx(ctx, key, 0, loader)
+}
+
+// GetOrComputeCtxEx is like GetOrComputeCtx but stores the loaded value
+// with expire.
+func (c *Cache) GetOrComputeCtxEx(ctx context.Context, key interface{}, expire time.Duration, loader func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	if value, ok := c.lru.Get(key); ok {
+		c.unlock()
+		return value, nil
+	}
+	cl, joined := c.calls[key]
+	if !joined {
+		cl = c.startCall(key)
+		loadCtx, cancel := context.WithCancel(detachedContext{ctx})
+		cl.cancel = cancel
+		c.pending.Add(1)
+		go func() {
+			defer c.pending.Done()
+			defer cancel()
+			c.finishCall(loadCtx, key, expire, cl, func() (interface{}, error) {
+				return loader(loadCtx)
+			})
+		}()
+	}
+	cl.waiters++
+	c.unlock()
+
+	select {
+	case <-cl.done:
+		if cl.panicked && !joined {
+			panic(cl.panicVal)
+		}
+		return cl.value, cl.err
+	case <-ctx.Done():
+		c.lock.Lock()
+		if cl.waiters--; cl.waiters == 0 && cl.cancel != nil {
+			// The abandoned load is not joined by later callers, which
+			// start their own instead
+			cl.cancel()
+			if c.calls[key] == cl {
+				delete(c.calls, key)
+			}
+		}
+		c.unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of a context but not its cancellation
+// or deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return }
+func (detachedContext) Done() <-chan struct{}                   { return nil }
+func (detachedContext) Err() error                              { return nil }
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+// GetOrComputeStale is like GetOrComputeEx, but serves stale values: on
+// an expired entry still in the cache, see simplelru.WithStaleTTL, it
+// returns the expired value at once and reloads the key in the
+// background. A failed reload, including one whose loader panicked,
+// leaves the stale value in place.
+func (c *Cache) GetOrComputeStale(key interface{}, expire time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	c.lock.Lock()
+	value, expired, ok := c.lru.GetStale(key)
+	if ok {
+		if _, loading := c.calls[key]; expired && !loading && !c.closed {
+			cl := c.startCall(key)
+			c.pending.Add(1)
+			go func() {
+				defer c.pending.Done()
+				c.finishCall(context.Background(), key, expire, cl, loader)
+			}()
+		}
+		c.unlock()
+		return value, nil
+	}
+	c.unlock()
+	return c.GetOrComputeEx(key, expire, loader)
+}
+
+// startCall registers a load of key, the lock must be held
+func (c *Cache) startCall(key interface{}) *call {
+	cl := &call{done: make(chan struct{}), slots: c.loaderSlots(key)}
+	if c.calls == nil {
+		c.calls = make(map[interface{}]*call)
+	}
+	c.calls[key] = cl
+	return cl
+}
+
+// finishCall runs the load registered by startCall, once the class of
+// the key has a free slot or ctx is done, and stores its value. A loader
+// panicking fails the load with ErrLoaderPanic for the callers waiting
+// for it; the panic is recovered and kept in cl, so that loads running
+// in their own goroutine do not crash the process, and it is up to the
+// caller to panic again.
+func (c *Cache) finishCall(ctx context.Context, key interface{}, expire time.Duration, cl *call, loader func() (interface{}, error)) {
+	finished := false
+	defer func() {
+		if !finished {
+			cl.panicked, cl.panicVal = true, recover()
+			cl.value, cl.err = nil, ErrLoaderPanic
+		}
+		c.lock.Lock()
+		// An abandoned load stores nothing, a later load of the key may
+		// be running in its place
+		if c.calls[key] == cl {
+			delete(c.calls, key)
+			if cl.err == nil && !c.closed {
+				c.lru.AddEx(key, cl.value, expire)
+			}
+		}
+		c.unlock()
+		close(cl.done)
+	}()
+	if cl.slots != nil {
+		select {
+		case cl.slots <- struct{}{}:
+			defer func() { <-cl.slots }()
+		case <-ctx.Done():
+			cl.err = ctx.Err()
+			finished = true
+			return
+		}
+	}
+	cl.value, cl.err = loader()
+	finished = true
+}
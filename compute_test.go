@@ -0,0 +1,338 @@
+package lru
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestCacheGetOrCompute(t *testing.T) {
+	l, _ := New(4)
+	var loads int32
+	release := make(chan struct{})
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "v", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := l.GetOrCompute(1, loader)
+			if err != nil || v != "v" {
+				t.Errorf("bad value: %v %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if loads != 1 {
+		t.Fatalf("bad loads: %d", loads)
+	}
+	if v, ok := l.Get(1); !ok || v != "v" {
+		t.Fatalf("loaded value should be cached")
+	}
+
+	// Hits do not call the loader
+	if v, _ := l.GetOrCompute(1, loader); v != "v" || loads != 1 {
+		t.Fatalf("bad hit: %v %d", v, loads)
+	}
+}
+
+func TestCacheGetOrComputeError(t *testing.T) {
+	l, _ := New(4)
+	errLoad := errors.New("load")
+	if _, err := l.GetOrCompute(1, func() (interface{}, error) {
+		return nil, errLoad
+	}); err != errLoad {
+		t.Fatalf("bad err: %v", err)
+	}
+	if l.Contains(1) {
+		t.Fatalf("errors should not be cached")
+	}
+
+	v, err := l.GetOrComputeEx(1, time.Millisecond, func() (interface{}, error) {
+		return 1, nil
+	})
+	if err != nil || v != 1 {
+		t.Fatalf("bad value: %v %v", v, err)
+	}
+	if _, expire, ok := l.PeekWithExpireTime(1); !ok || expire == nil {
+		t.Fatalf("loaded value should expire")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if l.Contains(1) {
+		t.Fatalf("loaded value should have expired")
+	}
+}
+
+func TestCacheGetOrComputeStale(t *testing.T) {
+	l, _ := NewWithOptions(4, nil, simplelru.WithStaleTTL(time.Hour))
+	l.AddEx(1, "old", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	release := make(chan struct{})
+	var loads int32
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "new", nil
+	}
+
+	// Stale reads are served while a single reload runs
+	for i := 0; i < 3; i++ {
+		v, err := l.GetOrComputeStale(1, time.Hour, loader)
+		if err != nil || v != "old" {
+			t.Fatalf("bad stale value: %v %v", v, err)
+		}
+	}
+	close(release)
+	for i := 0; i < 100; i++ {
+		if _, expired, _ := l.GetStale(1); !expired {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("bad loads: %v", n)
+	}
+	if v, expired, ok := l.GetStale(1); !ok || expired || v != "new" {
+		t.Fatalf("bad reloaded value: %v %v %v", v, expired, ok)
+	}
+
+	// A miss loads synchronously
+	v, err := l.GetOrComputeStale(2, 0, func() (interface{}, error) { return 2, nil })
+	if err != nil || v != 2 {
+		t.Fatalf("bad loaded value: %v %v", v, err)
+	}
+}
+
+func TestCacheGetOrComputeCtx(t *testing.T) {
+	l, _ := New(4)
+	type ctxKey struct{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var loads int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		if ctx.Value(ctxKey{}) != "first" {
+			t.Errorf("loader context should carry the values of the caller")
+		}
+		close(started)
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// The first caller gives up, the second still gets the value
+	first, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "first"))
+	errs := make(chan error, 1)
+	go func() {
+		_, err := l.GetOrComputeCtx(first, 1, loader)
+		errs <- err
+	}()
+	<-started
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v, err := l.GetOrComputeCtx(context.Background(), 1, loader)
+		if err != nil || v != "value" {
+			t.Errorf("bad value: %v %v", v, err)
+		}
+	}()
+	for {
+		l.lock.RLock()
+		waiters := l.calls[1].waiters
+		l.lock.RUnlock()
+		if waiters == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("bad error: %v", err)
+	}
+	close(release)
+	wg.Wait()
+	if v, ok := l.Get(1); !ok || v != "value" || atomic.LoadInt32(&loads) != 1 {
+		t.Fatalf("bad cached value: %v, loads %v", v, loads)
+	}
+}
+
+func TestCacheGetOrComputeCtxCancelled(t *testing.T) {
+	l, _ := New(4)
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := l.GetOrComputeCtx(ctx, 1, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	// The load is cancelled once no caller waits, and nothing is cached
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("loader not cancelled")
+	}
+	for {
+		l.lock.RLock()
+		_, loading := l.calls[1]
+		l.lock.RUnlock()
+		if !loading {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	v, err := l.GetOrComputeCtx(context.Background(), 1, func(ctx context.Context) (interface{}, error) {
+		return 1, nil
+	})
+	if err != nil || v != 1 {
+		t.Fatalf("bad value: %v %v", v, err)
+	}
+	if _, err := l.GetOrComputeCtx(ctx, 2, nil); err != context.DeadlineExceeded {
+		t.Fatalf("done context should fail at once: %v", err)
+	}
+}
+
+func TestCacheGetOrComputeSoftTTL(t *testing.T) {
+	l, _ := New(4)
+	l.AddExSoft(1, "old", time.Millisecond, time.Hour)
+	time.Sleep(2 * time.Millisecond)
+	reloaded := make(chan struct{})
+	v, err := l.GetOrComputeStale(1, time.Hour, func() (interface{}, error) {
+		defer close(reloaded)
+		return "new", nil
+	})
+	if v != "old" || err != nil {
+		t.Fatalf("stale value should be served: %v %v", v, err)
+	}
+	<-reloaded
+	l.Shutdown(context.Background())
+	if v, _ := l.Peek(1); v != "new" {
+		t.Fatalf("stale value should be refreshed: %v", v)
+	}
+}
+
+func TestCacheGetOrComputePanic(t *testing.T) {
+	l, _ := New(4)
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		l.GetOrCompute(1, func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("load")
+		})
+	}()
+	<-started
+	waiter := make(chan error)
+	go func() {
+		_, err := l.GetOrCompute(1, nil)
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-waiter; err != ErrLoaderPanic {
+		t.Fatalf("bad err: %v", err)
+	}
+
+	// The key is not left loading
+	v, err := l.GetOrCompute(1, func() (interface{}, error) {
+		return 1, nil
+	})
+	if err != nil || v != 1 {
+		t.Fatalf("bad value: %v %v", v, err)
+	}
+}
+
+func TestCacheGetOrComputePanicBackground(t *testing.T) {
+	l, _ := NewWithOptions(4, nil, simplelru.WithStaleTTL(time.Hour))
+	started, release := make(chan struct{}), make(chan struct{})
+	owner := make(chan interface{})
+	go func() {
+		defer func() { owner <- recover() }()
+		l.GetOrComputeCtx(context.Background(), 1, func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			panic("load")
+		})
+	}()
+	<-started
+	waiter := make(chan error)
+	go func() {
+		_, err := l.GetOrComputeCtx(context.Background(), 1, nil)
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if r := <-owner; r != "load" {
+		t.Fatalf("the starting caller should panic: %v", r)
+	}
+	if err := <-waiter; err != ErrLoaderPanic {
+		t.Fatalf("bad err: %v", err)
+	}
+
+	// A panicking reload leaves the stale value in place
+	l.AddEx(2, "old", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if v, err := l.GetOrComputeStale(2, time.Hour, func() (interface{}, error) {
+		panic("reload")
+	}); v != "old" || err != nil {
+		t.Fatalf("bad stale value: %v %v", v, err)
+	}
+	l.Shutdown(context.Background())
+	if v, expired, ok := l.GetStale(2); v != "old" || !expired || !ok {
+		t.Fatalf("stale value should stay: %v %v %v", v, expired, ok)
+	}
+}
+
+func TestCacheGetOrComputeCtxAbandoned(t *testing.T) {
+	l, _ := New(4)
+	ctx, cancel := context.WithCancel(context.Background())
+	started, finish := make(chan struct{}), make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := l.GetOrComputeCtx(ctx, 1, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		<-finish
+		return "abandoned", nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("bad err: %v", err)
+	}
+
+	// The abandoned load is still running, a new caller starts afresh
+	v, err := l.GetOrComputeCtx(context.Background(), 1, func(ctx context.Context) (interface{}, error) {
+		return "fresh", nil
+	})
+	if err != nil || v != "fresh" {
+		t.Fatalf("bad value: %v %v", v, err)
+	}
+	close(finish)
+	l.Shutdown(context.Background())
+	if v, _ := l.Get(1); v != "fresh" {
+		t.Fatalf("abandoned load should not be stored: %v", v)
+	}
+}
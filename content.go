@@ -0,0 +1,74 @@
+package lru
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ContentHash derives the key of a value in a ContentCache.
+type ContentHash func(value []byte) string
+
+// SHA256Hash is the default ContentHash, the hex encoded SHA-256 digest
+// of the value.
+func SHA256Hash(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentCache is a thread-safe fixed size LRU cache of content-addressed
+// blobs: keys are derived from the values by hashing them, so identical
+// content is stored once. Entries are immutable, a key always maps to
+// the content it was derived from.
+type ContentCache struct {
+	c    *Cache
+	hash ContentHash
+}
+
+// NewContentCache creates a ContentCache of the given size keyed by hash,
+// or by SHA256Hash if hash is nil.
+func NewContentCache(size int, hash ContentHash) (*ContentCache, error) {
+	c, err := New(size)
+	if err != nil {
+		return nil, err
+	}
+	if hash == nil {
+		hash = SHA256Hash
+	}
+	return &ContentCache{c: c, hash: hash}, nil
+}
+
+// AddValue stores value under the key derived from it and returns the
+// key. Adding content already in the cache only refreshes its recent-ness.
+// The cache keeps a copy of value, so the caller may reuse it.
+func (c *ContentCache) AddValue(value []byte) (key string) {
+	key = c.hash(value)
+	if _, ok := c.c.Get(key); !ok {
+		c.c.AddImmutable(key, append([]byte(nil), value...), 0)
+	}
+	return key
+}
+
+// Get looks up the content of a key.
+func (c *ContentCache) Get(key string) ([]byte, bool) {
+	v, ok := c.c.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
+
+// Contains checks if the content of a key is in the cache, without
+// updating its recent-ness.
+func (c *ContentCache) Contains(key string) bool {
+	return c.c.Contains(key)
+}
+
+// Remove removes the content of a key from the cache.
+func (c *ContentCache) Remove(key string) {
+	c.c.Remove(key)
+}
+
+// Len returns the number of distinct contents in the cache.
+func (c *ContentCache) Len() int {
+	return c.c.Len()
+}
@@ -0,0 +1,51 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestContentCache(t *testing.T) {
+	c, err := NewContentCache(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	k1 := c.AddValue([]byte("hello"))
+	if k1 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("bad key: %v", k1)
+	}
+	if k := c.AddValue([]byte("hello")); k != k1 || c.Len() != 1 {
+		t.Fatalf("identical content should be deduplicated")
+	}
+	if v, ok := c.Get(k1); !ok || string(v) != "hello" {
+		t.Fatalf("bad: %s, %v", v, ok)
+	}
+
+	k2 := c.AddValue([]byte("world"))
+	c.AddValue([]byte("hello"))
+	c.AddValue([]byte("!"))
+	if !c.Contains(k1) || c.Contains(k2) {
+		t.Fatalf("re-adding content should refresh it")
+	}
+
+	c.Remove(k1)
+	if c.Contains(k1) {
+		t.Fatalf("should be removed")
+	}
+
+	buf := []byte("reused")
+	k3 := c.AddValue(buf)
+	copy(buf, "REUSED")
+	if v, ok := c.Get(k3); !ok || string(v) != "reused" || SHA256Hash(v) != k3 {
+		t.Fatalf("the cache should keep its own copy: %s, %v", v, ok)
+	}
+}
+
+func TestContentCache_Hash(t *testing.T) {
+	c, _ := NewContentCache(2, func(v []byte) string {
+		return string(v[:1])
+	})
+	if k := c.AddValue([]byte("abc")); k != "a" {
+		t.Fatalf("bad key: %v", k)
+	}
+}
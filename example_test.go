@@ -0,0 +1,64 @@
+package lru_test
+
+import (
+	"fmt"
+	"time"
+
+	lru "github.com/hnlq715/golang-lru"
+)
+
+func ExampleCache() {
+	l, _ := lru.New(128)
+	for i := 0; i < 256; i++ {
+		l.Add(i, nil)
+	}
+	fmt.Println(l.Len())
+	// Output: 128
+}
+
+func ExampleCache_ContainsOrAdd() {
+	l, _ := lru.New(2)
+	fmt.Println(l.ContainsOrAdd("a", 1))
+	fmt.Println(l.ContainsOrAdd("a", 2))
+	v, _ := l.Get("a")
+	fmt.Println(v)
+	// Output:
+	// false false
+	// true false
+	// 1
+}
+
+func ExampleCache_AddEx() {
+	l, _ := lru.NewWithExpire(2, time.Hour)
+	l.AddEx("a", 1, 10*time.Millisecond)
+	l.Add("b", 2)
+	time.Sleep(20 * time.Millisecond)
+
+	fmt.Println(l.Contains("a"), l.Contains("b"))
+	// Output: false true
+}
+
+func ExampleARCCache() {
+	l, _ := lru.NewARC(2)
+	l.Add("a", 1)
+	l.Get("a")
+	l.Add("b", 2)
+	l.Add("c", 3)
+
+	// a was used twice and survives the one-off keys
+	fmt.Println(l.Contains("a"), l.Contains("b"), l.Contains("c"))
+	// Output: true false true
+}
+
+func ExampleTwoQueueCache() {
+	l, _ := lru.New2Q(4)
+	l.Add("a", 1)
+	l.Get("a")
+	for _, k := range []string{"b", "c", "d", "e", "f"} {
+		l.Add(k, k)
+	}
+
+	// a was promoted to the frequent queue and survives the scan
+	fmt.Println(l.Contains("a"))
+	// Output: true
+}
@@ -0,0 +1,344 @@
+// Package expirable provides a thread safe LRU cache whose entries all
+// live for the same time to live, with an optional size cap.
+//
+// The entries are grouped in buckets by expire time, and a background
+// goroutine removes one bucket of expired entries at a time, so expired
+// entries are reclaimed without scanning the whole cache. It shares the
+// list of the simplelru package.
+package expirable
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// numBuckets is the number of buckets the time to live is divided in:
+// the background goroutine wakes up every ttl/numBuckets, or every
+// minSweepPeriod for a shorter ttl, to remove the expired entries of the
+// oldest bucket
+const numBuckets = 100
+
+// minSweepPeriod bounds how often the background goroutine wakes up, so
+// that a ttl of a few nanoseconds does not make it spin. Entries of such
+// a ttl stay resident up to numBuckets*minSweepPeriod, though lookups
+// never return them once expired.
+const minSweepPeriod = time.Millisecond
+
+// EvictCallback is used to get a callback when a cache entry is evicted
+type EvictCallback[K comparable, V any] func(key K, value V)
+
+// LRU implements a thread safe LRU cache with expiration. A size of 0
+// means the cache is unbounded and entries only leave it when expired or
+// removed.
+type LRU[K comparable, V any] struct {
+	lock      sync.Mutex
+	size      int
+	ttl       time.Duration
+	evictList *simplelru.List
+	items     map[K]*simplelru.Element
+	onEvict   EvictCallback[K, V]
+	clock     simplelru.Clock
+
+	// buckets group the entries by expire time, next is the bucket the
+	// background goroutine sweeps next. New entries go to that bucket
+	// too: a round of sweeps later, it is the first swept once they
+	// expired
+	buckets [numBuckets]map[K]*simplelru.Element
+	next    int
+
+	stop      func()
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// entry is used to hold a value in the evictList
+type entry[K comparable, V any] struct {
+	key    K
+	value  V
+	expire time.Time
+	bucket int
+}
+
+// NewLRU constructs an LRU of the given size whose entries expire after
+// ttl. A ttl <= 0 disables expiration. Close stops the background
+// goroutine removing the expired entries.
+func NewLRU[K comparable, V any](size int, onEvict EvictCallback[K, V], ttl time.Duration) (*LRU[K, V], error) {
+	return NewLRUWithClock(size, onEvict, ttl, simplelru.SystemClock)
+}
+
+// NewLRUWithClock is like NewLRU with expire times read from clock, see
+// simplelru.WithClock.
+func NewLRUWithClock[K comparable, V any](size int, onEvict EvictCallback[K, V], ttl time.Duration, clock simplelru.Clock) (*LRU[K, V], error) {
+	if size < 0 {
+		return nil, errors.New("must provide a non-negative size")
+	}
+	c := &LRU[K, V]{
+		size:      size,
+		ttl:       ttl,
+		evictList: simplelru.New(),
+		items:     make(map[K]*simplelru.Element),
+		onEvict:   onEvict,
+		clock:     clock,
+		done:      make(chan struct{}),
+	}
+	for i := range c.buckets {
+		c.buckets[i] = make(map[K]*simplelru.Element)
+	}
+	if ttl > 0 {
+		period := ttl / numBuckets
+		if period < minSweepPeriod {
+			period = minSweepPeriod
+		}
+		tick, stop := clock.NewTicker(period)
+		c.stop = stop
+		go c.sweep(tick)
+	}
+	return c, nil
+}
+
+// Close stops the background goroutine removing the expired entries. The
+// cache stays usable, but expired entries then stay until evicted or
+// removed.
+func (c *LRU[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		if c.stop != nil {
+			c.stop()
+		}
+		close(c.done)
+	})
+}
+
+func (c *LRU[K, V]) sweep(tick <-chan time.Time) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-tick:
+			c.deleteExpired()
+		}
+	}
+}
+
+// deleteExpired removes the expired entries of the next bucket, the
+// entries of the bucket not expired yet are left for the next round
+func (c *LRU[K, V]) deleteExpired() {
+	var evicted []*entry[K, V]
+	c.lock.Lock()
+	now := c.clock.Now()
+	for _, ent := range c.buckets[c.next] {
+		if kv := ent.Value.(*entry[K, V]); now.After(kv.expire) {
+			evicted = append(evicted, c.removeElement(ent))
+		}
+	}
+	c.next = (c.next + 1) % numBuckets
+	c.lock.Unlock()
+	c.evicted(evicted)
+}
+
+// Purge is used to completely clear the cache.
+func (c *LRU[K, V]) Purge() {
+	var evicted []*entry[K, V]
+	c.lock.Lock()
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Back() {
+		evicted = append(evicted, c.removeElement(ent))
+	}
+	c.lock.Unlock()
+	c.evicted(evicted)
+}
+
+// Add adds a value to the cache, or updates it and its expire time.
+// Returns true if an eviction occurred.
+func (c *LRU[K, V]) Add(key K, value V) (evicted bool) {
+	var removed []*entry[K, V]
+	c.lock.Lock()
+	now := c.clock.Now()
+	if ent, ok := c.items[key]; ok {
+		kv := ent.Value.(*entry[K, V])
+		kv.value = value
+		c.evictList.MoveToFront(ent)
+		c.setExpire(ent, now)
+		c.lock.Unlock()
+		return false
+	}
+	ent := c.evictList.PushFront(&entry[K, V]{key: key, value: value})
+	c.items[key] = ent
+	c.setExpire(ent, now)
+	if c.size > 0 && c.evictList.Len() > c.size {
+		if oldest := c.evictList.Back(); oldest != nil {
+			removed = append(removed, c.removeElement(oldest))
+		}
+	}
+	c.lock.Unlock()
+	c.evicted(removed)
+	return len(removed) > 0
+}
+
+// setExpire sets the expire time of an entry counting from now and moves
+// it to the bucket swept first after it expires
+func (c *LRU[K, V]) setExpire(ent *simplelru.Element, now time.Time) {
+	kv := ent.Value.(*entry[K, V])
+	delete(c.buckets[kv.bucket], kv.key)
+	kv.expire = now.Add(c.ttl)
+	kv.bucket = c.next
+	c.buckets[kv.bucket][kv.key] = ent
+}
+
+// Get looks up a key's value from the cache, marking it as recently used.
+func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ent, ok := c.items[key]
+	if !ok || c.expired(ent) {
+		return value, false
+	}
+	c.evictList.MoveToFront(ent)
+	return ent.Value.(*entry[K, V]).value, true
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (c *LRU[K, V]) Contains(key K) (ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ent, ok := c.items[key]
+	return ok && !c.expired(ent)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ent, ok := c.items[key]
+	if !ok || c.expired(ent) {
+		return value, false
+	}
+	return ent.Value.(*entry[K, V]).value, true
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *LRU[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	ent, ok := c.items[key]
+	if !ok {
+		c.lock.Unlock()
+		return false
+	}
+	kv := c.removeElement(ent)
+	c.lock.Unlock()
+	c.evicted([]*entry[K, V]{kv})
+	return true
+}
+
+// RemoveOldest removes the oldest item from the cache.
+func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	c.lock.Lock()
+	ent := c.evictList.Back()
+	if ent == nil {
+		c.lock.Unlock()
+		return key, value, false
+	}
+	kv := c.removeElement(ent)
+	c.lock.Unlock()
+	c.evicted([]*entry[K, V]{kv})
+	return kv.key, kv.value, true
+}
+
+// GetOldest returns the oldest live entry
+func (c *LRU[K, V]) GetOldest() (key K, value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if !c.expired(ent) {
+			kv := ent.Value.(*entry[K, V])
+			return kv.key, kv.value, true
+		}
+	}
+	return key, value, false
+}
+
+// Keys returns a slice of the live keys in the cache, from oldest to
+// newest.
+func (c *LRU[K, V]) Keys() []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, 0, len(c.items))
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if !c.expired(ent) {
+			keys = append(keys, ent.Value.(*entry[K, V]).key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of the live values in the cache, from oldest to
+// newest.
+func (c *LRU[K, V]) Values() []V {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	values := make([]V, 0, len(c.items))
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		if !c.expired(ent) {
+			values = append(values, ent.Value.(*entry[K, V]).value)
+		}
+	}
+	return values
+}
+
+// Len returns the number of items in the cache, including the expired
+// entries not removed yet.
+func (c *LRU[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.evictList.Len()
+}
+
+// Cap returns the maximum number of items the cache can hold, 0 if it is
+// unbounded.
+func (c *LRU[K, V]) Cap() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.size
+}
+
+// Resize changes the cache size, 0 making it unbounded. Returns the
+// number of evicted entries.
+func (c *LRU[K, V]) Resize(size int) (evicted int) {
+	var removed []*entry[K, V]
+	c.lock.Lock()
+	c.size = size
+	for size > 0 && c.evictList.Len() > size {
+		removed = append(removed, c.removeElement(c.evictList.Back()))
+	}
+	c.lock.Unlock()
+	c.evicted(removed)
+	return len(removed)
+}
+
+// expired returns if the entry is expired, the lock must be held
+func (c *LRU[K, V]) expired(ent *simplelru.Element) bool {
+	return c.ttl > 0 && c.clock.Now().After(ent.Value.(*entry[K, V]).expire)
+}
+
+// removeElement removes an entry from the cache, the lock must be held
+func (c *LRU[K, V]) removeElement(ent *simplelru.Element) *entry[K, V] {
+	kv := c.evictList.Remove(ent).(*entry[K, V])
+	delete(c.items, kv.key)
+	delete(c.buckets[kv.bucket], kv.key)
+	return kv
+}
+
+// evicted calls the eviction callback for the removed entries, without
+// the lock held so that it may use the cache
+func (c *LRU[K, V]) evicted(removed []*entry[K, V]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, kv := range removed {
+		c.onEvict(kv.key, kv.value)
+	}
+}
@@ -0,0 +1,211 @@
+package expirable
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestLRU(t *testing.T) {
+	evicted := 0
+	l, err := NewLRU[int, int](128, func(k, v int) {
+		if k != v {
+			t.Fatalf("Evict values not equal (%v!=%v)", k, v)
+		}
+		evicted++
+	}, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if evicted != 128 {
+		t.Fatalf("bad evict count: %v", evicted)
+	}
+	for i, k := range l.Keys() {
+		if v, ok := l.Get(k); !ok || v != k || v != i+128 {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+	if _, ok := l.Get(0); ok {
+		t.Fatalf("should be evicted")
+	}
+	if !l.Remove(200) || l.Contains(200) || l.Remove(200) {
+		t.Fatalf("bad remove")
+	}
+	if k, _, ok := l.GetOldest(); !ok || k != 128 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || k != 128 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	if n := l.Resize(10); n != 116 || l.Len() != 10 {
+		t.Fatalf("bad resize: %v %v", n, l.Len())
+	}
+	l.Purge()
+	if l.Len() != 0 || l.evictList.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if _, err := NewLRU[int, int](-1, nil, 0); err == nil {
+		t.Fatalf("should fail on a negative size")
+	}
+}
+
+func TestLRU_Unbounded(t *testing.T) {
+	l, err := NewLRU[int, int](0, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+	for i := 0; i < 1000; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("should not evict")
+		}
+	}
+	if l.Len() != 1000 || l.Cap() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestLRU_Expire(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Unix(0, 0))
+	evicted := make(chan string, 2)
+	l, err := NewLRUWithClock[string, int](0, func(k string, v int) {
+		evicted <- k
+	}, 100*time.Second, clock)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	l.Add("a", 1)
+	clock.Advance(50 * time.Second)
+	l.Add("b", 2)
+	if v, ok := l.Peek("a"); !ok || v != 1 {
+		t.Fatalf("bad: %v", v)
+	}
+
+	clock.Advance(51 * time.Second)
+	if _, ok := l.Get("a"); ok || l.Contains("a") {
+		t.Fatalf("should be expired")
+	}
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []string{"b"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if values := l.Values(); !reflect.DeepEqual(values, []int{2}) {
+		t.Fatalf("bad values: %v", values)
+	}
+
+	// Updating an entry renews its expire time
+	l.Add("b", 3)
+	clock.Advance(60 * time.Second)
+	if v, ok := l.Get("b"); !ok || v != 3 {
+		t.Fatalf("bad: %v", v)
+	}
+
+	// The sweeper removes the expired entry within a round of ticks
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case k := <-evicted:
+			// b may share the bucket of a, if the sweeper missed ticks
+			if k == "a" {
+				return
+			}
+		default:
+			clock.Advance(time.Second)
+			time.Sleep(time.Millisecond)
+		}
+	}
+	t.Fatalf("should be swept")
+}
+
+func TestLRU_BucketSweep(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Unix(0, 0))
+	l, err := NewLRUWithClock[int, int](0, nil, 100*time.Second, clock)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Close()
+	// Sweep a bucket every second, as the background goroutine does
+	sweep := func(n int) {
+		for i := 0; i < n; i++ {
+			clock.Advance(time.Second)
+			l.deleteExpired()
+		}
+	}
+
+	l.Add(1, 1)
+	sweep(30)
+	l.Add(2, 2)
+
+	// Sweeping bucket by bucket only reaches the entries of their bucket,
+	// the first swept after they expired
+	sweep(70)
+	if l.Len() != 2 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	sweep(1)
+	if l.Len() != 1 || !l.Contains(2) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+	sweep(29)
+	if l.Len() != 1 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	sweep(1)
+	if l.Len() != 0 {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
+
+// tickerClock records the period of the tickers it creates
+type tickerClock struct {
+	simplelru.Clock
+	period time.Duration
+}
+
+func (c *tickerClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	c.period = d
+	return c.Clock.NewTicker(d)
+}
+
+func TestLRU_SweepPeriod(t *testing.T) {
+	for _, c := range []struct {
+		ttl, period time.Duration
+	}{
+		{time.Second, 10 * time.Millisecond},
+		{50 * time.Millisecond, time.Millisecond},
+		{50 * time.Nanosecond, time.Millisecond},
+	} {
+		clock := &tickerClock{Clock: simplelru.SystemClock}
+		l, _ := NewLRUWithClock[int, int](0, nil, c.ttl, clock)
+		l.Close()
+		if clock.period != c.period {
+			t.Fatalf("bad period for %v: %v", c.ttl, clock.period)
+		}
+	}
+}
+
+func TestLRU_ShortTTL(t *testing.T) {
+	l, err := NewLRU[int, int](0, nil, 50*time.Nanosecond)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+	l.Add(1, 1)
+	for i := 0; i < 1000 && l.Len() != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if l.Len() != 0 {
+		t.Fatalf("should be swept")
+	}
+}
@@ -0,0 +1,222 @@
+package lru
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+)
+
+// GDSCache is a thread-safe fixed size cache using the GreedyDual-Size
+// replacement policy. Every entry carries the cost of fetching it again
+// from its origin. The cache evicts the entry with the lowest credit,
+// where an entry's credit is set to the cost plus an inflation value on
+// insert and on every hit, and the inflation value rises to the credit
+// of each evicted entry. Cheap entries are therefore evicted first,
+// while expensive ones survive as long as they keep being used.
+//
+// In the GDSF variant the cost is multiplied by the number of accesses,
+// which also favors frequently used entries.
+type GDSCache struct {
+	size      int
+	freq      bool
+	inflation float64
+	items     map[interface{}]*gdsEntry
+	heap      gdsHeap
+	lock      sync.Mutex
+}
+
+// gdsEntry is used to hold a value in the GDSCache
+type gdsEntry struct {
+	key   interface{}
+	value interface{}
+	cost  float64
+	hits  float64
+	h     float64
+	index int
+}
+
+// NewGDS creates a GreedyDual-Size cache of the given size.
+func NewGDS(size int) (*GDSCache, error) {
+	return newGDS(size, false)
+}
+
+// NewGDSF creates a GreedyDual-Size-Frequency cache of the given size.
+func NewGDSF(size int) (*GDSCache, error) {
+	return newGDS(size, true)
+}
+
+func newGDS(size int, freq bool) (*GDSCache, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size")
+	}
+	c := &GDSCache{
+		size:  size,
+		freq:  freq,
+		items: make(map[interface{}]*gdsEntry),
+	}
+	return c, nil
+}
+
+// Add adds a value to the cache along with the cost of fetching it
+// again. Returns true if an eviction occurred.
+func (c *GDSCache) Add(key, value interface{}, cost float64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		e.cost = cost
+		c.touch(e)
+		return false
+	}
+
+	evict := len(c.items) >= c.size
+	if evict {
+		e := heap.Pop(&c.heap).(*gdsEntry)
+		c.inflation = e.h
+		delete(c.items, e.key)
+	}
+
+	e := &gdsEntry{key: key, value: value, cost: cost}
+	e.hits = 1
+	e.h = c.credit(e)
+	heap.Push(&c.heap, e)
+	c.items[key] = e
+	return evict
+}
+
+// Get looks up a key's value from the cache, restoring its credit.
+func (c *GDSCache) Get(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.touch(e)
+	return e.value, true
+}
+
+// Peek returns the key's value without updating its credit.
+func (c *GDSCache) Peek(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	return nil, false
+}
+
+// Contains checks if a key is in the cache without updating its credit.
+func (c *GDSCache) Contains(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache.
+func (c *GDSCache) Remove(key interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		heap.Remove(&c.heap, e.index)
+		delete(c.items, key)
+	}
+}
+
+// Purge is used to completely clear the cache
+func (c *GDSCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items = make(map[interface{}]*gdsEntry)
+	c.heap = nil
+	c.inflation = 0
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be
+// evicted to the last.
+func (c *GDSCache) Keys() []interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	h := make(gdsHeap, len(c.heap))
+	copy(h, c.heap)
+	keys := make([]interface{}, 0, len(h))
+	for len(h) > 0 {
+		// Pop from a copy so the entries' indexes are left alone
+		e := h[0]
+		keys = append(keys, e.key)
+		h[0] = h[len(h)-1]
+		h = h[:len(h)-1]
+		gdsDown(h, 0)
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *GDSCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.items)
+}
+
+// touch records an access to e, the lock must be held
+func (c *GDSCache) touch(e *gdsEntry) {
+	e.hits++
+	e.h = c.credit(e)
+	heap.Fix(&c.heap, e.index)
+}
+
+// credit returns the credit of e given the current inflation value
+func (c *GDSCache) credit(e *gdsEntry) float64 {
+	if c.freq {
+		return c.inflation + e.hits*e.cost
+	}
+	return c.inflation + e.cost
+}
+
+// gdsHeap is a min-heap of entries ordered by credit
+type gdsHeap []*gdsEntry
+
+func (h gdsHeap) Len() int           { return len(h) }
+func (h gdsHeap) Less(i, j int) bool { return h[i].h < h[j].h }
+
+func (h gdsHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *gdsHeap) Push(x interface{}) {
+	e := x.(*gdsEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *gdsHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// gdsDown sifts down element i of h without touching entry indexes
+func gdsDown(h []*gdsEntry, i int) {
+	for {
+		l := 2*i + 1
+		if l >= len(h) {
+			return
+		}
+		m := l
+		if r := l + 1; r < len(h) && h[r].h < h[l].h {
+			m = r
+		}
+		if h[i].h <= h[m].h {
+			return
+		}
+		h[i], h[m] = h[m], h[i]
+		i = m
+	}
+}
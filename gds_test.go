@@ -0,0 +1,113 @@
+package lru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func BenchmarkGDS_Rand(b *testing.B) {
+	l, err := NewGDS(8192)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		trace[i] = rand.Int63() % 32768
+	}
+
+	b.ResetTimer()
+
+	var hit, miss int
+	for i := 0; i < 2*b.N; i++ {
+		if i%2 == 0 {
+			l.Add(trace[i], trace[i], float64(trace[i]%8+1))
+		} else {
+			_, ok := l.Get(trace[i])
+			if ok {
+				hit++
+			} else {
+				miss++
+			}
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
+}
+
+func TestGDS(t *testing.T) {
+	l, err := NewGDS(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := NewGDS(0); err == nil {
+		t.Fatalf("should fail")
+	}
+
+	l.Add(1, 1, 10)
+	l.Add(2, 2, 1)
+	if !l.Add(3, 3, 1) {
+		t.Fatalf("should evict")
+	}
+	if l.Contains(2) || !l.Contains(1) {
+		t.Fatalf("the cheap entry should have been evicted")
+	}
+
+	// Inflation rose to 1, so 3 now has credit 2 and 1 keeps 10
+	l.Add(4, 4, 1)
+	if l.Contains(3) || !l.Contains(1) {
+		t.Fatalf("3 should have been evicted")
+	}
+
+	// Cheap entries age out the expensive one once inflation catches up
+	for i := 5; i < 20; i++ {
+		l.Add(i, i, 1)
+	}
+	if l.Contains(1) {
+		t.Fatalf("1 should have aged out")
+	}
+	if l.Len() != 2 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	l.Remove(19)
+	if l.Contains(19) || l.Len() != 1 {
+		t.Fatalf("19 should be removed")
+	}
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestGDS_Keys(t *testing.T) {
+	l, _ := NewGDS(3)
+	l.Add(1, 1, 3)
+	l.Add(2, 2, 1)
+	l.Add(3, 3, 2)
+
+	keys := l.Keys()
+	if len(keys) != 3 || keys[0] != 2 || keys[1] != 3 || keys[2] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if v, ok := l.Peek(3); !ok || v != 3 {
+		t.Fatalf("bad: %v", v)
+	}
+	// Keys must not disturb the heap
+	l.Add(4, 4, 5)
+	if l.Contains(2) {
+		t.Fatalf("2 should have been evicted")
+	}
+}
+
+func TestGDSF(t *testing.T) {
+	l, _ := NewGDSF(2)
+	l.Add(1, 1, 1)
+	l.Add(2, 2, 1)
+	for i := 0; i < 5; i++ {
+		l.Get(1)
+	}
+	l.Add(3, 3, 1)
+	if !l.Contains(1) || l.Contains(2) {
+		t.Fatalf("the frequent entry should survive")
+	}
+}
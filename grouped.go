@@ -0,0 +1,479 @@
+package lru
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// GroupKey is the key a Group stores an entry under in the shared LRU of
+// a GroupedCache, as seen by the eviction callbacks set by options.
+type GroupKey struct {
+	Group string
+	Key   interface{}
+}
+
+// GroupStats holds the counters of a Group.
+type GroupStats struct {
+	Len     int // Len is the number of entries of the group
+	MaxSize int // MaxSize is the quota of the group, 0 if none
+
+	Hits      uint64 // Hits is the number of successful Gets
+	Misses    uint64 // Misses is the number of Gets of absent or expired keys
+	Evictions uint64 // Evictions counts the entries evicted or expired
+}
+
+// GroupedCache is a thread safe LRU cache divided in named groups, such as
+// one per kind of data, which share the capacity and the eviction order
+// of a single LRU: the memory goes to the groups used the most, instead
+// of being split up front between independent caches. A group may be
+// capped by a quota, see Group.SetMaxSize.
+type GroupedCache struct {
+	lock   sync.Mutex
+	lru    *simplelru.LRU
+	groups map[string]*Group
+
+	// removed counts the entries that left the cache, to tell if an Add
+	// added an entry
+	removed int
+
+	// fairShare and fairWindow configure the fairness mode, see
+	// SetFairness
+	fairShare  float64
+	fairWindow time.Duration
+
+	// evictedKV buffers the entries evicted while the lock is held for
+	// unlock to pass them to the eviction callback set by options
+	evictedKV []keyValue
+	onReason  simplelru.EvictCallbackWithReason
+
+	// seq numbers the accesses tracked in the fairness mode
+	seq uint64
+}
+
+// Group is a namespace of a GroupedCache, returned by
+// GroupedCache.Group. Its keys do not collide with the keys of the other
+// groups.
+type Group struct {
+	name string
+	c    *GroupedCache
+
+	// the counters are guarded by the lock of c
+	maxSize   int
+	len       int
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	// windowStart is the start of the current fairness window, and
+	// windowBase and windowLost the number of entries of the group then
+	// and the number of them evicted by the other groups since
+	windowStart time.Time
+	windowBase  int
+	windowLost  int
+
+	// order lists the keys of the group from the most to the least
+	// recently used along with the sequence of their last access, and
+	// elems indexes it, both kept only in the fairness mode
+	order *simplelru.List
+	elems map[interface{}]*simplelru.Element
+}
+
+// groupAccess is the last access of a key of a Group
+type groupAccess struct {
+	key interface{}
+	seq uint64
+}
+
+// NewGrouped creates a GroupedCache holding size entries in total,
+// configured by the given options.
+func NewGrouped(size int, opts ...simplelru.Option) (*GroupedCache, error) {
+	lru, err := simplelru.NewLRUWithOptions(size, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := &GroupedCache{lru: lru, groups: make(map[string]*Group)}
+	c.onReason = lru.EvictCallbackWithReason()
+	lru.SetEvictCallbackWithReason(func(key, value interface{}, reason simplelru.EvictReason) {
+		c.evicted(key.(GroupKey), reason)
+		if c.onReason != nil {
+			c.evictedKV = append(c.evictedKV, keyValue{key, value, reason})
+		}
+	})
+	return c, nil
+}
+
+// unlock releases the lock, then invokes the eviction callback for the
+// entries evicted while it was held, so that it may call back into the
+// cache.
+func (c *GroupedCache) unlock() {
+	evicted := c.evictedKV
+	c.evictedKV = nil
+	c.lock.Unlock()
+	for _, kv := range evicted {
+		c.onReason(kv.key, kv.value, kv.reason)
+	}
+}
+
+// evicted updates the counters of the group of an entry leaving the
+// cache, the lock must be held
+func (c *GroupedCache) evicted(key GroupKey, reason simplelru.EvictReason) {
+	if reason == simplelru.EvictReplaced {
+		return
+	}
+	c.removed++
+	g := c.group(key.Group)
+	g.len--
+	g.forget(key.Key)
+	if reason == simplelru.EvictCapacity || reason == simplelru.EvictExpired {
+		g.evictions++
+	}
+}
+
+// Group returns the group of the given name, creating it if needed.
+func (c *GroupedCache) Group(name string) *Group {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.group(name)
+}
+
+// group is Group with the lock held
+func (c *GroupedCache) group(name string) *Group {
+	g, ok := c.groups[name]
+	if !ok {
+		g = &Group{name: name, c: c}
+		if c.fairWindow > 0 {
+			g.track()
+		}
+		c.groups[name] = g
+	}
+	return g
+}
+
+// Groups returns the names of the groups, sorted.
+func (c *GroupedCache) Groups() []string {
+	c.lock.Lock()
+	defer c.unlock()
+	names := make([]string, 0, len(c.groups))
+	for name := range c.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns the number of entries of all the groups.
+func (c *GroupedCache) Len() int {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Len()
+}
+
+// Cap returns the number of entries the groups can hold in total.
+func (c *GroupedCache) Cap() int {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Cap()
+}
+
+// Resize changes the total capacity of the groups.
+func (c *GroupedCache) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Resize(size)
+}
+
+// Purge removes the entries of all the groups.
+func (c *GroupedCache) Purge() {
+	c.lock.Lock()
+	defer c.unlock()
+	c.lru.Purge()
+}
+
+// SetFairness bounds the entries of a group the new keys of the other
+// groups can evict within each window to share of the entries the group
+// had at the start of the window, so that a burst in one group cannot
+// wipe out another. Once the victim of an Add belongs to a group at its
+// bound, the oldest entry of the adding group or of a group with room
+// left is evicted instead, and the Add is declined if there is none.
+// The fairness mode keeps the recency of the keys of each group, so that
+// choosing the victim takes a time proportional to the number of groups.
+// A window <= 0 disables the fairness mode.
+func (c *GroupedCache) SetFairness(share float64, window time.Duration) {
+	c.lock.Lock()
+	defer c.unlock()
+	enable := window > 0 && c.fairWindow <= 0
+	c.fairShare = share
+	c.fairWindow = window
+	for _, g := range c.groups {
+		if window <= 0 {
+			g.order, g.elems = nil, nil
+		} else if enable {
+			g.track()
+		}
+	}
+	if enable {
+		for _, key := range c.lru.Keys() {
+			k := key.(GroupKey)
+			c.groups[k.Group].accessed(k.Key)
+		}
+	}
+}
+
+// Stats returns the stats of the shared LRU.
+func (c *GroupedCache) Stats() simplelru.Stats {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Stats()
+}
+
+// Lookups returns the hit and miss counters of the shared LRU without
+// its scan for expired entries.
+func (c *GroupedCache) Lookups() (hits, misses uint64) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Lookups()
+}
+
+// GroupStats returns the stats of each group.
+func (c *GroupedCache) GroupStats() map[string]GroupStats {
+	c.lock.Lock()
+	defer c.unlock()
+	stats := make(map[string]GroupStats, len(c.groups))
+	for name, g := range c.groups {
+		stats[name] = g.stats()
+	}
+	return stats
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+func (g *Group) key(key interface{}) GroupKey {
+	return GroupKey{Group: g.name, Key: key}
+}
+
+// Add adds a value to the group.  Returns true if an eviction occurred.
+func (g *Group) Add(key, value interface{}) bool {
+	return g.AddEx(key, value, 0)
+}
+
+// AddEx adds a value to the group with expire. A new key of a group at
+// its quota evicts the oldest entry of the group, otherwise the oldest
+// entry of the cache is evicted when it is full. Returns true if an
+// eviction occurred.
+func (g *Group) AddEx(key, value interface{}, expire time.Duration) bool {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	k := g.key(key)
+	if g.c.lru.Contains(k) {
+		g.accessed(key)
+		return g.c.lru.AddEx(k, value, expire)
+	}
+	evict := false
+	for g.maxSize > 0 && g.len >= g.maxSize && g.removeOldest() {
+		evict = true
+	}
+	if g.c.fairWindow > 0 && g.c.lru.Len() >= g.c.lru.Cap() {
+		if !g.makeFairRoom() {
+			return evict
+		}
+		evict = true
+	}
+	// The Add may be declined, e.g. by an admission policy, or replace an
+	// expired entry of the key
+	n, removed := g.c.lru.Len(), g.c.removed
+	if g.c.lru.AddEx(k, value, expire) {
+		evict = true
+	}
+	if g.c.lru.Len()-n+g.c.removed-removed > 0 {
+		g.len++
+		g.accessed(key)
+	}
+	return evict
+}
+
+// removeOldest evicts the oldest entry of the group to honor its quota,
+// expired or not, the lock must be held. Finding it walks the shared LRU
+// from its oldest entry.
+func (g *Group) removeOldest() bool {
+	_, ok := g.c.lru.EvictOldestFunc(func(key interface{}) bool {
+		return key.(GroupKey).Group == g.name
+	})
+	return ok
+}
+
+// makeFairRoom evicts the least recently used entry the fairness mode
+// lets g evict, the lock must be held. Returns false if there is none.
+func (g *Group) makeFairRoom() bool {
+	now := g.c.lru.Clock().Now()
+	var owner *Group
+	var oldest *simplelru.Element
+	for _, o := range g.c.groups {
+		back := o.order.Back()
+		if back == nil || (o != g && !o.canLose(now)) {
+			continue
+		}
+		if oldest == nil || back.Value.(*groupAccess).seq < oldest.Value.(*groupAccess).seq {
+			owner, oldest = o, back
+		}
+	}
+	if owner == nil {
+		return false
+	}
+	if owner != g {
+		owner.windowLost++
+	}
+	g.c.lru.Evict(owner.key(oldest.Value.(*groupAccess).key))
+	return true
+}
+
+// track starts keeping the recency of the keys of g for the fairness
+// mode
+func (g *Group) track() {
+	g.order = simplelru.New()
+	g.elems = make(map[interface{}]*simplelru.Element)
+}
+
+// accessed records an access of a key of g in the fairness mode
+func (g *Group) accessed(key interface{}) {
+	if g.order == nil {
+		return
+	}
+	g.c.seq++
+	if e, ok := g.elems[key]; ok {
+		e.Value.(*groupAccess).seq = g.c.seq
+		g.order.MoveToFront(e)
+		return
+	}
+	g.elems[key] = g.order.PushFront(&groupAccess{key: key, seq: g.c.seq})
+}
+
+// forget drops a key leaving g from the fairness mode records
+func (g *Group) forget(key interface{}) {
+	if e, ok := g.elems[key]; ok {
+		delete(g.elems, key)
+		g.order.Remove(e)
+	}
+}
+
+// canLose returns if the other groups may evict an entry of g in the
+// current fairness window, starting a new window if it is over
+func (g *Group) canLose(now time.Time) bool {
+	if now.Sub(g.windowStart) >= g.c.fairWindow {
+		g.windowStart = now
+		g.windowBase = g.len
+		g.windowLost = 0
+	}
+	return float64(g.windowLost) < g.c.fairShare*float64(g.windowBase)
+}
+
+// Get looks up a key's value from the group.
+func (g *Group) Get(key interface{}) (value interface{}, ok bool) {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	value, ok = g.c.lru.Get(g.key(key))
+	if ok {
+		g.hits++
+		g.accessed(key)
+	} else {
+		g.misses++
+	}
+	return value, ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (g *Group) Peek(key interface{}) (value interface{}, ok bool) {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	return g.c.lru.Peek(g.key(key))
+}
+
+// Contains checks if a key is in the group, without updating the
+// recent-ness or deleting it for being stale.
+func (g *Group) Contains(key interface{}) bool {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	return g.c.lru.Contains(g.key(key))
+}
+
+// Remove removes the provided key from the group, returning if the key
+// was contained.
+func (g *Group) Remove(key interface{}) bool {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	return g.c.lru.Remove(g.key(key))
+}
+
+// Keys returns a slice of the keys of the group, from oldest to newest.
+// It walks the whole shared LRU.
+func (g *Group) Keys() []interface{} {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	return g.keys()
+}
+
+// keys is Keys with the lock held
+func (g *Group) keys() []interface{} {
+	keys := make([]interface{}, 0, g.len)
+	g.c.lru.Range(func(key, _ interface{}) bool {
+		if k := key.(GroupKey); k.Group == g.name {
+			keys = append(keys, k.Key)
+		}
+		return true
+	})
+	return keys
+}
+
+// Len returns the number of entries of the group, including the expired
+// entries not removed yet.
+func (g *Group) Len() int {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	return g.len
+}
+
+// Purge removes the entries of the group, leaving the other groups
+// untouched.
+func (g *Group) Purge() {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	for _, key := range g.keys() {
+		g.c.lru.Remove(g.key(key))
+	}
+}
+
+// SetMaxSize sets the quota of the group, evicting its oldest entries
+// above it. A size <= 0 removes the quota. Returns the number of evicted
+// entries.
+func (g *Group) SetMaxSize(size int) (evicted int) {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	g.maxSize = size
+	for size > 0 && g.len > size && g.removeOldest() {
+		evicted++
+	}
+	return evicted
+}
+
+// Stats returns the stats of the group.
+func (g *Group) Stats() GroupStats {
+	g.c.lock.Lock()
+	defer g.c.unlock()
+	return g.stats()
+}
+
+func (g *Group) stats() GroupStats {
+	return GroupStats{
+		Len:       g.len,
+		MaxSize:   g.maxSize,
+		Hits:      g.hits,
+		Misses:    g.misses,
+		Evictions: g.evictions,
+	}
+}
@@ -0,0 +1,198 @@
+package lru
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestGroupedCache(t *testing.T) {
+	c, err := NewGrouped(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sessions, tokens := c.Group("sessions"), c.Group("tokens")
+	if c.Group("sessions") != sessions {
+		t.Fatalf("should return the same group")
+	}
+
+	sessions.Add(1, "s1")
+	sessions.Add(2, "s2")
+	tokens.Add(1, "t1")
+	if v, ok := sessions.Get(1); !ok || v != "s1" {
+		t.Fatalf("bad value: %v", v)
+	}
+	if v, ok := tokens.Get(1); !ok || v != "t1" {
+		t.Fatalf("bad value: %v", v)
+	}
+	tokens.Get(2)
+
+	// The groups share the capacity and the eviction order
+	tokens.Add(2, "t2")
+	tokens.Add(3, "t3")
+	if sessions.Contains(2) || c.Len() != 4 {
+		t.Fatalf("oldest entry should be evicted: %v", sessions.Keys())
+	}
+	if sessions.Len() != 1 || tokens.Len() != 3 {
+		t.Fatalf("bad len: %d %d", sessions.Len(), tokens.Len())
+	}
+	want := map[string]GroupStats{
+		"sessions": {Len: 1, Hits: 1, Evictions: 1},
+		"tokens":   {Len: 3, Hits: 1, Misses: 1},
+	}
+	if stats := c.GroupStats(); !reflect.DeepEqual(stats, want) {
+		t.Fatalf("bad stats: %+v", stats)
+	}
+
+	// Updates do not count twice
+	tokens.Add(3, "t3'")
+	if tokens.Len() != 3 {
+		t.Fatalf("bad len: %d", tokens.Len())
+	}
+
+	tokens.Purge()
+	if tokens.Len() != 0 || c.Len() != 1 || !sessions.Contains(1) {
+		t.Fatalf("should only purge the group")
+	}
+	if names := c.Groups(); !reflect.DeepEqual(names, []string{"sessions", "tokens"}) {
+		t.Fatalf("bad groups: %v", names)
+	}
+	if !sessions.Remove(1) || sessions.Len() != 0 {
+		t.Fatalf("bad remove")
+	}
+}
+
+func TestGroupedCacheQuota(t *testing.T) {
+	var reasons []simplelru.EvictReason
+	c, err := NewGrouped(10, simplelru.WithEvictCallbackWithReason(func(key, value interface{}, reason simplelru.EvictReason) {
+		if _, ok := key.(GroupKey); !ok {
+			t.Fatalf("bad key: %v", key)
+		}
+		reasons = append(reasons, reason)
+	}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	small, big := c.Group("small"), c.Group("big")
+	small.SetMaxSize(2)
+	for i := 0; i < 5; i++ {
+		big.Add(i, i)
+		small.Add(i, i)
+	}
+	if keys := small.Keys(); !reflect.DeepEqual(keys, []interface{}{3, 4}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if big.Len() != 5 || small.Stats().Evictions != 3 || len(reasons) != 3 {
+		t.Fatalf("bad stats: %+v %v", small.Stats(), reasons)
+	}
+
+	big.SetMaxSize(1)
+	if keys := big.Keys(); !reflect.DeepEqual(keys, []interface{}{4}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	big.SetMaxSize(0)
+	big.Add(5, 5)
+	if big.Len() != 2 {
+		t.Fatalf("bad len: %d", big.Len())
+	}
+}
+
+func TestGroupedCacheQuotaEviction(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Unix(0, 0))
+	var c *GroupedCache
+	var reasons []simplelru.EvictReason
+	c, _ = NewGrouped(8, simplelru.WithClock(clock), simplelru.WithTombstones(time.Hour, nil),
+		simplelru.WithEvictCallbackWithReason(func(key, value interface{}, reason simplelru.EvictReason) {
+			// The callback may call back into the cache
+			c.Len()
+			reasons = append(reasons, reason)
+		}))
+	g := c.Group("g")
+	g.SetMaxSize(2)
+
+	// The expired entry makes room first
+	g.AddEx(1, 1, time.Minute)
+	g.Add(2, 2)
+	clock.Advance(2 * time.Minute)
+	g.Add(3, 3)
+	if g.Len() != 2 || !g.Contains(2) || !g.Contains(3) {
+		t.Fatalf("bad group: %v %v", g.Len(), g.Keys())
+	}
+
+	// Quota evictions leave no tombstone
+	g.Add(4, 4)
+	g.Add(2, 2)
+	if g.Len() != 2 || !g.Contains(2) || !g.Contains(4) {
+		t.Fatalf("bad group: %v %v", g.Len(), g.Keys())
+	}
+	want := []simplelru.EvictReason{simplelru.EvictExpired, simplelru.EvictCapacity, simplelru.EvictCapacity}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Fatalf("bad reasons: %v", reasons)
+	}
+}
+
+func TestGroupedCacheFairness(t *testing.T) {
+	c, _ := NewGrouped(10)
+	c.SetFairness(0.2, time.Hour)
+	a, b := c.Group("a"), c.Group("b")
+	for i := 0; i < 10; i++ {
+		a.Add(i, i)
+	}
+	// The burst of b evicts 2 entries of a, then its own oldest ones
+	for i := 0; i < 10; i++ {
+		b.Add(i, i)
+	}
+	if a.Len() != 8 || b.Len() != 2 || c.Len() != 10 {
+		t.Fatalf("bad lens: %v %v", a.Len(), b.Len())
+	}
+	if keys := b.Keys(); !reflect.DeepEqual(keys, []interface{}{8, 9}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if a.Stats().Evictions != 2 || b.Stats().Evictions != 8 {
+		t.Fatalf("bad evictions: %+v %+v", a.Stats(), b.Stats())
+	}
+
+	// A new group takes a share of b, then recycles its own entries, and
+	// a group without entries to give up is declined
+	n := c.Group("n")
+	n.Add(1, 1)
+	n.Add(2, 2)
+	if n.Len() != 1 || b.Len() != 1 || a.Len() != 8 {
+		t.Fatalf("bad lens: %v %v %v", n.Len(), b.Len(), a.Len())
+	}
+	n.Purge()
+	b.Add(10, 10)
+	if n.Add(3, 3); n.Len() != 0 {
+		t.Fatalf("add should be declined")
+	}
+
+	c.SetFairness(0, 0)
+	n.Add(3, 3)
+	if n.Len() != 1 || c.Len() != 10 {
+		t.Fatalf("bad lens: %v %v", n.Len(), c.Len())
+	}
+}
+
+func TestGroupedCacheFairnessClock(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Unix(0, 0))
+	c, _ := NewGrouped(4, simplelru.WithClock(clock))
+	c.SetFairness(0.25, time.Minute)
+	a, b := c.Group("a"), c.Group("b")
+	for i := 0; i < 4; i++ {
+		a.Add(i, i)
+	}
+	// a may lose one entry per window, its hit entry going last
+	a.Get(0)
+	b.Add(0, 0)
+	b.Add(1, 1)
+	if a.Len() != 3 || b.Len() != 1 || a.Contains(1) || !a.Contains(0) {
+		t.Fatalf("bad groups: %v %v", a.Keys(), b.Keys())
+	}
+	clock.Advance(2 * time.Minute)
+	b.Add(2, 2)
+	if a.Len() != 2 || b.Len() != 2 || a.Contains(2) {
+		t.Fatalf("a new window should start on the cache clock: %v %v", a.Keys(), b.Keys())
+	}
+}
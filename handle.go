@@ -0,0 +1,72 @@
+package lru
+
+import (
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// Handle refers to an entry of a Cache, letting hot loops operate on the
+// same entry repeatedly without hashing its key again. A handle goes
+// stale once its entry leaves the cache; its methods then report false.
+type Handle struct {
+	c *Cache
+	h simplelru.Handle
+}
+
+// GetHandle looks up a key like Get, updating its recent-ness, and
+// returns a handle to its entry.
+func (c *Cache) GetHandle(key interface{}) (Handle, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	h, ok := c.lru.GetHandle(key)
+	if !ok {
+		return Handle{}, false
+	}
+	return Handle{c: c, h: h}, true
+}
+
+// Key returns the key of the entry.
+func (h Handle) Key() interface{} {
+	return h.h.Key()
+}
+
+// Value returns the value of the entry without updating its recent-ness.
+func (h Handle) Value() (interface{}, bool) {
+	if h.c == nil {
+		return nil, false
+	}
+	h.c.lock.RLock()
+	defer h.c.lock.RUnlock()
+	return h.h.Value()
+}
+
+// Touch marks the entry as recently used.
+func (h Handle) Touch() bool {
+	if h.c == nil {
+		return false
+	}
+	h.c.lock.Lock()
+	defer h.c.unlock()
+	return h.h.Touch()
+}
+
+// UpdateExpire sets the entry to expire after the given duration.
+func (h Handle) UpdateExpire(expire time.Duration) bool {
+	if h.c == nil {
+		return false
+	}
+	h.c.lock.Lock()
+	defer h.c.unlock()
+	return h.h.UpdateExpire(expire)
+}
+
+// Remove removes the entry from the cache.
+func (h Handle) Remove() bool {
+	if h.c == nil {
+		return false
+	}
+	h.c.lock.Lock()
+	defer h.c.unlock()
+	return h.h.Remove()
+}
@@ -0,0 +1,31 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	l, _ := New(2)
+	l.Add(1, 1)
+
+	h, ok := l.GetHandle(1)
+	if !ok || h.Key() != 1 {
+		t.Fatalf("should be found")
+	}
+	if v, ok := h.Value(); !ok || v != 1 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if !h.Touch() || !h.UpdateExpire(0) {
+		t.Fatalf("handle should be valid")
+	}
+	if !h.Remove() || l.Contains(1) {
+		t.Fatalf("should be removed")
+	}
+	if _, ok := h.Value(); ok {
+		t.Fatalf("handle should be stale")
+	}
+
+	if h, ok := l.GetHandle(2); ok || h.Touch() {
+		t.Fatalf("should not be found")
+	}
+}
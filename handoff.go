@@ -0,0 +1,87 @@
+package lru
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// handoffBatch is the number of records sent in each message of a handoff
+const handoffBatch = 256
+
+// HandoffTo streams the live entries of the cache to w for TakeOver, as
+// when an old process hands its warm cache to the new one during a
+// graceful restart. The entries are copied under the lock and sent in
+// batches after it is released, so the cache keeps serving during the
+// transfer. The concrete types of the keys and values must be registered
+// with gob.Register.
+func (c *Cache) HandoffTo(w io.Writer) error {
+	records := c.Dump()
+	enc := gob.NewEncoder(w)
+	for len(records) > 0 {
+		n := len(records)
+		if n > handoffBatch {
+			n = handoffBatch
+		}
+		if err := enc.Encode(records[:n]); err != nil {
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+// TakeOver loads the entries streamed by HandoffTo from r until it is
+// closed, one batch at a time, so the cache serves while it fills up.
+// Keys added to the cache in the meantime are fresher and are kept over
+// the records streamed. Returns the number of records loaded.
+func (c *Cache) TakeOver(r io.Reader) (int, error) {
+	dec := gob.NewDecoder(r)
+	n := 0
+	for {
+		var records []simplelru.Record
+		if err := dec.Decode(&records); err != nil {
+			if err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+
+		c.lock.Lock()
+		if c.closed {
+			c.unlock()
+			return n, ErrClosed
+		}
+		fresh := records[:0]
+		for _, rec := range records {
+			if !c.lru.Contains(rec.Key) {
+				fresh = append(fresh, rec)
+			}
+		}
+		c.lru.Load(fresh)
+		c.unlock()
+		n += len(fresh)
+	}
+}
+
+// ServeHandoff hands the cache off to every connection accepted on l,
+// typically a unix socket the new process dials during a restart, until
+// l is closed.
+func (c *Cache) ServeHandoff(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			c.HandoffTo(conn)
+		}()
+	}
+}
@@ -0,0 +1,44 @@
+package lru
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheHandoff(t *testing.T) {
+	old, _ := New(1024)
+	for i := 0; i < 600; i++ {
+		old.Add(i, i)
+	}
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "handoff.sock"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	done := make(chan error)
+	go func() { done <- old.ServeHandoff(l) }()
+
+	next, _ := New(1024)
+	// Served by the new process before the handoff completes
+	next.Add(5, "fresh")
+	conn, err := net.Dial("unix", l.Addr().String())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	n, err := next.TakeOver(conn)
+	conn.Close()
+	if err != nil || n != 599 || next.Len() != 600 {
+		t.Fatalf("bad takeover: %v %v len: %v", n, err, next.Len())
+	}
+	if v, _ := next.Peek(5); v != "fresh" {
+		t.Fatalf("fresh value should be kept: %v", v)
+	}
+	if keys := next.Keys(); keys[0] != 5 || keys[len(keys)-1] != 599 {
+		t.Fatalf("bad recency: %v %v", keys[0], keys[len(keys)-1])
+	}
+
+	l.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package lru
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// updateHitRatios rewrites the recorded baselines instead of checking
+// them, after a change meant to affect the hit ratios.
+var updateHitRatios = flag.Bool("update-hit-ratios", false, "rewrite testdata/hitratio.golden")
+
+const (
+	// hitRatioGolden holds the recorded hit ratio of each policy on
+	// each reference trace
+	hitRatioGolden = "testdata/hitratio.golden"
+
+	// hitRatioTolerance is the drift allowed from the baselines, for the
+	// policies depending on map iteration order
+	hitRatioTolerance = 0.005
+
+	// hitRatioCacheSize is the capacity the traces are replayed with
+	hitRatioCacheSize = 1000
+)
+
+// referenceTraces generates the reference traces. They are seeded, so
+// that every run replays the same accesses, and mimic the shape of
+// published traces rather than bundling them.
+var referenceTraces = map[string]func() []int{
+	// zipf is a skewed web or CDN workload over a large keyspace
+	"zipf": func() []int {
+		r := rand.New(rand.NewSource(1))
+		z := rand.NewZipf(r, 1.1, 1, 100000)
+		keys := make([]int, 50000)
+		for i := range keys {
+			keys[i] = int(z.Uint64())
+		}
+		return keys
+	},
+	// loop cycles over a working set a quarter larger than the cache,
+	// like the loops of the glimpse trace
+	"loop": func() []int {
+		keys := make([]int, 50000)
+		for i := range keys {
+			keys[i] = i % (hitRatioCacheSize * 5 / 4)
+		}
+		return keys
+	},
+	// scan is a skewed database workload interrupted by sequential scans
+	// of keys never used again, like the OLTP traces
+	"scan": func() []int {
+		r := rand.New(rand.NewSource(2))
+		z := rand.NewZipf(r, 1.2, 1, 5000)
+		keys := make([]int, 50000)
+		next := 1 << 20
+		for i := range keys {
+			if i%2000 < 500 {
+				keys[i] = next
+				next++
+			} else {
+				keys[i] = int(z.Uint64())
+			}
+		}
+		return keys
+	},
+	// shift moves the hot set every 10000 accesses, like the phases of
+	// a batch workload
+	"shift": func() []int {
+		r := rand.New(rand.NewSource(3))
+		z := rand.NewZipf(r, 1.1, 1, 20000)
+		keys := make([]int, 50000)
+		for i := range keys {
+			keys[i] = int(z.Uint64()) + i/10000*20000
+		}
+		return keys
+	},
+}
+
+// hitRatioPolicy is a cache replayed by the regression tests
+type hitRatioPolicy struct {
+	get func(key interface{}) bool
+	add func(key interface{})
+}
+
+// referencePolicies builds a cache of each policy of the package
+var referencePolicies = map[string]func(size int) hitRatioPolicy{
+	"lru": func(size int) hitRatioPolicy {
+		c, _ := New(size)
+		return cacheHitRatioPolicy(c)
+	},
+	"fifo": func(size int) hitRatioPolicy {
+		c, _ := NewWithOptions(size, nil, simplelru.WithEvictionOrder(simplelru.InsertionOrder))
+		return cacheHitRatioPolicy(c)
+	},
+	"generation": func(size int) hitRatioPolicy {
+		c, _ := NewWithOptions(size, nil, simplelru.WithEvictionOrder(simplelru.GenerationOrder))
+		return cacheHitRatioPolicy(c)
+	},
+	"tinylfu": func(size int) hitRatioPolicy {
+		c, _ := NewWithOptions(size, nil, simplelru.WithAdmissionPolicy(func() simplelru.AdmissionPolicy {
+			return simplelru.NewTinyLFU(10 * size)
+		}))
+		return cacheHitRatioPolicy(c)
+	},
+	"2q": func(size int) hitRatioPolicy {
+		c, _ := New2Q(size)
+		return hitRatioPolicy{
+			get: func(key interface{}) bool { _, ok := c.Get(key); return ok },
+			add: func(key interface{}) { c.Add(key, key) },
+		}
+	},
+	"arc": func(size int) hitRatioPolicy {
+		c, _ := NewARC(size)
+		return hitRatioPolicy{
+			get: func(key interface{}) bool { _, ok := c.Get(key); return ok },
+			add: func(key interface{}) { c.Add(key, key) },
+		}
+	},
+	"lfu": func(size int) hitRatioPolicy {
+		c, _ := NewLFU(size)
+		return hitRatioPolicy{
+			get: func(key interface{}) bool { _, ok := c.Get(key); return ok },
+			add: func(key interface{}) { c.Add(key, key) },
+		}
+	},
+}
+
+func cacheHitRatioPolicy(c *Cache) hitRatioPolicy {
+	return hitRatioPolicy{
+		get: func(key interface{}) bool { _, ok := c.Get(key); return ok },
+		add: func(key interface{}) { c.Add(key, key) },
+	}
+}
+
+// replay reads each key of the trace, adding it on a miss, and returns
+// the hit ratio
+func replay(p hitRatioPolicy, keys []int) float64 {
+	hits := 0
+	for _, k := range keys {
+		if p.get(k) {
+			hits++
+		} else {
+			p.add(k)
+		}
+	}
+	return float64(hits) / float64(len(keys))
+}
+
+func TestHitRatioRegression(t *testing.T) {
+	if testing.Short() {
+		t.Skip("replays the reference traces")
+	}
+	got := make(map[string]float64)
+	for trace, gen := range referenceTraces {
+		keys := gen()
+		for policy, build := range referencePolicies {
+			got[trace+" "+policy] = replay(build(hitRatioCacheSize), keys)
+		}
+	}
+
+	if *updateHitRatios {
+		names := make([]string, 0, len(got))
+		for name := range got {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var b strings.Builder
+		b.WriteString("# trace policy hit-ratio, rewrite with go test -run HitRatio -update-hit-ratios\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s %.4f\n", name, got[name])
+		}
+		if err := os.WriteFile(hitRatioGolden, []byte(b.String()), 0o644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return
+	}
+
+	want, err := readHitRatios(hitRatioGolden)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for name, ratio := range got {
+		baseline, ok := want[name]
+		if !ok {
+			t.Errorf("%s: no baseline, rerun with -update-hit-ratios", name)
+			continue
+		}
+		if math.Abs(ratio-baseline) > hitRatioTolerance {
+			t.Errorf("%s: hit ratio %.4f, baseline %.4f", name, ratio, baseline)
+		}
+	}
+}
+
+// readHitRatios parses the baselines written by -update-hit-ratios
+func readHitRatios(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ratios := make(map[string]float64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var trace, policy string
+		var ratio float64
+		if _, err := fmt.Sscan(line, &trace, &policy, &ratio); err != nil {
+			return nil, fmt.Errorf("%s: %q: %v", path, line, err)
+		}
+		ratios[trace+" "+policy] = ratio
+	}
+	return ratios, sc.Err()
+}
@@ -0,0 +1,186 @@
+// Package httpcache provides a thread-safe fixed size LRU cache for HTTP
+// response bodies. Entries are keyed by URL plus the values of the
+// request headers named by the response's Vary header, so several
+// variants of one logical resource can be cached and invalidated
+// together.
+package httpcache
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// Cache is a thread-safe fixed size LRU cache of response bodies.
+type Cache struct {
+	lru      *simplelru.LRU
+	vary     map[string][]string
+	variants map[string]map[string]struct{}
+	lock     sync.Mutex
+}
+
+// variant is used to hold a response body in the underlying LRU
+type variant struct {
+	url  string
+	body []byte
+}
+
+// New creates a Cache holding up to size variants in total.
+func New(size int) (*Cache, error) {
+	return NewWithExpire(size, 0)
+}
+
+// NewWithExpire creates a Cache holding up to size variants in total with
+// expire feature.
+func NewWithExpire(size int, expire time.Duration) (*Cache, error) {
+	c := &Cache{
+		vary:     make(map[string][]string),
+		variants: make(map[string]map[string]struct{}),
+	}
+	lru, err := simplelru.NewLRUWithExpire(size, expire, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = lru
+	return c, nil
+}
+
+// Set stores body as the variant of r's URL selected by the request
+// headers named in vary. Changing the vary headers of a URL invalidates
+// all variants stored with the previous ones.
+func (c *Cache) Set(r *http.Request, vary []string, body []byte) {
+	c.SetEx(r, vary, body, 0)
+}
+
+// SetEx is like Set with a per-entry expire time.
+func (c *Cache) SetEx(r *http.Request, vary []string, body []byte, expire time.Duration) {
+	url := URL(r)
+	vary = canonicalHeaders(vary)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if old, ok := c.vary[url]; ok && !equalHeaders(old, vary) {
+		c.invalidate(url)
+	}
+	c.vary[url] = vary
+
+	key := variantKey(url, vary, r.Header)
+	keys, ok := c.variants[url]
+	if !ok {
+		keys = make(map[string]struct{})
+		c.variants[url] = keys
+	}
+	keys[key] = struct{}{}
+	c.lru.AddEx(key, &variant{url: url, body: body}, expire)
+}
+
+// Get looks up the variant of r's URL matching r's headers.
+func (c *Cache) Get(r *http.Request) ([]byte, bool) {
+	url := URL(r)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	vary, ok := c.vary[url]
+	if !ok {
+		return nil, false
+	}
+	val, ok := c.lru.Get(variantKey(url, vary, r.Header))
+	if !ok {
+		return nil, false
+	}
+	return val.(*variant).body, true
+}
+
+// URL returns the logical key of r's resource, its host followed by the
+// request URI, as used by Invalidate and Variants.
+func URL(r *http.Request) string {
+	host := r.URL.Host
+	if host == "" {
+		host = r.Host
+	}
+	return host + r.URL.RequestURI()
+}
+
+// Invalidate removes all variants of the given URL.
+func (c *Cache) Invalidate(url string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.invalidate(url)
+}
+
+// Variants returns the number of variants cached for the given URL.
+func (c *Cache) Variants(url string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.variants[url])
+}
+
+// Len returns the number of variants in the cache.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// Purge is used to completely clear the cache
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.Purge()
+}
+
+// invalidate removes all variants of url, the lock must be held
+func (c *Cache) invalidate(url string) {
+	for key := range c.variants[url] {
+		c.lru.Remove(key)
+	}
+}
+
+// onEvict keeps the variant index in sync with the underlying LRU
+func (c *Cache) onEvict(key interface{}, value interface{}) {
+	url := value.(*variant).url
+	keys := c.variants[url]
+	delete(keys, key.(string))
+	if len(keys) == 0 {
+		delete(c.variants, url)
+		delete(c.vary, url)
+	}
+}
+
+// variantKey builds the LRU key of the variant of url selected by h
+func variantKey(url string, vary []string, h http.Header) string {
+	var b strings.Builder
+	b.WriteString(url)
+	for _, name := range vary {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(h.Values(name), ","))
+	}
+	return b.String()
+}
+
+func canonicalHeaders(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = http.CanonicalHeaderKey(name)
+	}
+	return out
+}
+
+func equalHeaders(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func request(url, encoding string) *http.Request {
+	r := httptest.NewRequest("GET", url, nil)
+	if encoding != "" {
+		r.Header.Set("Accept-Encoding", encoding)
+	}
+	return r
+}
+
+func TestCache_Vary(t *testing.T) {
+	c, err := New(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	vary := []string{"accept-encoding"}
+	c.Set(request("/a", "gzip"), vary, []byte("gzipped"))
+	c.Set(request("/a", ""), vary, []byte("plain"))
+
+	if body, ok := c.Get(request("/a", "gzip")); !ok || string(body) != "gzipped" {
+		t.Fatalf("bad: %s, %v", body, ok)
+	}
+	if body, ok := c.Get(request("/a", "")); !ok || string(body) != "plain" {
+		t.Fatalf("bad: %s, %v", body, ok)
+	}
+	if _, ok := c.Get(request("/a", "br")); ok {
+		t.Fatalf("should not match")
+	}
+	if n := c.Variants("example.com/a"); n != 2 {
+		t.Fatalf("bad variants: %d", n)
+	}
+
+	c.Invalidate("example.com/a")
+	if c.Len() != 0 {
+		t.Fatalf("bad len: %d", c.Len())
+	}
+	if _, ok := c.Get(request("/a", "gzip")); ok {
+		t.Fatalf("should be invalidated")
+	}
+}
+
+func TestCache_VaryChange(t *testing.T) {
+	c, _ := New(4)
+	c.Set(request("/a", "gzip"), []string{"Accept-Encoding"}, []byte("gzipped"))
+	c.Set(request("/a", "gzip"), nil, []byte("any"))
+
+	if c.Len() != 1 {
+		t.Fatalf("bad len: %d", c.Len())
+	}
+	if body, ok := c.Get(request("/a", "")); !ok || string(body) != "any" {
+		t.Fatalf("bad: %s, %v", body, ok)
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	c, _ := New(1)
+	c.Set(request("/a", ""), nil, []byte("a"))
+	c.Set(request("/b", ""), nil, []byte("b"))
+
+	if _, ok := c.Get(request("/a", "")); ok {
+		t.Fatalf("should be evicted")
+	}
+	if n := c.Variants("example.com/a"); n != 0 {
+		t.Fatalf("bad variants: %d", n)
+	}
+}
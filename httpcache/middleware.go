@@ -0,0 +1,357 @@
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	lru "github.com/hnlq715/golang-lru"
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// errUncacheable keeps a loaded response that may not be cached under its
+// key out of the cache, and from the other requests waiting for it
+var errUncacheable = errors.New("httpcache: response is not cacheable")
+
+// response is a recorded response held by a ResponseCache
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+
+	// stored is when the response was recorded and expires when it goes
+	// stale, zero if it never does
+	stored  time.Time
+	expires time.Time
+
+	// streamed is set if the body outgrew the maximum body size and was
+	// written through rather than recorded
+	streamed bool
+}
+
+// ResponseCache caches whole responses for Middleware, bounded by their
+// total size in bytes.
+type ResponseCache struct {
+	cache    *lru.Cache
+	maxBytes int64
+	maxBody  int64 // accessed atomically
+}
+
+// NewResponseCache creates a ResponseCache holding up to maxBytes of
+// response bodies and headers. Expired responses are kept for stale
+// more, during which Middleware serves them while refreshing them in the
+// background; 0 disables serving stale responses.
+func NewResponseCache(maxBytes int64, stale time.Duration) (*ResponseCache, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("Must provide a positive size")
+	}
+	opts := []simplelru.Option{simplelru.WithMaxCost(maxBytes, responseSize)}
+	if stale > 0 {
+		opts = append(opts, simplelru.WithStaleTTL(stale))
+	}
+	cache, err := lru.NewWithOptions(math.MaxInt, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseCache{cache: cache, maxBytes: maxBytes, maxBody: maxBytes}, nil
+}
+
+// SetMaxBodySize sets the size of the largest body cached. Larger bodies
+// are written through to the client as they come rather than buffered,
+// and are not cached. It defaults to the size of the cache, which a value
+// of 0 restores.
+func (c *ResponseCache) SetMaxBodySize(n int64) {
+	if n <= 0 {
+		n = c.maxBytes
+	}
+	atomic.StoreInt64(&c.maxBody, n)
+}
+
+// Len returns the number of responses in the cache.
+func (c *ResponseCache) Len() int {
+	return c.cache.Len()
+}
+
+// Size returns the total size of the responses in the cache.
+func (c *ResponseCache) Size() int64 {
+	return c.cache.Cost()
+}
+
+// Purge is used to completely clear the cache
+func (c *ResponseCache) Purge() {
+	c.cache.Purge()
+}
+
+// Middleware caches the successful responses of the GET and HEAD requests
+// served by the next handler for ttl, keyed by method, URL and the values
+// of the request headers named in vary or in the Vary header of the
+// response. Concurrent misses of a key wait for a single call of the next
+// handler. Responses with a Cache-Control of no-store or private, setting
+// cookies, varying on * or larger than the maximum body size are not
+// cached. The X-Cache response header tells HIT, MISS or STALE, the Age
+// header how many seconds ago the response was recorded, added to the Age
+// of the next handler if any, and the X-Cache-TTL header how many seconds
+// it stays fresh, when ttl is positive.
+//
+// It is a plain net/http middleware, and this package ships no adapters
+// for Gin, Echo or other frameworks, which would make them dependencies.
+// It plugs into the frameworks accepting net/http middleware, e.g. with
+// echo.WrapMiddleware, or wraps the http.Handler of their router.
+func Middleware(c *ResponseCache, ttl time.Duration, vary []string) func(http.Handler) http.Handler {
+	vary = canonicalHeaders(vary)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			base := r.Method + " " + URL(r)
+			names := c.varyOf(base, vary)
+			key := variantKey(base, names, r.Header)
+
+			if v, expired, ok := c.cache.GetStale(key); ok {
+				if !expired {
+					serve(w, v.(*response), "HIT")
+					return
+				}
+				// Reload in the background, detached from the client
+				bg := r.Clone(detach(r.Context()))
+				c.cache.GetOrComputeStale(key, ttl, func() (interface{}, error) {
+					return c.load(next, nil, bg, ttl, base, names)
+				})
+				serve(w, v.(*response), "STALE")
+				return
+			}
+
+			var own *response
+			v, err := c.cache.GetOrComputeEx(key, ttl, func() (interface{}, error) {
+				resp, err := c.load(next, w, r, ttl, base, names)
+				own = resp
+				return resp, err
+			})
+			switch {
+			case own != nil:
+				if !own.streamed {
+					serve(w, own, "MISS")
+				}
+			case err == nil:
+				serve(w, v.(*response), "HIT")
+			default:
+				// The response loaded for another request may not be
+				// shared with this one
+				if resp := c.record(next, w, r, ttl); !resp.streamed {
+					serve(w, resp, "MISS")
+				}
+			}
+		})
+	}
+}
+
+// varyOf returns the headers selecting the variants of base: the vary
+// headers of the middleware followed by those the responses of base
+// varied on.
+func (c *ResponseCache) varyOf(base string, vary []string) []string {
+	if v, ok := c.cache.Get(varyKey(base)); ok {
+		return unionHeaders(vary, v.([]string))
+	}
+	return vary
+}
+
+// load records the response of next to r, returning errUncacheable if it
+// may not be stored under the key of names. A response varying on more
+// headers is stored under the key of all of them instead, and base is
+// looked up with these from then on. w is where a body too large to be
+// cached is written through, nil to discard it.
+func (c *ResponseCache) load(next http.Handler, w http.ResponseWriter, r *http.Request, ttl time.Duration, base string, names []string) (*response, error) {
+	resp := c.record(next, w, r, ttl)
+	if !cacheable(resp) {
+		return resp, errUncacheable
+	}
+	varied, ok := responseVary(resp.header)
+	if !ok {
+		return resp, errUncacheable
+	}
+	if all := unionHeaders(names, varied); len(all) > len(names) {
+		c.cache.Add(varyKey(base), all)
+		c.cache.AddEx(variantKey(base, all, r.Header), resp, ttl)
+		return resp, errUncacheable
+	}
+	return resp, nil
+}
+
+// record runs h on r and returns the response it wrote, fresh for ttl. A
+// body outgrowing the maximum body size is written through to w instead.
+func (c *ResponseCache) record(h http.Handler, w http.ResponseWriter, r *http.Request, ttl time.Duration) *response {
+	rec := &recorder{header: make(http.Header), max: atomic.LoadInt64(&c.maxBody), w: w}
+	h.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	resp := &response{status: rec.status, header: rec.header, body: rec.body.Bytes(), stored: time.Now(), streamed: rec.streamed}
+	if ttl > 0 {
+		resp.expires = resp.stored.Add(ttl)
+	}
+	return resp
+}
+
+// serve writes a recorded response to w
+func serve(w http.ResponseWriter, resp *response, status string) {
+	for name, values := range resp.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", status)
+	now := time.Now()
+	age := int64(now.Sub(resp.stored) / time.Second)
+	if origin, err := strconv.ParseInt(resp.header.Get("Age"), 10, 64); err == nil && origin > 0 {
+		age += origin
+	}
+	w.Header().Set("Age", strconv.FormatInt(age, 10))
+	if !resp.expires.IsZero() {
+		// Round up, so a fresh response never claims 0 seconds
+		ttl := (resp.expires.Sub(now) + time.Second - 1) / time.Second
+		if ttl < 0 {
+			ttl = 0
+		}
+		w.Header().Set("X-Cache-TTL", strconv.FormatInt(int64(ttl), 10))
+	}
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// cacheable returns if a response may be shared between clients
+func cacheable(resp *response) bool {
+	if resp.streamed || resp.status != http.StatusOK || len(resp.header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	for _, v := range resp.header.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-store", "private":
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// responseVary returns the headers named by the Vary header of a
+// response, or false if it varies on *
+func responseVary(h http.Header) ([]string, bool) {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			switch name = strings.TrimSpace(name); name {
+			case "":
+			case "*":
+				return nil, false
+			default:
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names, true
+}
+
+// unionHeaders returns a followed by the names of b missing from a,
+// leaving a unchanged
+func unionHeaders(a, b []string) []string {
+	out := a[:len(a):len(a)]
+	for _, name := range b {
+		found := false
+		for _, n := range out {
+			found = found || n == name
+		}
+		if !found {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// varyKey is the key of the headers the responses of base varied on
+func varyKey(base string) string {
+	return "vary " + base
+}
+
+// responseSize returns the size of a recorded response, or of the vary
+// headers of a URL, its cost in the cache
+func responseSize(key, value interface{}) int64 {
+	resp, ok := value.(*response)
+	if !ok {
+		size := int64(len(key.(string)))
+		for _, name := range value.([]string) {
+			size += int64(len(name))
+		}
+		return size
+	}
+	size := int64(len(key.(string)) + len(resp.body))
+	for name, values := range resp.header {
+		size += int64(len(name))
+		for _, v := range values {
+			size += int64(len(v))
+		}
+	}
+	return size
+}
+
+// recorder is a ResponseWriter buffering the response, up to max bytes
+// of body past which it writes through to w, if any
+type recorder struct {
+	header   http.Header
+	status   int
+	body     bytes.Buffer
+	max      int64
+	w        http.ResponseWriter
+	streamed bool
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	if !r.streamed && int64(r.body.Len()+len(b)) > r.max {
+		r.streamed = true
+		if r.w != nil {
+			for name, values := range r.header {
+				r.w.Header()[name] = values
+			}
+			r.w.Header().Set("X-Cache", "MISS")
+			r.w.WriteHeader(r.status)
+			if _, err := r.w.Write(r.body.Bytes()); err != nil {
+				return 0, err
+			}
+		}
+		r.body = bytes.Buffer{}
+	}
+	if r.streamed {
+		if r.w == nil {
+			return len(b), nil
+		}
+		return r.w.Write(b)
+	}
+	return r.body.Write(b)
+}
+
+// detachedContext keeps the values of a context but not its cancellation,
+// so that a background reload outlives the request that started it.
+type detachedContext struct {
+	context.Context
+}
+
+func detach(ctx context.Context) context.Context { return detachedContext{ctx} }
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return }
+func (detachedContext) Done() <-chan struct{}                   { return nil }
+func (detachedContext) Err() error                              { return nil }
@@ -0,0 +1,227 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	c, err := NewResponseCache(1<<20, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls int32
+	h := Middleware(c, time.Hour, []string{"accept-encoding"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s %d", r.Header.Get("Accept-Encoding"), n)
+	}))
+	get := func(method, url, encoding string) *httptest.ResponseRecorder {
+		r := request(url, encoding)
+		r.Method = method
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := get("GET", "/a", "gzip"); w.Body.String() != "gzip 1" || w.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("bad response: %q %v", w.Body, w.Header())
+	}
+	w := get("GET", "/a", "gzip")
+	if w.Body.String() != "gzip 1" || w.Header().Get("X-Cache") != "HIT" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("bad cached response: %q %v", w.Body, w.Header())
+	}
+	if w := get("GET", "/a", "br"); w.Body.String() != "br 2" {
+		t.Fatalf("vary header should select another variant: %q", w.Body)
+	}
+	if w := get("POST", "/a", "gzip"); w.Body.String() != "gzip 3" {
+		t.Fatalf("POST should not be cached: %q", w.Body)
+	}
+	get("GET", "/private", "")
+	if w := get("GET", "/private", ""); w.Body.String() != " 5" {
+		t.Fatalf("private response should not be cached: %q", w.Body)
+	}
+	if c.Len() != 2 || c.Size() <= 0 {
+		t.Fatalf("bad len: %v size: %v", c.Len(), c.Size())
+	}
+}
+
+func TestMiddleware_SizeLimit(t *testing.T) {
+	c, _ := NewResponseCache(2500, 0)
+	h := Middleware(c, 0, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 1000))
+	}))
+	for _, url := range []string{"/a", "/b", "/c"} {
+		h.ServeHTTP(httptest.NewRecorder(), request(url, ""))
+	}
+	if c.Len() != 2 || c.Size() > 2500 {
+		t.Fatalf("bad len: %v size: %v", c.Len(), c.Size())
+	}
+	if _, err := NewResponseCache(0, 0); err == nil {
+		t.Fatalf("should reject an empty budget")
+	}
+}
+
+func TestMiddleware_StaleWhileRevalidate(t *testing.T) {
+	c, _ := NewResponseCache(1<<20, time.Hour)
+	var calls int32
+	h := Middleware(c, 50*time.Millisecond, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, atomic.AddInt32(&calls, 1))
+	}))
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request("/a", ""))
+		return w
+	}
+
+	get()
+	time.Sleep(60 * time.Millisecond)
+	if w := get(); w.Body.String() != "1" || w.Header().Get("X-Cache") != "STALE" {
+		t.Fatalf("bad stale response: %q %v", w.Body, w.Header())
+	}
+	for i := 0; i < 100 && get().Header().Get("X-Cache") != "HIT"; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if w := get(); w.Body.String() != "2" || w.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("response should be refreshed: %q %v", w.Body, w.Header())
+	}
+}
+
+func TestMiddleware_Freshness(t *testing.T) {
+	c, _ := NewResponseCache(1<<20, time.Hour)
+	h := Middleware(c, time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/proxied" {
+			w.Header().Set("Age", "5")
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	get := func(url string) http.Header {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request(url, ""))
+		return w.Header()
+	}
+
+	if hdr := get("/a"); hdr.Get("Age") != "0" || hdr.Get("X-Cache-TTL") != "60" {
+		t.Fatalf("bad fresh headers: %v", hdr)
+	}
+	get("/proxied")
+	// Age the recorded responses
+	for _, v := range c.cache.Values() {
+		resp := v.(*response)
+		resp.stored = resp.stored.Add(-20 * time.Second)
+		resp.expires = resp.expires.Add(-20 * time.Second)
+	}
+	if hdr := get("/a"); hdr.Get("X-Cache") != "HIT" || hdr.Get("Age") != "20" || hdr.Get("X-Cache-TTL") != "40" {
+		t.Fatalf("bad cached headers: %v", hdr)
+	}
+	if hdr := get("/proxied"); hdr.Get("Age") != "25" {
+		t.Fatalf("bad proxied age: %v", hdr)
+	}
+}
+
+func TestMiddleware_ResponseVary(t *testing.T) {
+	c, _ := NewResponseCache(1<<20, 0)
+	var calls int32
+	h := Middleware(c, time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Vary", "Accept-Encoding")
+		if r.URL.Path == "/any" {
+			w.Header().Set("Vary", "*")
+		}
+		fmt.Fprint(w, r.Header.Get("Accept-Encoding"))
+	}))
+	get := func(url, encoding string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request(url, encoding))
+		return w
+	}
+
+	get("/a", "gzip")
+	if w := get("/a", ""); w.Body.String() != "" || w.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("a gzip body should not be served without gzip: %q %v", w.Body, w.Header())
+	}
+	if w := get("/a", "gzip"); w.Body.String() != "gzip" || w.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("bad gzip variant: %q %v", w.Body, w.Header())
+	}
+	if w := get("/a", ""); w.Body.String() != "" || w.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("bad identity variant: %q %v", w.Body, w.Header())
+	}
+	get("/any", "")
+	if w := get("/any", ""); w.Header().Get("X-Cache") != "MISS" || calls != 4 {
+		t.Fatalf("a response varying on * should not be cached: %v %v", w.Header(), calls)
+	}
+}
+
+func TestMiddleware_SingleFlight(t *testing.T) {
+	c, _ := NewResponseCache(1<<20, 0)
+	var calls int32
+	release := make(chan struct{})
+	h := Middleware(c, time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		<-release
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private")
+		}
+		fmt.Fprint(w, n)
+	}))
+
+	for _, url := range []string{"/a", "/private"} {
+		atomic.StoreInt32(&calls, 0)
+		bodies := make(chan string, 4)
+		for i := 0; i < 4; i++ {
+			go func() {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, request(url, ""))
+				bodies <- w.Body.String()
+			}()
+		}
+		for i := 0; i < 100 && atomic.LoadInt32(&calls) == 0; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Fatalf("%s: concurrent misses should wait for one call: %v", url, n)
+		}
+		close(release)
+		seen := make(map[string]bool)
+		for i := 0; i < 4; i++ {
+			seen[<-bodies] = true
+		}
+		release = make(chan struct{})
+		if url == "/a" && (len(seen) != 1 || !seen["1"]) {
+			t.Fatalf("%s: the waiters should share the response: %v", url, seen)
+		}
+		// A private response is not shared, the waiters load their own
+		if url == "/private" && (len(seen) != 4 || calls != 4) {
+			t.Fatalf("%s: the waiters should load their own: %v", url, seen)
+		}
+	}
+}
+
+func TestMiddleware_MaxBodySize(t *testing.T) {
+	c, _ := NewResponseCache(1<<20, 0)
+	c.SetMaxBodySize(10)
+	h := Middleware(c, time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		for i := 0; i < 4; i++ {
+			fmt.Fprint(w, "abcd")
+		}
+	}))
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request("/big", ""))
+		if w.Body.String() != "abcdabcdabcdabcd" || w.Header().Get("Content-Type") != "text/plain" || w.Header().Get("X-Cache") != "MISS" {
+			t.Fatalf("a large body should be written through: %q %v", w.Body, w.Header())
+		}
+	}
+	if c.Len() != 0 {
+		t.Fatalf("a large body should not be cached: %v", c.Len())
+	}
+}
@@ -0,0 +1,129 @@
+package lru
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// IdempotencyCache replays the responses of API requests retried with the
+// same idempotency key. A request reserves its key when it starts and
+// fills the reservation with its response when it completes; duplicates
+// arriving within the TTL get the stored response, or wait for the
+// request in flight to complete.
+type IdempotencyCache struct {
+	cache *Cache
+	ttl   time.Duration
+	lease time.Duration
+
+	lock     sync.Mutex
+	reserved map[interface{}]*Reservation
+}
+
+// Reservation is the exclusive right to compute the response of an
+// idempotency key, returned by IdempotencyCache.Reserve.
+type Reservation struct {
+	ic     *IdempotencyCache
+	key    interface{}
+	done   chan struct{}
+	timer  *time.Timer
+	filled bool
+	result interface{}
+}
+
+// NewIdempotencyCache stores the responses in cache for ttl. A request
+// holding a reservation for longer than lease, as when it crashed, loses
+// it to the duplicates waiting for it; a lease <= 0 never expires.
+func NewIdempotencyCache(cache *Cache, ttl, lease time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		cache:    cache,
+		ttl:      ttl,
+		lease:    lease,
+		reserved: make(map[interface{}]*Reservation),
+	}
+}
+
+// Reserve starts a request with the given idempotency key. If the key has
+// a stored response, Reserve returns it with a nil reservation. If
+// another request holds the key, Reserve waits for it to complete and
+// returns its response, or takes over the reservation if it is released
+// without a response. Otherwise the caller gets the reservation and must
+// Fill or Release it. Returns ctx.Err() if ctx is done while waiting.
+func (ic *IdempotencyCache) Reserve(ctx context.Context, key interface{}) (*Reservation, interface{}, error) {
+	for {
+		ic.lock.Lock()
+		if result, ok := ic.cache.Get(key); ok {
+			ic.lock.Unlock()
+			return nil, result, nil
+		}
+		if r, ok := ic.reserved[key]; ok {
+			ic.lock.Unlock()
+			select {
+			case <-r.done:
+				if r.filled {
+					return nil, r.result, nil
+				}
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		r := &Reservation{ic: ic, key: key, done: make(chan struct{})}
+		if ic.lease > 0 {
+			r.timer = time.AfterFunc(ic.lease, func() { r.finish(false, nil) })
+		}
+		ic.reserved[key] = r
+		ic.lock.Unlock()
+		return r, nil, nil
+	}
+}
+
+// Forget removes the stored response of a key, so the next request with
+// it runs again.
+func (ic *IdempotencyCache) Forget(key interface{}) {
+	ic.lock.Lock()
+	defer ic.lock.Unlock()
+	ic.cache.Remove(key)
+}
+
+// Key returns the idempotency key of the reservation.
+func (r *Reservation) Key() interface{} {
+	return r.key
+}
+
+// Fill stores the response of the request and hands it to the duplicates
+// waiting for it. Returns false if the lease expired or the reservation
+// was already filled or released, in which case the response is not
+// stored.
+func (r *Reservation) Fill(result interface{}) bool {
+	return r.finish(true, result)
+}
+
+// Release gives up the reservation without a response, as when the
+// request failed in a way that is safe to retry. One of the duplicates
+// waiting for it takes it over. Returns false if the lease expired or the
+// reservation was already filled or released.
+func (r *Reservation) Release() bool {
+	return r.finish(false, nil)
+}
+
+// finish ends the reservation if it is still held
+func (r *Reservation) finish(filled bool, result interface{}) bool {
+	ic := r.ic
+	ic.lock.Lock()
+	if ic.reserved[r.key] != r {
+		ic.lock.Unlock()
+		return false
+	}
+	delete(ic.reserved, r.key)
+	if filled {
+		ic.cache.AddEx(r.key, result, ic.ttl)
+	}
+	r.filled, r.result = filled, result
+	close(r.done)
+	ic.lock.Unlock()
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package lru
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	l, _ := New(8)
+	ic := NewIdempotencyCache(l, time.Minute, 0)
+
+	r, _, err := ic.Reserve(context.Background(), "req")
+	if r == nil || err != nil {
+		t.Fatalf("should reserve: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dup, result, err := ic.Reserve(context.Background(), "req")
+			if dup != nil || result != "resp" || err != nil {
+				t.Errorf("bad duplicate: %v %v %v", dup, result, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if !r.Fill("resp") {
+		t.Fatalf("should fill")
+	}
+	wg.Wait()
+	if r.Fill("again") || r.Release() {
+		t.Fatalf("reservation should be over")
+	}
+
+	// Replays come from the cache
+	if dup, result, _ := ic.Reserve(context.Background(), "req"); dup != nil || result != "resp" {
+		t.Fatalf("bad replay: %v %v", dup, result)
+	}
+	ic.Forget("req")
+	if r, _, _ := ic.Reserve(context.Background(), "req"); r == nil {
+		t.Fatalf("should reserve after forget")
+	}
+}
+
+func TestIdempotencyCacheRelease(t *testing.T) {
+	l, _ := New(8)
+	ic := NewIdempotencyCache(l, time.Minute, 0)
+	r, _, _ := ic.Reserve(context.Background(), "req")
+
+	taken := make(chan *Reservation)
+	go func() {
+		dup, _, _ := ic.Reserve(context.Background(), "req")
+		taken <- dup
+	}()
+	time.Sleep(10 * time.Millisecond)
+	r.Release()
+	dup := <-taken
+	if dup == nil || dup == r {
+		t.Fatalf("duplicate should take over the reservation")
+	}
+	if l.Contains("req") {
+		t.Fatalf("released reservation should not be stored")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := ic.Reserve(ctx, "req"); err != context.DeadlineExceeded {
+		t.Fatalf("bad err: %v", err)
+	}
+}
+
+func TestIdempotencyCacheLease(t *testing.T) {
+	l, _ := New(8)
+	ic := NewIdempotencyCache(l, time.Minute, 10*time.Millisecond)
+	r, _, _ := ic.Reserve(context.Background(), "req")
+
+	dup, _, _ := ic.Reserve(context.Background(), "req")
+	if dup == nil {
+		t.Fatalf("duplicate should take over the expired lease")
+	}
+	if r.Fill("late") {
+		t.Fatalf("expired lease should not fill")
+	}
+	if !dup.Fill("resp") {
+		t.Fatalf("should fill")
+	}
+	if v, _ := l.Get("req"); v != "resp" {
+		t.Fatalf("bad value: %v", v)
+	}
+}
@@ -0,0 +1,117 @@
+package lru
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// LFUCache is a thread-safe fixed size cache evicting the least frequently
+// used entry, and the least recently used one among equally frequent
+// entries. It suits frequency-skewed workloads where scans would flush
+// an LRU cache. With aging, the frequencies are halved periodically, so
+// keys that were hot once do not stay in the cache forever. It is an
+// LRU with a simplelru.LFUPolicy.
+type LFUCache struct {
+	lru  *simplelru.LRU
+	lock sync.Mutex
+}
+
+// NewLFU creates an LFU cache of the given size whose frequencies never
+// decay.
+func NewLFU(size int) (*LFUCache, error) {
+	return NewLFUWithAging(size, 0)
+}
+
+// NewLFUWithAging creates an LFU cache of the given size halving the
+// frequencies of its entries every period accesses. A period of 0
+// disables aging.
+func NewLFUWithAging(size, period int) (*LFUCache, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size")
+	}
+	if period < 0 {
+		return nil, fmt.Errorf("invalid aging period")
+	}
+	lru, err := simplelru.NewLRUWithOptions(size, nil, simplelru.WithPolicy(func() simplelru.Policy {
+		return simplelru.NewLFUPolicyWithAging(period)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &LFUCache{lru: lru}, nil
+}
+
+// Add adds a value to the cache, counting as an access of the key.
+// Returns true if an eviction occurred.
+func (c *LFUCache) Add(key, value interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Add(key, value)
+}
+
+// Get looks up a key's value from the cache, incrementing its frequency.
+func (c *LFUCache) Get(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Get(key)
+}
+
+// Peek returns the key's value without updating its frequency.
+func (c *LFUCache) Peek(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Peek(key)
+}
+
+// Contains checks if a key is in the cache without updating its
+// frequency.
+func (c *LFUCache) Contains(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Contains(key)
+}
+
+// Frequency returns the current, possibly aged, access count of a key.
+func (c *LFUCache) Frequency(key interface{}) (uint64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.policy().Frequency(key)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *LFUCache) Remove(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Remove(key)
+}
+
+// Purge is used to completely clear the cache
+func (c *LFUCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lru.Purge()
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be
+// evicted to the last.
+func (c *LFUCache) Keys() []interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.policy().Keys()
+}
+
+// Len returns the number of items in the cache.
+func (c *LFUCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// policy returns the policy of the LRU, replaced by each Purge, the lock
+// must be held
+func (c *LFUCache) policy() *simplelru.LFUPolicy {
+	return c.lru.Policy().(*simplelru.LFUPolicy)
+}
@@ -0,0 +1,68 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestLFU(t *testing.T) {
+	l, err := NewLFU(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := NewLFU(0); err == nil {
+		t.Fatalf("should reject zero size")
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+	l.Get(1)
+	l.Get(2)
+
+	// A scan does not flush the frequent keys
+	for i := 10; i < 20; i++ {
+		l.Add(i, i)
+	}
+	if !l.Contains(1) || !l.Contains(2) || l.Contains(3) || l.Len() != 3 {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+	if keys := l.Keys(); keys[0] != 19 || keys[2] != 1 {
+		t.Fatalf("bad eviction order: %v", keys)
+	}
+	if f, _ := l.Frequency(1); f != 3 {
+		t.Fatalf("bad frequency: %v", f)
+	}
+
+	if v, ok := l.Peek(2); !ok || v != 2 {
+		t.Fatalf("bad peek: %v", v)
+	}
+	if !l.Remove(2) || l.Remove(2) {
+		t.Fatalf("bad remove")
+	}
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestLFU_Aging(t *testing.T) {
+	l, _ := NewLFUWithAging(2, 10)
+	l.Add(1, 1)
+	for i := 0; i < 8; i++ {
+		l.Get(1)
+	}
+	l.Add(2, 2)
+	if f, _ := l.Frequency(1); f != 4 {
+		t.Fatalf("frequency should be halved: %v", f)
+	}
+
+	// Once hot 1 decays and gives way to 2
+	for i := 0; i < 40; i++ {
+		l.Get(2)
+	}
+	l.Add(3, 3)
+	if l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("aged key should be evicted: %v", l.Keys())
+	}
+}
@@ -0,0 +1,31 @@
+package lru
+
+// LimitLoaders bounds the number of loaders of GetOrCompute and its
+// variants running at once for the keys of a class, such as a key prefix
+// naming a downstream service, so that misses on many distinct keys do
+// not fan out to that service all at once. classify assigns the class of
+// a key and limits gives the number of loads per class; classes without
+// a positive limit are not bounded. The loads over the limit wait for a
+// slot, and those of GetOrComputeCtx give up once all their callers did.
+// The limits apply to the loads started after the call; a nil classify
+// removes them.
+func (c *Cache) LimitLoaders(classify func(key interface{}) string, limits map[string]int) {
+	c.lock.Lock()
+	defer c.unlock()
+	c.classify = classify
+	c.slots = make(map[string]chan struct{}, len(limits))
+	for class, n := range limits {
+		if n > 0 {
+			c.slots[class] = make(chan struct{}, n)
+		}
+	}
+}
+
+// loaderSlots returns the semaphore of the class of key, or nil if it is
+// not bounded, the lock must be held
+func (c *Cache) loaderSlots(key interface{}) chan struct{} {
+	if c.classify == nil {
+		return nil
+	}
+	return c.slots[c.classify(key)]
+}
@@ -0,0 +1,130 @@
+package lru
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheLimitLoaders(t *testing.T) {
+	l, _ := New(16)
+	l.LimitLoaders(func(key interface{}) string {
+		s, _ := key.(string)
+		return strings.SplitN(s, ":", 2)[0]
+	}, map[string]int{"db": 2})
+
+	var running, peak, other int32
+	release := make(chan struct{})
+	loader := func() (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		return "v", nil
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"db:1", "db:2", "db:3", "db:4", "db:5"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if v, err := l.GetOrCompute(key, loader); v != "v" || err != nil {
+				t.Errorf("bad value: %v %v", v, err)
+			}
+		}(key)
+	}
+	// Other classes are not bounded by the db loads
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l.GetOrCompute(i, func() (interface{}, error) {
+				atomic.AddInt32(&other, 1)
+				return i, nil
+			})
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := atomic.LoadInt32(&other); n != 3 {
+		t.Fatalf("bad other loads: %v", n)
+	}
+	if n := atomic.LoadInt32(&running); n != 2 {
+		t.Fatalf("bad running loads: %v", n)
+	}
+	close(release)
+	wg.Wait()
+	if peak != 2 || l.Len() != 8 {
+		t.Fatalf("bad loads: %v %v", peak, l.Len())
+	}
+}
+
+// classOf puts all the keys in the class of the limit
+func classOf(interface{}) string { return "" }
+
+func TestCacheLimitLoadersCtx(t *testing.T) {
+	l, _ := New(16)
+	l.LimitLoaders(classOf, map[string]int{"": 1})
+	release := make(chan struct{})
+	go l.GetOrCompute(1, func() (interface{}, error) {
+		<-release
+		return 1, nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	// A load waiting for a slot gives up with its callers
+	var loads int32
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.GetOrComputeCtx(ctx, 2, func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		return 2, nil
+	}); err != context.DeadlineExceeded {
+		t.Fatalf("bad err: %v", err)
+	}
+	close(release)
+	l.Shutdown(context.Background())
+	if loads != 0 || l.Contains(2) {
+		t.Fatalf("cancelled load should not run: %v", loads)
+	}
+
+	// Removing the limits
+	l2, _ := New(4)
+	l2.LimitLoaders(classOf, map[string]int{"": 1})
+	l2.LimitLoaders(nil, nil)
+	if v, _ := l2.GetOrCompute(1, func() (interface{}, error) { return 1, nil }); v != 1 {
+		t.Fatalf("bad value: %v", v)
+	}
+}
+
+func TestCacheLimitLoadersPanic(t *testing.T) {
+	l, _ := New(4)
+	l.LimitLoaders(classOf, map[string]int{"": 1})
+	func() {
+		defer func() { recover() }()
+		l.GetOrCompute(1, func() (interface{}, error) {
+			panic("load")
+		})
+	}()
+
+	// The slot of the panicking loader is free again
+	done := make(chan struct{})
+	go func() {
+		l.GetOrCompute(2, func() (interface{}, error) {
+			return 2, nil
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("slot lost by the panicking loader")
+	}
+}
@@ -0,0 +1,140 @@
+package lru
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Row is an entry of a dataset to load into a cache. A zero TTL uses
+// the default expire of the cache.
+type Row struct {
+	Key   interface{}
+	Value interface{}
+	TTL   time.Duration
+}
+
+// RowDecoder decodes the rows of a dataset, passing them to emit one at
+// a time so that the dataset does not have to fit in memory. Decoding
+// stops at the first error returned by emit.
+type RowDecoder interface {
+	Decode(r io.Reader, emit func(Row) error) error
+}
+
+// CSVDecoder decodes CSV rows of key, value and optional ttl columns,
+// with the ttl in the format of time.ParseDuration. Keys and values are
+// strings.
+type CSVDecoder struct {
+	// Header skips the first row
+	Header bool
+}
+
+// Decode implements RowDecoder.
+func (d CSVDecoder) Decode(r io.Reader, emit func(Row) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if line == 1 && d.Header {
+			continue
+		}
+		if len(rec) < 2 || len(rec) > 3 {
+			return fmt.Errorf("lru: line %d: expected 2 or 3 columns, got %d", line, len(rec))
+		}
+		row := Row{Key: rec[0], Value: rec[1]}
+		if len(rec) == 3 && rec[2] != "" {
+			if row.TTL, err = time.ParseDuration(rec[2]); err != nil {
+				return fmt.Errorf("lru: line %d: %v", line, err)
+			}
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+}
+
+// JSONLDecoder decodes JSON Lines rows of objects with key, value and
+// optional ttl fields, with the ttl in the format of time.ParseDuration.
+// Keys and values are decoded as by encoding/json into an interface{};
+// keys must be strings, numbers or booleans.
+type JSONLDecoder struct{}
+
+// jsonlRow is the JSON encoding of a row
+type jsonlRow struct {
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
+	TTL   string      `json:"ttl"`
+}
+
+// Decode implements RowDecoder.
+func (JSONLDecoder) Decode(r io.Reader, emit func(Row) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var jr jsonlRow
+		if err := json.Unmarshal(sc.Bytes(), &jr); err != nil {
+			return fmt.Errorf("lru: line %d: %v", line, err)
+		}
+		switch jr.Key.(type) {
+		case nil:
+			return fmt.Errorf("lru: line %d: missing key", line)
+		case string, float64, bool:
+		default:
+			return fmt.Errorf("lru: line %d: key must be a string, number or boolean", line)
+		}
+		row := Row{Key: jr.Key, Value: jr.Value}
+		if jr.TTL != "" {
+			var err error
+			if row.TTL, err = time.ParseDuration(jr.TTL); err != nil {
+				return fmt.Errorf("lru: line %d: %v", line, err)
+			}
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// ErrClosed is returned by LoadEntriesFromFile once the cache is shut down.
+var ErrClosed = errors.New("lru: cache is shut down")
+
+// LoadEntriesFromFile warms the cache with the rows of the file at path
+// decoded by decoder, streaming them in order so that the last rows of
+// the file end up the most recently used. It returns the number of rows
+// added, which may be followed by an error if the file is malformed.
+func (c *Cache) LoadEntriesFromFile(path string, decoder RowDecoder) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	err = decoder.Decode(f, func(row Row) error {
+		c.lock.Lock()
+		defer c.unlock()
+		if c.closed {
+			return ErrClosed
+		}
+		c.lru.AddEx(row.Key, row.Value, row.TTL)
+		n++
+		return nil
+	})
+	return n, err
+}
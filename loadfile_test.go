@@ -0,0 +1,68 @@
+package lru
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return path
+}
+
+func TestCacheLoadEntriesFromCSV(t *testing.T) {
+	l, _ := New(2)
+	path := writeFile(t, "rows.csv", "key,value,ttl\na,1,\nb,2,1h\nc,3,1ms\n")
+	n, err := l.LoadEntriesFromFile(path, CSVDecoder{Header: true})
+	if err != nil || n != 3 {
+		t.Fatalf("bad load: %v %v", n, err)
+	}
+	if l.Contains("a") || !l.Contains("b") {
+		t.Fatalf("rows should be added in order: %v", l.Keys())
+	}
+	if _, expire, _ := l.PeekWithExpireTime("b"); expire == nil || time.Until(*expire) < 59*time.Minute {
+		t.Fatalf("bad ttl: %v", expire)
+	}
+
+	path = writeFile(t, "bad.csv", "a,1\nb\n")
+	if n, err := l.LoadEntriesFromFile(path, CSVDecoder{}); err == nil || n != 1 {
+		t.Fatalf("should fail on the malformed row: %v %v", n, err)
+	}
+	if _, err := l.LoadEntriesFromFile(filepath.Join(t.TempDir(), "missing"), CSVDecoder{}); err == nil {
+		t.Fatalf("should fail on a missing file")
+	}
+}
+
+func TestCacheLoadEntriesFromJSONL(t *testing.T) {
+	l, _ := New(4)
+	path := writeFile(t, "rows.jsonl", `{"key":"a","value":{"n":1}}
+
+{"key":"b","value":[1,2],"ttl":"1h"}
+`)
+	n, err := l.LoadEntriesFromFile(path, JSONLDecoder{})
+	if err != nil || n != 2 {
+		t.Fatalf("bad load: %v %v", n, err)
+	}
+	if v, _ := l.Get("a"); v.(map[string]interface{})["n"] != 1.0 {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	path = writeFile(t, "bad.jsonl", `{"value":1}`)
+	if _, err := l.LoadEntriesFromFile(path, JSONLDecoder{}); err == nil {
+		t.Fatalf("should fail on a missing key")
+	}
+
+	for _, key := range []string{`{"k":1}`, `[1]`} {
+		path = writeFile(t, "unhashable.jsonl", `{"key":"c","value":1}`+"\n"+`{"key":`+key+`,"value":1}`)
+		n, err := l.LoadEntriesFromFile(path, JSONLDecoder{})
+		if err == nil || n != 1 || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("should fail on the key %s: %v %v", key, n, err)
+		}
+	}
+}
@@ -4,16 +4,72 @@
 package lru
 
 import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hnlq715/golang-lru/simplelru"
 )
 
-// Cache is a thread-safe fixed size LRU cache.
+// Cache is a thread-safe fixed size LRU cache. The eviction callbacks are
+// invoked after the lock is released, so they may safely call back into
+// the cache.
 type Cache struct {
-	lru  *simplelru.LRU
-	lock sync.RWMutex
+	*cache
+}
+
+// cache is the state of a Cache. The background reaper only refers to
+// it, so that a Cache no longer referenced is collected, its finalizer
+// stopping the reaper.
+type cache struct {
+	// length, size, hits and misses mirror the LRU so that Len, Cap and
+	// Lookups never wait for the lock; unlock publishes them. They come
+	// first to be 64-bit aligned for the atomic operations.
+	length  int64
+	size    int64
+	hits    uint64
+	misses  uint64
+	dropped uint64
+
+	lru       *simplelru.LRU
+	lock      sync.RWMutex
+	onEvicted func(key interface{}, value interface{})
+	onReason  simplelru.EvictCallbackWithReason
+	evicted   []keyValue
+	delayed   map[*delayedRemoval]struct{}
+	pending   sync.WaitGroup
+	closed    bool
+
+	// subscribers are the channels returned by Notify
+	subscribers []*subscriber
+
+	// calls holds the loads in flight of GetOrCompute
+	calls map[interface{}]*call
+
+	// classify and slots bound the concurrent loads per key class,
+	// see LimitLoaders
+	classify func(key interface{}) string
+	slots    map[string]chan struct{}
+
+	// janitor is closed by Close to stop the background reaper
+	janitor   chan struct{}
+	closeOnce sync.Once
+
+	// name and labels are those of lru, kept to be read without the lock
+	name   string
+	labels map[string]string
+}
+
+// keyValue holds an entry copied out of the cache, such as one evicted
+// while the lock is held
+type keyValue struct {
+	key    interface{}
+	value  interface{}
+	reason simplelru.EvictReason
 }
 
 // New creates an LRU of the given size
@@ -22,58 +78,463 @@ func New(size int) (*Cache, error) {
 }
 
 // NewWithEvict constructs a fixed size cache with the given eviction
-// callback.
+// callback. The callback runs after the lock is released, so it may
+// Add, Get or Remove on the same cache.
 func NewWithEvict(size int, onEvicted func(key interface{}, value interface{})) (*Cache, error) {
-	lru, err := simplelru.NewLRU(size, simplelru.EvictCallback(onEvicted))
+	return NewWithOptions(size, onEvicted)
+}
+
+// NewWithOptions constructs a fixed size cache with the given eviction
+// callback, configured by the given options. A callback set with
+// simplelru.WithEvictCallbackWithReason is also invoked after the lock is
+// released.
+func NewWithOptions(size int, onEvicted func(key interface{}, value interface{}), opts ...simplelru.Option) (*Cache, error) {
+	lru, err := simplelru.NewLRUWithOptions(size, nil, opts...)
 	if err != nil {
 		return nil, err
 	}
-	c := &Cache{
-		lru: lru,
-	}
+	c := &Cache{&cache{
+		lru:       lru,
+		onEvicted: onEvicted,
+		onReason:  lru.EvictCallbackWithReason(),
+		name:      lru.Name(),
+		labels:    lru.Labels(),
+	}}
+	c.bindCallbacks()
+	c.publish()
+	c.startJanitor()
 	return c, nil
 }
 
+// NewWithCost constructs a cache bounded by the total cost of its
+// entries rather than by their number, see simplelru.NewLRUWithCost.
+func NewWithCost(maxCost int64, costFn func(key, value interface{}) int64, onEvicted func(key interface{}, value interface{})) (*Cache, error) {
+	if maxCost <= 0 {
+		return nil, errors.New("Must provide a positive cost")
+	}
+	return NewWithOptions(math.MaxInt, onEvicted, simplelru.WithMaxCost(maxCost, costFn))
+}
+
+// NewWithMaxBytes constructs a cache bounded by the estimated memory of
+// its entries, see simplelru.WithMaxBytes.
+func NewWithMaxBytes(maxBytes int64, sizer simplelru.Sizer, onEvicted func(key interface{}, value interface{})) (*Cache, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("Must provide a positive byte budget")
+	}
+	return NewWithOptions(math.MaxInt, onEvicted, simplelru.WithMaxBytes(maxBytes, sizer))
+}
+
 // NewWithExpire constructs a fixed size cache with expire feature
 func NewWithExpire(size int, expire time.Duration) (*Cache, error) {
-	lru, err := simplelru.NewLRUWithExpire(size, expire, nil)
-	if err != nil {
-		return nil, err
+	return NewWithOptions(size, nil, simplelru.WithExpire(expire))
+}
+
+// bindCallbacks makes the underlying LRU buffer the entries it evicts
+// for unlock to pass them to the eviction callbacks.
+func (c *Cache) bindCallbacks() {
+	c.lru.SetEvictCallback(nil)
+	if c.onEvicted == nil && c.onReason == nil && len(c.subscribers) == 0 {
+		c.lru.SetEvictCallbackWithReason(nil)
+		return
 	}
-	c := &Cache{
-		lru: lru,
+	// The LRU refers to the state only, see cache
+	st := c.cache
+	c.lru.SetEvictCallbackWithReason(func(key, value interface{}, reason simplelru.EvictReason) {
+		if reason == simplelru.EvictReplaced && st.onReason == nil && len(st.subscribers) == 0 {
+			return
+		}
+		st.evicted = append(st.evicted, keyValue{key, value, reason})
+	})
+}
+
+// unlock releases the write lock, then invokes the eviction callbacks for
+// the entries evicted while it was held.
+func (c *Cache) unlock() {
+	c.publish()
+	evicted := c.evicted
+	c.evicted = nil
+	subscribers := c.subscribers
+	c.lock.Unlock()
+	for _, kv := range evicted {
+		for _, s := range subscribers {
+			c.publishEvent(s, kv)
+		}
+		if c.onEvicted != nil && kv.reason != simplelru.EvictReplaced {
+			c.onEvicted(kv.key, kv.value)
+		}
+		if c.onReason != nil {
+			c.onReason(kv.key, kv.value, kv.reason)
+		}
 	}
-	return c, nil
+}
+
+// publish copies the size and counters of the LRU for the lock-free
+// readers, the lock must be held
+func (c *Cache) publish() {
+	atomic.StoreInt64(&c.length, int64(c.lru.Len()))
+	atomic.StoreInt64(&c.size, int64(c.lru.Cap()))
+	hits, misses := c.lru.Lookups()
+	atomic.StoreUint64(&c.hits, hits)
+	atomic.StoreUint64(&c.misses, misses)
+}
+
+// Clone returns an independent copy of the cache with the same
+// configuration and entries, preserving their order and expire times.
+// Values are copied as is.
+func (c *Cache) Clone() *Cache {
+	return c.CloneFunc(nil)
+}
+
+// CloneFunc is like Clone but copies each value with copyValue, allowing
+// a deep copy of the entries.
+func (c *Cache) CloneFunc(copyValue func(value interface{}) interface{}) *Cache {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	n := &Cache{&cache{
+		lru:       c.lru.CloneFunc(copyValue),
+		onEvicted: c.onEvicted,
+		onReason:  c.onReason,
+		name:      c.name,
+		labels:    c.labels,
+	}}
+	n.bindCallbacks()
+	n.publish()
+	n.startJanitor()
+	return n
+}
+
+// cloneLRU copies the LRU under the read lock, without the callbacks and
+// background reaper of a Cache.
+func (c *Cache) cloneLRU() *simplelru.LRU {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Clone()
+}
+
+// startJanitor starts the background reaper if the cache was configured
+// with simplelru.WithJanitor.
+func (c *Cache) startJanitor() {
+	interval := c.lru.JanitorInterval()
+	if interval <= 0 {
+		return
+	}
+	c.janitor = make(chan struct{})
+	runtime.SetFinalizer(c, (*Cache).Close)
+	st, janitor := c.cache, c.janitor
+	clock := c.lru.Clock()
+	delay := c.lru.JanitorDelay()
+	var ticks <-chan time.Time
+	var stop func()
+	if delay <= 0 {
+		// Start ticking before returning, so that a manual clock
+		// advanced right away fires the ticker
+		ticks, stop = clock.NewTicker(interval)
+	}
+	go func() {
+		if delay > 0 {
+			timer, stopTimer := clock.NewTimer(delay)
+			select {
+			case <-timer:
+			case <-janitor:
+				stopTimer()
+				return
+			}
+			ticks, stop = clock.NewTicker(interval)
+		}
+		defer stop()
+		for {
+			select {
+			case <-ticks:
+				(&Cache{st}).RunJanitorOnce(0)
+			case <-janitor:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the background reaper, if any. The cache stays usable and
+// expired entries can still be reaped with DeleteExpired.
+// A cache no longer referenced is closed once garbage collected.
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() {
+		if c.janitor != nil {
+			close(c.janitor)
+		}
+	})
+}
+
+// RunJanitorOnce runs a sweep of the janitor now, examining at most max
+// entries, or the budget set by simplelru.WithJanitorBudget if max is 0,
+// and returns how many expired entries it removed. Like the sweeps of the
+// janitor, a sweep stopping early resumes where it stopped. With a
+// simplelru.ManualClock, it steps the expiration of tests without
+// waiting for the janitor.
+func (c *Cache) RunJanitorOnce(max int) int {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.SweepExpiredN(max)
+}
+
+// DeleteExpired removes the expired entries from the cache, invoking the
+// eviction callback for each of them, and returns how many were removed.
+func (c *Cache) DeleteExpired() int {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.DeleteExpired()
 }
 
 // Purge is used to completely clear the cache
 func (c *Cache) Purge() {
 	c.lock.Lock()
+	defer c.unlock()
 	c.lru.Purge()
-	c.lock.Unlock()
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
+// Adds are ignored once the cache is shut down.
 func (c *Cache) Add(key, value interface{}) bool {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	return c.lru.Add(key, value)
+	return c.AddEx(key, value, 0)
 }
 
 // AddEx adds a value to the cache.  Returns true if an eviction occurred.
 func (c *Cache) AddEx(key, value interface{}, expire time.Duration) bool {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
+	if c.dropAdd("AddEx") {
+		return false
+	}
 	return c.lru.AddEx(key, value, expire)
 }
 
+// AddExSoft adds a value to the cache with a soft and a hard TTL, see
+// simplelru.LRU.AddExSoft.  Returns true if an eviction occurred.
+func (c *Cache) AddExSoft(key, value interface{}, soft, hard time.Duration) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddExSoft") {
+		return false
+	}
+	return c.lru.AddExSoft(key, value, soft, hard)
+}
+
+// AddExAt adds a value to the cache with expire counted from now, see
+// simplelru.LRU.AddExAt.  Returns true if an eviction occurred.
+func (c *Cache) AddExAt(key, value interface{}, expire time.Duration, now time.Time) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddExAt") {
+		return false
+	}
+	return c.lru.AddExAt(key, value, expire, now)
+}
+
+// AddWithPriority adds a value to the cache with the given priority.
+// Returns true if an eviction occurred.
+func (c *Cache) AddWithPriority(key, value interface{}, priority simplelru.Priority) bool {
+	return c.AddExWithPriority(key, value, 0, priority)
+}
+
+// AddExWithPriority adds a value to the cache with expire and the given
+// priority. Returns true if an eviction occurred.
+func (c *Cache) AddExWithPriority(key, value interface{}, expire time.Duration, priority simplelru.Priority) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddExWithPriority") {
+		return false
+	}
+	return c.lru.AddExWithPriority(key, value, expire, priority)
+}
+
+// AddWithCost adds a value to the cache with the given cost, evicting
+// entries until the total cost fits the budget. Returns true if an
+// eviction occurred.
+func (c *Cache) AddWithCost(key, value interface{}, cost int64) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddWithCost") {
+		return false
+	}
+	return c.lru.AddWithCost(key, value, cost)
+}
+
+// Cost returns the total cost of the entries in the cache.
+func (c *Cache) Cost() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Cost()
+}
+
+// MaxCost returns the cost budget of the cache, or 0 if it is only
+// bounded by the number of entries.
+func (c *Cache) MaxCost() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.MaxCost()
+}
+
+// SetMaxCost changes the cost budget, evicting entries until their total
+// cost fits it. Returns the number of evicted entries.
+func (c *Cache) SetMaxCost(maxCost int64) (evicted int) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.SetMaxCost(maxCost)
+}
+
+// BytesUsed returns the estimated memory of the entries in a cache built
+// by NewWithMaxBytes.
+func (c *Cache) BytesUsed() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.BytesUsed()
+}
+
+// AddWithOrigin adds a value to the cache with expire, recording origin
+// as the source that produced it. Returns true if an eviction occurred.
+func (c *Cache) AddWithOrigin(key, value interface{}, expire time.Duration, origin string) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddWithOrigin") {
+		return false
+	}
+	return c.lru.AddWithOrigin(key, value, expire, origin)
+}
+
+// OriginStats returns the stats of each origin, see
+// simplelru.WithOriginStats.
+func (c *Cache) OriginStats() map[string]simplelru.OriginStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.OriginStats()
+}
+
+// AddImmutable adds a write-once value to the cache with expire, which
+// cannot be overwritten until it is removed, evicted or expires. Returns
+// simplelru.ErrImmutable if the key is already held by such an entry.
+func (c *Cache) AddImmutable(key, value interface{}, expire time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddImmutable") {
+		return false, nil
+	}
+	return c.lru.AddImmutable(key, value, expire)
+}
+
 // Get looks up a key's value from the cache.
 func (c *Cache) Get(key interface{}) (interface{}, bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
 	return c.lru.Get(key)
 }
 
+// AddInt64 adds an int64 value stored inline in its entry, see
+// simplelru.LRU.AddInt64.  Returns true if an eviction occurred.
+func (c *Cache) AddInt64(key interface{}, value int64) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddInt64") {
+		return false
+	}
+	return c.lru.AddInt64(key, value)
+}
+
+// GetInt64 looks up an int64 value without boxing it, see
+// simplelru.LRU.GetInt64.
+func (c *Cache) GetInt64(key interface{}) (int64, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.GetInt64(key)
+}
+
+// PeekInt64 returns an int64 value without boxing it or updating the
+// "recently used"-ness of the key.
+func (c *Cache) PeekInt64(key interface{}) (int64, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.PeekInt64(key)
+}
+
+// PeekAndUpdate replaces the value of a live key without updating its
+// recent-ness, see simplelru.LRU.PeekAndUpdate.
+func (c *Cache) PeekAndUpdate(key, value interface{}) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("PeekAndUpdate") {
+		return false
+	}
+	return c.lru.PeekAndUpdate(key, value)
+}
+
+// AddIfAbsent adds a value as the oldest entry if the key is absent, see
+// simplelru.LRU.AddIfAbsent.
+func (c *Cache) AddIfAbsent(key, value interface{}) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("AddIfAbsent") {
+		return false
+	}
+	return c.lru.AddIfAbsent(key, value)
+}
+
+// GetWithWeight looks up a key's value, only promoting it with
+// probability w, see simplelru.LRU.GetWithWeight.
+func (c *Cache) GetWithWeight(key interface{}, w float64) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.GetWithWeight(key, w)
+}
+
+// GetAt looks up a key's value with the expiration checked at now, see
+// simplelru.LRU.GetAt.
+func (c *Cache) GetAt(key interface{}, now time.Time) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.GetAt(key, now)
+}
+
+// GetStale looks up a key's value, also returning the value of an expired
+// entry still in the cache flagged as expired, see simplelru.LRU.GetStale.
+func (c *Cache) GetStale(key interface{}) (value interface{}, expired, ok bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.GetStale(key)
+}
+
+// GetMany looks up the values of several keys under a single lock
+// acquisition, returning them along with whether each key was found.
+func (c *Cache) GetMany(keys []interface{}) (values []interface{}, found []bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.GetMany(keys)
+}
+
+// SetMany adds several entries under a single lock acquisition, evicting
+// once after the whole batch, and returns the evicted entries, see
+// simplelru.LRU.SetMany.
+func (c *Cache) SetMany(entries []simplelru.KV) (victims []simplelru.KV) {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("SetMany") {
+		return nil
+	}
+	return c.lru.SetMany(entries)
+}
+
+// Touch marks a live key as recently used without returning its value,
+// restarting its expire time if the cache has a sliding expire.
+func (c *Cache) Touch(key interface{}) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Touch(key)
+}
+
+// SetTTL sets a live key to expire after d without changing its value.
+func (c *Cache) SetTTL(key interface{}, d time.Duration) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.SetTTL(key, d)
+}
+
 // Check if a key is in the cache, without updating the recent-ness
 // or deleting it for being stale.
 func (c *Cache) Contains(key interface{}) bool {
@@ -90,33 +551,189 @@ func (c *Cache) Peek(key interface{}) (interface{}, bool) {
 	return c.lru.Peek(key)
 }
 
+// PeekWithExpireTime returns the key value (or undefined if not found)
+// and its associated expire time without updating the "recently
+// used"-ness of the key.
+func (c *Cache) PeekWithExpireTime(key interface{}) (value interface{}, expire *time.Time, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.PeekWithExpireTime(key)
+}
+
+// PeekWithPosition returns the key value (or undefined if not found) and
+// its distance from the most recently used entry without updating the
+// "recently used"-ness of the key.
+func (c *Cache) PeekWithPosition(key interface{}) (value interface{}, position int, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.PeekWithPosition(key)
+}
+
 // ContainsOrAdd checks if a key is in the cache  without updating the
 // recent-ness or deleting it for being stale,  and if not, adds the value.
 // Returns whether found and whether an eviction occurred.
 func (c *Cache) ContainsOrAdd(key, value interface{}) (ok, evict bool) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	defer c.unlock()
+	if c.dropAdd("ContainsOrAdd") {
+		return c.lru.Contains(key), false
+	}
+	return c.lru.ContainsOrAdd(key, value)
+}
 
-	if c.lru.Contains(key) {
-		return true, false
-	} else {
-		evict := c.lru.Add(key, value)
-		return false, evict
+// PeekOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns the previous value if found, whether found and whether an
+// eviction occurred.
+func (c *Cache) PeekOrAdd(key, value interface{}) (previous interface{}, ok, evict bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("PeekOrAdd") {
+		previous, ok = c.lru.Peek(key)
+		return previous, ok, false
 	}
+	return c.lru.PeekOrAdd(key, value)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Cache) Remove(key interface{}) (present bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Remove(key)
+}
+
+// RemoveIf removes the provided key from the cache if pred holds for its
+// current value, checking and removing it under the lock so that no Add
+// can slip in between. pred must not call back into the cache.
+func (c *Cache) RemoveIf(key interface{}, pred func(value interface{}) bool) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.RemoveIf(key, pred)
 }
 
-// Remove removes the provided key from the cache.
-func (c *Cache) Remove(key interface{}) {
+// IsTombstoned returns if the key was removed less than the tombstone
+// period ago, see simplelru.WithTombstones.
+func (c *Cache) IsTombstoned(key interface{}) bool {
 	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.IsTombstoned(key)
+}
+
+// Pin protects a key from being evicted to make room for new entries
+// until a matching Unpin. Returns false if the key is not in the cache.
+func (c *Cache) Pin(key interface{}) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Pin(key)
+}
+
+// Unpin releases a Pin of the key, returning false if the key is not in
+// the cache or not pinned.
+func (c *Cache) Unpin(key interface{}) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Unpin(key)
+}
+
+// RemoveDelayed removes the provided key from the cache now and once more
+// after delay, timed by the clock of the cache. The second removal
+// discards a stale value that a concurrent reader may have put back
+// between a write to the backing store and the first removal.
+func (c *Cache) RemoveDelayed(key interface{}, delay time.Duration) {
+	c.lock.Lock()
+	defer c.unlock()
 	c.lru.Remove(key)
-	c.lock.Unlock()
+	if c.closed {
+		return
+	}
+
+	fire, stop := c.lru.Clock().NewTimer(delay)
+	d := &delayedRemoval{key: key, stop: stop, cancel: make(chan struct{})}
+	if c.delayed == nil {
+		c.delayed = make(map[*delayedRemoval]struct{})
+	}
+	c.delayed[d] = struct{}{}
+	c.pending.Add(1)
+	go func() {
+		defer c.pending.Done()
+		select {
+		case <-fire:
+		case <-d.cancel:
+			return
+		}
+		c.lock.Lock()
+		defer c.unlock()
+		// Shutdown may have flushed the removal while the timer fired
+		if _, ok := c.delayed[d]; ok {
+			delete(c.delayed, d)
+			c.lru.Remove(key)
+		}
+	}()
+}
+
+// delayedRemoval is a removal scheduled by RemoveDelayed on the clock of
+// the cache, see simplelru.WithClock.
+type delayedRemoval struct {
+	key    interface{}
+	stop   func()
+	cancel chan struct{}
+}
+
+// dropAdd returns if an add must be ignored because the cache is shut
+// down, panicking instead in strict mode, see simplelru.WithStrict. The
+// lock must be held.
+func (c *Cache) dropAdd(op string) bool {
+	if !c.closed {
+		return false
+	}
+	if c.lru.Strict() {
+		panic(&simplelru.MisuseError{Op: op, Err: ErrClosed})
+	}
+	return true
+}
+
+// Shutdown stops the cache from accepting new entries and flushes the
+// pending delayed removals, waiting for the ones already running. It
+// returns ctx.Err() if ctx is done first. Reads and removals keep
+// working after Shutdown, and the background reaper is stopped.
+func (c *Cache) Shutdown(ctx context.Context) error {
+	c.Close()
+	c.lock.Lock()
+	c.closed = true
+	for d := range c.delayed {
+		delete(c.delayed, d)
+		d.stop()
+		close(d.cancel)
+		c.lru.Remove(d.key)
+	}
+	c.unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // RemoveOldest removes the oldest item from the cache.
-func (c *Cache) RemoveOldest() {
+func (c *Cache) RemoveOldest() (key, value interface{}, ok bool) {
 	c.lock.Lock()
-	c.lru.RemoveOldest()
-	c.lock.Unlock()
+	defer c.unlock()
+	return c.lru.RemoveOldest()
+}
+
+// GetOldest returns the oldest entry
+func (c *Cache) GetOldest() (key, value interface{}, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.GetOldest()
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
@@ -126,9 +743,198 @@ func (c *Cache) Keys() []interface{} {
 	return c.lru.Keys()
 }
 
-// Len returns the number of items in the cache.
+// SampleKeys returns a uniform random sample of up to n keys resident in
+// the cache drawn from a source seeded with seed, in O(n) time.
+func (c *Cache) SampleKeys(n int, seed int64) []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.SampleKeys(n, seed)
+}
+
+// KeysMRU returns a slice of the keys in the cache, from newest to oldest.
+func (c *Cache) KeysMRU() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.KeysMRU()
+}
+
+// Values returns a slice of the values in the cache, from oldest to newest.
+func (c *Cache) Values() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Values()
+}
+
+// NextExpiry returns the earliest expire time among the live entries
+// in the cache. ok is false if no live entry has an expire time.
+func (c *Cache) NextExpiry() (time.Time, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.NextExpiry()
+}
+
+// ExpiringBefore returns the keys of the live entries that will expire
+// before t, ordered from soonest to latest expiration.
+func (c *Cache) ExpiringBefore(t time.Time) []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ExpiringBefore(t)
+}
+
+// Len returns the number of items in the cache. It does not take the
+// lock, so monitoring never delays the users of the cache.
 func (c *Cache) Len() int {
+	return int(atomic.LoadInt64(&c.length))
+}
+
+// Cap returns the maximum number of items the cache can hold. It does not
+// take the lock.
+func (c *Cache) Cap() int {
+	return int(atomic.LoadInt64(&c.size))
+}
+
+// Resize changes the cache size, returning the number of evicted items.
+func (c *Cache) Resize(size int) (evicted int) {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.lru.Resize(size)
+}
+
+// ResizeDeferred changes the cache size, spreading the evictions of a
+// shrink over the following Adds, see simplelru.LRU.ResizeDeferred.
+func (c *Cache) ResizeDeferred(size int) {
+	c.lock.Lock()
+	defer c.unlock()
+	c.lru.ResizeDeferred(size)
+}
+
+// CheckInvariants validates the internal consistency of the cache under
+// the lock, see simplelru.LRU.CheckInvariants.
+func (c *Cache) CheckInvariants() error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.CheckInvariants()
+}
+
+// Name returns the name set by simplelru.WithName.
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// Labels returns a copy of the labels set by simplelru.WithLabels, nil if
+// none.
+func (c *Cache) Labels() map[string]string {
+	if c.labels == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(c.labels))
+	for k, v := range c.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// Lookups returns the hit and miss counters of the cache without taking
+// the lock, for monitoring scrapes that must not add latency to the
+// operations on the cache.
+func (c *Cache) Lookups() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+// Counters returns the counters of Stats without the scan of the cache,
+// see simplelru.LRU.Counters.
+func (c *Cache) Counters() simplelru.Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Counters()
+}
+
+// Stats returns the lookup counters of the cache along with the number
+// and cost of resident expired entries. Counting these takes a scan under
+// the read lock; Lookups, Counters and Len are cheaper for frequent
+// monitoring.
+func (c *Cache) Stats() simplelru.Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Stats()
+}
+
+// StatsOver returns the stats of the cache with the lookup counters
+// restricted to the given window, see simplelru.LRU.StatsOver.
+func (c *Cache) StatsOver(window time.Duration) simplelru.Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.StatsOver(window)
+}
+
+// ResetStats zeroes the lookup counters of the cache.
+func (c *Cache) ResetStats() {
+	c.lock.Lock()
+	defer c.unlock()
+	c.lru.ResetStats()
+}
+
+// Diff compares the live entries of a and b without updating their
+// recent-ness. Each cache is copied under its own lock, so the caches
+// are never locked together.
+func Diff(a, b *Cache) simplelru.Difference {
+	return simplelru.Diff(a.cloneLRU(), b.cloneLRU())
+}
+
+// ChurningKeys returns the keys flagged by churn tracking, or nil if
+// churn tracking is disabled.
+func (c *Cache) ChurningKeys() []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ChurningKeys()
+}
+
+// KeyspaceEstimate returns the estimated number of distinct keys
+// requested from the cache, or 0 if the estimate is disabled.
+func (c *Cache) KeyspaceEstimate() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.KeyspaceEstimate()
+}
+
+// ReuseQuantile returns the q-quantile of the observed intervals between
+// hits on keys of the given class when reuse observation is enabled.
+func (c *Cache) ReuseQuantile(class string, q float64) (time.Duration, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.ReuseQuantile(class, q)
+}
+
+// TTLQuantile returns the q-quantile of the TTLs assigned to the entries
+// added when lifetime histograms are enabled.
+func (c *Cache) TTLQuantile(q float64) (time.Duration, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.TTLQuantile(q)
+}
+
+// LifetimeQuantile returns the q-quantile of the time spent in the cache
+// by the entries that left it for reason when lifetime histograms are
+// enabled.
+func (c *Cache) LifetimeQuantile(reason simplelru.EvictReason, q float64) (time.Duration, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.LifetimeQuantile(reason, q)
+}
+
+// HotKeys returns the n live keys hit the most since they were added,
+// the hottest first.
+func (c *Cache) HotKeys(n int) []simplelru.HotKey {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.HotKeys(n)
+}
+
+// RecommendedSize returns the size beyond which growing the cache would
+// gain less than minGain of the lookups as hits, as simulated by
+// simplelru.WithShadow, see simplelru.LRU.RecommendedSize.
+func (c *Cache) RecommendedSize(minGain float64) (int, bool) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	return c.lru.Len()
+	return c.lru.RecommendedSize(minGain)
 }
@@ -1,69 +1,94 @@
 package lru
 
 import (
+	"context"
+	"errors"
 	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
 )
 
+// benchPolicies are the eviction policies compared by the benchmarks
+var benchPolicies = []struct {
+	name string
+	opts []simplelru.Option
+}{
+	{"lru", nil},
+	{"random", []simplelru.Option{simplelru.WithEvictionPolicy(simplelru.EvictRandom)}},
+}
+
 func BenchmarkLRU_Rand(b *testing.B) {
-	l, err := New(8192)
-	if err != nil {
-		b.Fatalf("err: %v", err)
-	}
+	for _, p := range benchPolicies {
+		b.Run(p.name, func(b *testing.B) {
+			l, err := NewWithOptions(8192, nil, p.opts...)
+			if err != nil {
+				b.Fatalf("err: %v", err)
+			}
 
-	trace := make([]int64, b.N*2)
-	for i := 0; i < b.N*2; i++ {
-		trace[i] = rand.Int63() % 32768
-	}
+			trace := make([]int64, b.N*2)
+			for i := 0; i < b.N*2; i++ {
+				trace[i] = rand.Int63() % 32768
+			}
 
-	b.ResetTimer()
+			b.ResetTimer()
 
-	var hit, miss int
-	for i := 0; i < 2*b.N; i++ {
-		if i%2 == 0 {
-			l.Add(trace[i], trace[i])
-		} else {
-			_, ok := l.Get(trace[i])
-			if ok {
-				hit++
-			} else {
-				miss++
+			var hit, miss int
+			for i := 0; i < 2*b.N; i++ {
+				if i%2 == 0 {
+					l.Add(trace[i], trace[i])
+				} else {
+					_, ok := l.Get(trace[i])
+					if ok {
+						hit++
+					} else {
+						miss++
+					}
+				}
 			}
-		}
+			b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
+		})
 	}
-	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
 }
 
 func BenchmarkLRU_Freq(b *testing.B) {
-	l, err := New(8192)
-	if err != nil {
-		b.Fatalf("err: %v", err)
-	}
+	for _, p := range benchPolicies {
+		b.Run(p.name, func(b *testing.B) {
+			l, err := NewWithOptions(8192, nil, p.opts...)
+			if err != nil {
+				b.Fatalf("err: %v", err)
+			}
 
-	trace := make([]int64, b.N*2)
-	for i := 0; i < b.N*2; i++ {
-		if i%2 == 0 {
-			trace[i] = rand.Int63() % 16384
-		} else {
-			trace[i] = rand.Int63() % 32768
-		}
-	}
+			trace := make([]int64, b.N*2)
+			for i := 0; i < b.N*2; i++ {
+				if i%2 == 0 {
+					trace[i] = rand.Int63() % 16384
+				} else {
+					trace[i] = rand.Int63() % 32768
+				}
+			}
 
-	b.ResetTimer()
+			b.ResetTimer()
 
-	for i := 0; i < b.N; i++ {
-		l.Add(trace[i], trace[i])
-	}
-	var hit, miss int
-	for i := 0; i < b.N; i++ {
-		_, ok := l.Get(trace[i])
-		if ok {
-			hit++
-		} else {
-			miss++
-		}
+			for i := 0; i < b.N; i++ {
+				l.Add(trace[i], trace[i])
+			}
+			var hit, miss int
+			for i := 0; i < b.N; i++ {
+				_, ok := l.Get(trace[i])
+				if ok {
+					hit++
+				} else {
+					miss++
+				}
+			}
+			b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
+		})
 	}
-	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(miss))
 }
 
 func TestLRU(t *testing.T) {
@@ -219,3 +244,623 @@ func TestLRUPeek(t *testing.T) {
 		t.Errorf("should not have updated recent-ness of 1")
 	}
 }
+
+func TestLRUNextExpiry(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	if _, ok := l.NextExpiry(); ok {
+		t.Fatalf("should have no expiry")
+	}
+	l.AddEx(2, 2, time.Minute)
+	if _, ok := l.NextExpiry(); !ok {
+		t.Fatalf("should have an expiry")
+	}
+	keys := l.ExpiringBefore(time.Now().Add(2 * time.Minute))
+	if len(keys) != 1 || keys[0] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestLRURemoveDelayed(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.RemoveDelayed(1, 50*time.Millisecond)
+	if l.Contains(1) {
+		t.Fatalf("should be removed")
+	}
+
+	l.Add(1, 1)
+	time.Sleep(100 * time.Millisecond)
+	if l.Contains(1) {
+		t.Fatalf("should be removed again")
+	}
+}
+
+func TestLRURemoveDelayedClock(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Unix(0, 0))
+	l, _ := NewWithOptions(2, nil, simplelru.WithClock(clock))
+	l.RemoveDelayed(1, time.Minute)
+	l.Add(1, 1)
+	clock.Advance(30 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if !l.Contains(1) {
+		t.Fatalf("should not be removed before the delay")
+	}
+	clock.Advance(30 * time.Second)
+	for i := 0; i < 100 && l.Contains(1); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if l.Contains(1) {
+		t.Fatalf("should be removed once the clock reaches the delay")
+	}
+}
+
+func TestLRUShutdown(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.RemoveDelayed(2, time.Hour)
+	l.Add(2, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Shutdown(ctx); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if l.Contains(2) {
+		t.Fatalf("delayed removal should have been flushed")
+	}
+	if !l.Contains(1) {
+		t.Fatalf("1 should still be readable")
+	}
+
+	l.Add(3, 3)
+	if ok, _ := l.ContainsOrAdd(4, 4); ok || l.Len() != 1 {
+		t.Fatalf("should not accept new entries after shutdown")
+	}
+}
+
+func TestLRUStrictShutdown(t *testing.T) {
+	l, err := NewWithOptions(2, nil, simplelru.WithStrict())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	defer func() {
+		err, ok := recover().(*simplelru.MisuseError)
+		if !ok || err.Op != "AddEx" || !errors.Is(err, ErrClosed) {
+			t.Fatalf("bad panic: %v", err)
+		}
+		// The lock must have been released
+		l.Purge()
+	}()
+	l.Add(1, 1)
+}
+
+func TestLRUInsertionOrder(t *testing.T) {
+	l, err := NewWithOptions(2, nil, simplelru.WithEvictionOrder(simplelru.InsertionOrder))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Fatalf("1 should have been evicted")
+	}
+}
+
+func TestLRUPriority(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddWithPriority(1, 1, simplelru.PriorityHigh)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	if !l.Contains(1) || l.Contains(2) {
+		t.Fatalf("2 should have been evicted")
+	}
+}
+
+func TestLRUClone(t *testing.T) {
+	l, err := New(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+
+	c := l.Clone()
+	c.Remove(1)
+	if !l.Contains(1) || c.Contains(1) || !c.Contains(2) {
+		t.Fatalf("clone should be independent")
+	}
+
+	named, _ := NewWithOptions(2, nil, simplelru.WithName("users"), simplelru.WithLabels(map[string]string{"tier": "hot"}))
+	if c := named.Clone(); c.Name() != "users" || c.Labels()["tier"] != "hot" {
+		t.Fatalf("clone should keep the name and labels: %q %v", c.Name(), c.Labels())
+	}
+}
+
+func TestLRUDiff(t *testing.T) {
+	a, _ := New(2)
+	a.Add(1, 1)
+	b := a.Clone()
+	if d := Diff(a, b); !d.Equal() {
+		t.Fatalf("should be equal: %+v", d)
+	}
+	b.Add(2, 2)
+	if d := Diff(a, b); len(d.OnlyInB) != 1 {
+		t.Fatalf("bad diff: %+v", d)
+	}
+	if d := Diff(a, a); !d.Equal() {
+		t.Fatalf("should be equal: %+v", d)
+	}
+}
+
+func TestLRUPeekWithPosition(t *testing.T) {
+	l, _ := New(2)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if _, pos, ok := l.PeekWithPosition(1); !ok || pos != 1 {
+		t.Fatalf("bad: %v, %v", pos, ok)
+	}
+}
+
+func TestLRUAddImmutable(t *testing.T) {
+	l, _ := New(2)
+	if _, err := l.AddImmutable(1, 1, 0); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := l.AddImmutable(1, 2, 0); err != simplelru.ErrImmutable {
+		t.Fatalf("bad err: %v", err)
+	}
+	l.Add(1, 3)
+	if v, _ := l.Get(1); v != 1 {
+		t.Fatalf("bad: %v", v)
+	}
+}
+
+func TestLRUGetMany(t *testing.T) {
+	l, _ := New(2)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	values, found := l.GetMany([]interface{}{1, 3})
+	if !found[0] || values[0] != 1 || found[1] {
+		t.Fatalf("bad: %v, %v", values, found)
+	}
+	l.Add(3, 3)
+	if l.Contains(2) {
+		t.Fatalf("1 should have been promoted")
+	}
+}
+
+func TestLRUPin(t *testing.T) {
+	l, _ := New(1)
+	l.Add(1, 1)
+	if !l.Pin(1) {
+		t.Fatalf("should pin")
+	}
+	l.Add(2, 2)
+	if !l.Contains(1) {
+		t.Fatalf("pinned entry should survive")
+	}
+	if !l.Unpin(1) {
+		t.Fatalf("should unpin")
+	}
+}
+
+func TestLRUEvictOutsideLock(t *testing.T) {
+	var l *Cache
+	evicted := 0
+	l, _ = NewWithEvict(2, func(k, v interface{}) {
+		// Calling back into the cache must not deadlock
+		if l.Contains(k) {
+			t.Errorf("evicted key %v still in cache", k)
+		}
+		l.Len()
+		evicted++
+	})
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	if evicted != 2 {
+		t.Fatalf("bad evicted count: %d", evicted)
+	}
+	if k, _, ok := l.RemoveOldest(); !ok || k != 2 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	if !l.Remove(3) || l.Remove(3) {
+		t.Fatalf("bad remove")
+	}
+	if evicted != 4 {
+		t.Fatalf("bad evicted count: %d", evicted)
+	}
+
+	l.AddEx(5, 5, time.Minute)
+	if _, expire, ok := l.PeekWithExpireTime(5); !ok || expire == nil {
+		t.Fatalf("bad expire time")
+	}
+	if k, _, ok := l.GetOldest(); !ok || k != 5 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+
+	// The clone shares the callback but evicts its own entries
+	c := l.Clone()
+	l = c
+	c.Purge()
+	if evicted != 5 || c.Len() != 0 {
+		t.Fatalf("bad clone callback: %d %d", evicted, c.Len())
+	}
+}
+
+func TestLRUReentrantCallback(t *testing.T) {
+	var l *Cache
+	l, _ = NewWithEvict(4, func(k, v interface{}) {
+		// Re-adding from the callback evicts again; limit the recursion
+		if n := v.(int); n < 3 {
+			l.Add(k, n+1)
+		}
+		l.Get(k)
+		l.Remove(-1)
+	})
+
+	done := make(chan struct{})
+	for g := 0; g < 4; g++ {
+		go func(g int) {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 100; i++ {
+				l.Add(g*100+i, 0)
+			}
+		}(g)
+	}
+	for g := 0; g < 4; g++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("deadlock in reentrant callback")
+		}
+	}
+	if l.Len() != 4 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestLRUJanitor(t *testing.T) {
+	evicted := make(chan interface{}, 4)
+	l, _ := NewWithOptions(4, func(k, v interface{}) {
+		evicted <- k
+	}, simplelru.WithExpire(time.Millisecond), simplelru.WithJanitor(5*time.Millisecond))
+	defer l.Close()
+
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Hour)
+	select {
+	case k := <-evicted:
+		if k != 1 {
+			t.Fatalf("bad evicted key: %v", k)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("janitor did not reap expired entry")
+	}
+	if l.Len() != 1 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	l.Close()
+	l.Close()
+	l.AddEx(3, 3, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if l.Len() != 2 {
+		t.Fatalf("janitor still running after Close")
+	}
+	if n := l.DeleteExpired(); n != 1 {
+		t.Fatalf("bad deleted: %d", n)
+	}
+}
+
+func TestLRUJanitorCollected(t *testing.T) {
+	before := runtime.NumGoroutine()
+	a, _ := NewWithOptions(4, nil, simplelru.WithJanitor(time.Hour))
+	b, _ := NewWithOptions(4, nil, simplelru.WithJanitor(time.Hour))
+	defer a.Close()
+	defer b.Close()
+	for i := 0; i < 100; i++ {
+		Diff(a, b)
+	}
+	if n := runtime.NumGoroutine(); n > before+2 {
+		t.Fatalf("Diff should start no janitor: %d goroutines, %d before", n, before)
+	}
+
+	// Unreferenced caches stop their janitor once collected
+	for i := 0; i < 10; i++ {
+		NewWithOptions(4, nil, simplelru.WithJanitor(time.Hour))
+	}
+	for i := 0; i < 100 && runtime.NumGoroutine() > before+2; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before+2 {
+		t.Fatalf("janitors of collected caches still running: %d goroutines, %d before", n, before)
+	}
+}
+
+func TestLRUCost(t *testing.T) {
+	evicted := 0
+	l, err := NewWithCost(10, func(k, v interface{}) int64 {
+		return int64(len(v.([]byte)))
+	}, func(k, v interface{}) { evicted++ })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, make([]byte, 6))
+	l.AddWithCost(2, nil, 3)
+	if l.Cost() != 9 {
+		t.Fatalf("bad cost: %v", l.Cost())
+	}
+	if !l.Add(3, make([]byte, 4)) || evicted != 1 || l.Cost() != 7 {
+		t.Fatalf("bad eviction: %v %v", evicted, l.Cost())
+	}
+}
+
+func TestLRUMaxBytes(t *testing.T) {
+	evicted := 0
+	l, err := NewWithMaxBytes(1<<20, nil, func(k, v interface{}) { evicted++ })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		l.Add(i, make([]byte, 200<<10))
+	}
+	if l.Len() != 5 || evicted != 3 || l.BytesUsed() > 1<<20 || l.BytesUsed() < 1000<<10 {
+		t.Fatalf("bad len: %v evicted: %v bytes: %v", l.Len(), evicted, l.BytesUsed())
+	}
+}
+
+func TestLRUEvictReason(t *testing.T) {
+	var l *Cache
+	var reasons []simplelru.EvictReason
+	evicted := 0
+	l, _ = NewWithOptions(1, func(k, v interface{}) {
+		evicted++
+	}, simplelru.WithEvictCallbackWithReason(func(k, v interface{}, reason simplelru.EvictReason) {
+		// Invoked outside the lock
+		l.Len()
+		reasons = append(reasons, reason)
+	}))
+	l.Add(1, 1)
+	l.Add(1, 2)
+	l.Add(2, 2)
+	l.Remove(2)
+
+	want := []simplelru.EvictReason{simplelru.EvictReplaced, simplelru.EvictCapacity, simplelru.EvictRemoved}
+	if len(reasons) != len(want) || evicted != 2 {
+		t.Fatalf("bad reasons: %v %v", reasons, evicted)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Fatalf("bad reasons: %v", reasons)
+		}
+	}
+}
+
+func TestLRUStatsOver(t *testing.T) {
+	l, _ := NewWithOptions(2, nil, simplelru.WithWindowedStats())
+	l.Add(1, 1)
+	l.Get(1)
+	l.Get(2)
+	if s := l.StatsOver(time.Minute); s.Hits != 1 || s.Misses != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	l.ResetStats()
+	if s := l.StatsOver(time.Hour); s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("bad stats after reset: %+v", s)
+	}
+}
+
+func TestLRUPeekOrAdd(t *testing.T) {
+	l, _ := New(1)
+	if prev, ok, evicted := l.PeekOrAdd(1, 1); ok || evicted || prev != nil {
+		t.Fatalf("bad add: %v %v %v", prev, ok, evicted)
+	}
+	if prev, ok, _ := l.PeekOrAdd(1, 2); !ok || prev != 1 {
+		t.Fatalf("bad peek: %v %v", prev, ok)
+	}
+	if _, ok, evicted := l.PeekOrAdd(2, 2); ok || !evicted {
+		t.Fatalf("bad eviction")
+	}
+}
+
+func TestLRULockFreeStats(t *testing.T) {
+	l, _ := New(4)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Get(3)
+
+	// Monitoring reads do not wait for a writer holding the lock
+	l.lock.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if n := l.Len(); n != 2 {
+			t.Errorf("bad len: %v", n)
+		}
+		if n := l.Cap(); n != 4 {
+			t.Errorf("bad cap: %v", n)
+		}
+		if hits, misses := l.Lookups(); hits != 1 || misses != 1 {
+			t.Errorf("bad lookups: %v %v", hits, misses)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stats reads blocked on the lock")
+	}
+	l.unlock()
+
+	l.Resize(8)
+	l.Purge()
+	if l.Len() != 0 || l.Cap() != 8 {
+		t.Fatalf("bad len: %v cap: %v", l.Len(), l.Cap())
+	}
+}
+
+func TestLRUVirtualTime(t *testing.T) {
+	l, _ := New(2)
+	now := time.Now().Add(time.Hour)
+	l.AddExAt(1, 1, time.Minute, now)
+	if _, ok := l.GetAt(1, now.Add(time.Second)); !ok {
+		t.Fatalf("should be live")
+	}
+	if _, ok := l.GetAt(1, now.Add(2*time.Minute)); ok {
+		t.Fatalf("should be expired")
+	}
+}
+
+func TestLRUExpiryPrecision(t *testing.T) {
+	const precision = 10 * time.Millisecond
+	// slack covers the scheduling of the janitor on a loaded machine
+	const slack = 40 * time.Millisecond
+
+	var mu sync.Mutex
+	reaped := map[interface{}]time.Time{}
+	l, _ := NewWithOptions(100, func(k, v interface{}) {
+		mu.Lock()
+		reaped[k] = time.Now()
+		mu.Unlock()
+	}, simplelru.WithExpiryPrecision(precision))
+	defer l.Close()
+
+	expires := map[interface{}]time.Time{}
+	for i := 0; i < 10; i++ {
+		ttl := time.Duration(i+1) * 5 * time.Millisecond
+		expires[i] = time.Now().Add(ttl)
+		l.AddEx(i, i, ttl)
+	}
+	time.Sleep(50*time.Millisecond + precision + slack)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for k, expire := range expires {
+		at, ok := reaped[k]
+		if !ok {
+			t.Fatalf("key %v not reaped", k)
+		}
+		if lag := at.Sub(expire); lag > precision+slack {
+			t.Fatalf("key %v reaped %v after expiring", k, lag)
+		}
+	}
+	if s := l.Stats(); s.Reaped != 10 || s.MaxExpiryLag > precision+slack {
+		t.Fatalf("bad lag stats: %+v", s)
+	}
+}
+
+func TestLRUJanitorManualClock(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Now())
+	evicted := make(chan interface{}, 1)
+	l, _ := NewWithOptions(10, func(k, v interface{}) {
+		evicted <- k
+	}, simplelru.WithClock(clock), simplelru.WithJanitor(time.Minute))
+	defer l.Close()
+
+	l.AddEx(1, 1, 30*time.Second)
+	clock.Advance(time.Minute)
+	select {
+	case k := <-evicted:
+		if k != 1 {
+			t.Fatalf("bad key: %v", k)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("janitor did not run")
+	}
+}
+
+func TestLRURunJanitorOnce(t *testing.T) {
+	clock := simplelru.NewManualClock(time.Unix(0, 0))
+	var evicted []interface{}
+	l, _ := NewWithOptions(10, func(k, v interface{}) {
+		evicted = append(evicted, k)
+	}, simplelru.WithClock(clock))
+	defer l.Close()
+
+	for i := 0; i < 5; i++ {
+		l.AddEx(i, i, time.Minute)
+	}
+	l.Add(5, 5)
+	if n := l.RunJanitorOnce(0); n != 0 {
+		t.Fatalf("nothing should be expired: %v", n)
+	}
+	clock.Advance(2 * time.Minute)
+
+	// A budgeted sweep resumes where the previous one stopped
+	if n := l.RunJanitorOnce(2); n != 2 || l.Len() != 4 {
+		t.Fatalf("bad sweep: %v %v", n, l.Len())
+	}
+	if n := l.RunJanitorOnce(2); n != 2 || len(evicted) != 4 || evicted[3] != 3 {
+		t.Fatalf("bad sweep: %v %v", n, evicted)
+	}
+	if n := l.RunJanitorOnce(0); n != 1 || l.Len() != 1 || !l.Contains(5) {
+		t.Fatalf("bad sweep: %v %v", n, l.Keys())
+	}
+}
+
+func TestLRURemoveIf(t *testing.T) {
+	l, _ := New(128)
+	l.Add("k", 0)
+	// Concurrent conditional invalidations of one version remove at
+	// most the value they saw, never a newer one
+	var wg sync.WaitGroup
+	var removed int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.RemoveIf("k", func(v interface{}) bool { return v == 0 }) {
+				atomic.AddInt32(&removed, 1)
+				l.Add("k", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := l.Get("k"); removed != 1 || v != 1 {
+		t.Fatalf("bad removals: %v value: %v", removed, v)
+	}
+}
+
+func TestLRUSetMany(t *testing.T) {
+	l, _ := New(2)
+	l.Add(1, 1)
+	victims := l.SetMany([]simplelru.KV{{Key: 2, Value: 2}, {Key: 3, Value: 3}})
+	if len(victims) != 1 || victims[0].Key != 1 || l.Len() != 2 {
+		t.Fatalf("bad victims: %v", victims)
+	}
+
+	s, _ := NewSharded(8, 4)
+	batch := make([]simplelru.KV, 20)
+	for i := range batch {
+		batch[i] = simplelru.KV{Key: i, Value: i}
+	}
+	if victims := s.SetMany(batch); len(victims)+s.Len() != 20 {
+		t.Fatalf("bad victims: %v len: %v", len(victims), s.Len())
+	}
+}
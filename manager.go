@@ -0,0 +1,375 @@
+package lru
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// ManagedCache is the interface a cache must implement to be registered
+// with a Manager.
+type ManagedCache interface {
+	Len() int
+	Cap() int
+	Resize(size int) int
+	Stats() simplelru.Stats
+}
+
+// costBudgeted is implemented by the caches bounded by the total cost
+// of their entries, such as a Cache built by NewWithMaxBytes, which can
+// share the memory budget of a Manager.
+type costBudgeted interface {
+	MaxCost() int64
+	SetMaxCost(maxCost int64) int
+}
+
+// lookupsOf returns the lookup counters of c, read without the scan of
+// Stats if c has a Lookups method, as Cache and GroupedCache do
+func lookupsOf(c ManagedCache) (hits, misses uint64) {
+	if l, ok := c.(interface{ Lookups() (hits, misses uint64) }); ok {
+		return l.Lookups()
+	}
+	stats := c.Stats()
+	return stats.Hits, stats.Misses
+}
+
+// CacheStats is a point-in-time view of a registered cache.
+type CacheStats struct {
+	Len int // Len is the number of items in the cache
+	Cap int // Cap is the current capacity of the cache
+
+	// Labels are the labels of the cache if it has a Labels method, such
+	// as a Cache configured with simplelru.WithLabels
+	Labels map[string]string
+
+	simplelru.Stats
+}
+
+// ManagerStats aggregates the stats of all registered caches.
+type ManagerStats struct {
+	Len    int
+	Cap    int
+	Caches map[string]CacheStats
+}
+
+// Manager is a registry of named caches sharing optional global budgets,
+// one in entries and one in memory. When the sum of the capacities, or
+// of the cost budgets, the caches were registered with exceeds the
+// budget, every cache is shrunk in proportion to what it was registered
+// with.
+type Manager struct {
+	caches  map[string]ManagedCache
+	nominal map[string]int
+	budget  int
+	hits    map[string]uint64
+	misses  map[string]uint64
+	lock    sync.RWMutex
+
+	// nominalCost holds the cost budgets of the caches bounded by cost,
+	// shrunk to fit memory
+	nominalCost map[string]int64
+	memory      int64
+}
+
+// DefaultManager is the process-wide cache registry.
+var DefaultManager = NewManager()
+
+// NewManager creates an empty Manager without a budget.
+func NewManager() *Manager {
+	return &Manager{
+		caches:      make(map[string]ManagedCache),
+		nominal:     make(map[string]int),
+		hits:        make(map[string]uint64),
+		misses:      make(map[string]uint64),
+		nominalCost: make(map[string]int64),
+	}
+}
+
+// Register adds a cache under the given name. The cache's current
+// capacity, and cost budget if it has one, are kept as its nominal ones
+// for budget arbitration.
+func (m *Manager) Register(name string, c ManagedCache) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.caches[name]; ok {
+		return fmt.Errorf("cache %q already registered", name)
+	}
+	m.caches[name] = c
+	m.nominal[name] = c.Cap()
+	if cb, ok := c.(costBudgeted); ok && cb.MaxCost() > 0 {
+		m.nominalCost[name] = cb.MaxCost()
+	}
+	m.hits[name], m.misses[name] = lookupsOf(c)
+	m.rebalance()
+	return nil
+}
+
+// Unregister removes the named cache from the registry, restoring its
+// nominal capacity and cost budget.
+func (m *Manager) Unregister(name string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	c, ok := m.caches[name]
+	if !ok {
+		return
+	}
+	c.Resize(m.nominal[name])
+	if cost, ok := m.nominalCost[name]; ok {
+		c.(costBudgeted).SetMaxCost(cost)
+	}
+	delete(m.caches, name)
+	delete(m.nominal, name)
+	delete(m.nominalCost, name)
+	delete(m.hits, name)
+	delete(m.misses, name)
+	m.rebalance()
+}
+
+// Lookup returns the cache registered under the given name.
+func (m *Manager) Lookup(name string) (ManagedCache, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	c, ok := m.caches[name]
+	return c, ok
+}
+
+// Names returns the names of the registered caches in sorted order.
+func (m *Manager) Names() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the stats of every registered cache and their totals.
+func (m *Manager) Stats() ManagerStats {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	stats := ManagerStats{Caches: make(map[string]CacheStats, len(m.caches))}
+	for name, c := range m.caches {
+		cs := CacheStats{Len: c.Len(), Cap: c.Cap(), Stats: c.Stats()}
+		if l, ok := c.(interface{ Labels() map[string]string }); ok {
+			cs.Labels = l.Labels()
+		}
+		stats.Caches[name] = cs
+		stats.Len += cs.Len
+		stats.Cap += cs.Cap
+	}
+	return stats
+}
+
+// SetBudget sets the total capacity, in entries, shared by the
+// registered caches. A budget <= 0 removes the limit.
+func (m *Manager) SetBudget(budget int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.budget = budget
+	m.rebalance()
+}
+
+// SetMemoryBudget sets the total cost, in bytes for caches built by
+// NewWithMaxBytes, shared by the registered caches bounded by cost. The
+// caches bounded only by their number of entries are not part of it. A
+// budget <= 0 removes the limit.
+func (m *Manager) SetMemoryBudget(bytes int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.memory = bytes
+	m.rebalance()
+}
+
+// rebalance resizes the caches to fit the budgets, the lock must be held
+func (m *Manager) rebalance() {
+	m.rebalanceCost()
+	total := 0
+	for _, n := range m.nominal {
+		total += n
+	}
+	for name, c := range m.caches {
+		size := m.nominal[name]
+		if m.budget > 0 && total > m.budget {
+			size = size * m.budget / total
+			if size < 1 {
+				size = 1
+			}
+		}
+		if size != c.Cap() {
+			c.Resize(size)
+		}
+	}
+}
+
+// rebalanceCost shrinks the cost budgets of the caches to fit the memory
+// budget, the lock must be held
+func (m *Manager) rebalanceCost() {
+	var total int64
+	for _, n := range m.nominalCost {
+		total += n
+	}
+	for name, n := range m.nominalCost {
+		cost := n
+		if m.memory > 0 && total > m.memory {
+			cost = int64(float64(n) * float64(m.memory) / float64(total))
+			if cost < 1 {
+				cost = 1
+			}
+		}
+		if cb := m.caches[name].(costBudgeted); cost != cb.MaxCost() {
+			cb.SetMaxCost(cost)
+		}
+	}
+}
+
+// Arbitrate redistributes the budgets among the registered caches by
+// the hit ratio each one had since the previous arbitration, per unit
+// of what it holds: per entry of capacity for the budget in entries, and
+// per unit of cost budget, bytes for caches built by NewWithMaxBytes,
+// for the memory budget. Scarce capacity and memory thus flow to the
+// caches that make the most of them. Caches stay at their nominal size
+// while the nominal sizes fit the budget; otherwise a cache never grows
+// past its nominal size nor shrinks below one unit, and what is left
+// once the caches with hits are served goes to the others in proportion
+// to their nominal size. Arbitrate does nothing without a budget.
+func (m *Manager) Arbitrate() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ratios := make(map[string]float64, len(m.caches))
+	for name, c := range m.caches {
+		hits, misses := lookupsOf(c)
+		recentHits, recentMisses := hits-m.hits[name], misses-m.misses[name]
+		m.hits[name], m.misses[name] = hits, misses
+		if lookups := recentHits + recentMisses; lookups > 0 {
+			ratios[name] = float64(recentHits) / float64(lookups)
+		}
+	}
+
+	if m.budget > 0 {
+		nominal := make(map[string]int64, len(m.caches))
+		weights := make(map[string]float64, len(m.caches))
+		for name, c := range m.caches {
+			nominal[name] = int64(m.nominal[name])
+			weights[name] = ratios[name] / float64(c.Cap())
+		}
+		for name, size := range arbitrate(int64(m.budget), nominal, weights) {
+			if c := m.caches[name]; int(size) != c.Cap() {
+				c.Resize(int(size))
+			}
+		}
+	}
+
+	if m.memory > 0 {
+		weights := make(map[string]float64, len(m.nominalCost))
+		for name := range m.nominalCost {
+			weights[name] = ratios[name] / float64(m.caches[name].(costBudgeted).MaxCost())
+		}
+		for name, cost := range arbitrate(m.memory, m.nominalCost, weights) {
+			if cb := m.caches[name].(costBudgeted); cost != cb.MaxCost() {
+				cb.SetMaxCost(cost)
+			}
+		}
+	}
+}
+
+// arbitrate splits budget among caches of the given nominal sizes by
+// weight, capping them at their nominal size and handing what is left
+// to the others, then to the caches without weight by nominal size.
+// Every cache gets at least one unit.
+func arbitrate(budget int64, nominal map[string]int64, weights map[string]float64) map[string]int64 {
+	var total int64
+	for _, n := range nominal {
+		total += n
+	}
+	if total <= budget {
+		return nominal
+	}
+
+	names := make([]string, 0, len(nominal))
+	for name := range nominal {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sizes := make(map[string]int64, len(nominal))
+	for _, name := range names {
+		sizes[name] = 1
+	}
+	left := budget - int64(len(names))
+
+	fill := func(weight func(name string) float64) {
+		for left > 0 {
+			var total float64
+			for _, name := range names {
+				if sizes[name] < nominal[name] {
+					total += weight(name)
+				}
+			}
+			if total == 0 {
+				return
+			}
+			var given int64
+			for _, name := range names {
+				w := weight(name)
+				room := nominal[name] - sizes[name]
+				if room <= 0 || w == 0 {
+					continue
+				}
+				share := int64(float64(left) * w / total)
+				if share > room {
+					share = room
+				}
+				sizes[name] += share
+				given += share
+			}
+			if given == 0 {
+				// The shares rounded down to nothing, hand out the rest
+				// one unit at a time by decreasing weight
+				sort.SliceStable(names, func(i, j int) bool { return weight(names[i]) > weight(names[j]) })
+				for _, name := range names {
+					if left > 0 && weight(name) > 0 && sizes[name] < nominal[name] {
+						sizes[name]++
+						given++
+						left--
+					}
+				}
+				sort.Strings(names)
+				if given == 0 {
+					return
+				}
+				continue
+			}
+			left -= given
+		}
+	}
+	fill(func(name string) float64 { return weights[name] })
+	fill(func(name string) float64 { return float64(nominal[name]) })
+	return sizes
+}
+
+// StartArbiter runs Arbitrate every interval until the returned stop
+// function is called.
+func (m *Manager) StartArbiter(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				m.Arbitrate()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
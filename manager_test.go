@@ -0,0 +1,191 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	a, _ := New(100)
+	b, _ := New(300)
+
+	if err := m.Register("a", a); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := m.Register("a", b); err == nil {
+		t.Fatalf("should not register twice")
+	}
+	m.Register("b", b)
+
+	if c, ok := m.Lookup("b"); !ok || c != b {
+		t.Fatalf("bad lookup")
+	}
+	if names := m.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("bad names: %v", names)
+	}
+
+	for i := 0; i < 100; i++ {
+		a.Add(i, i)
+		b.Add(i, i)
+	}
+	stats := m.Stats()
+	if stats.Len != 200 || stats.Cap != 400 || stats.Caches["b"].Cap != 300 {
+		t.Fatalf("bad stats: %+v", stats)
+	}
+
+	c, _ := NewWithOptions(10, nil, simplelru.WithLabels(map[string]string{"tier": "edge"}))
+	m.Register("c", c)
+	if labels := m.Stats().Caches["c"].Labels; labels["tier"] != "edge" {
+		t.Fatalf("bad labels: %v", labels)
+	}
+	m.Unregister("c")
+
+	m.Unregister("b")
+	if _, ok := m.Lookup("b"); ok {
+		t.Fatalf("should be unregistered")
+	}
+}
+
+func TestManager_Budget(t *testing.T) {
+	m := NewManager()
+	a, _ := New(100)
+	b, _ := New(300)
+	m.Register("a", a)
+	m.Register("b", b)
+
+	m.SetBudget(200)
+	if a.Cap() != 50 || b.Cap() != 150 {
+		t.Fatalf("bad caps: %d, %d", a.Cap(), b.Cap())
+	}
+
+	m.Unregister("a")
+	if a.Cap() != 100 || b.Cap() != 200 {
+		t.Fatalf("bad caps: %d, %d", a.Cap(), b.Cap())
+	}
+
+	m.SetBudget(0)
+	if b.Cap() != 300 {
+		t.Fatalf("bad cap: %d", b.Cap())
+	}
+}
+
+func TestManager_MemoryBudget(t *testing.T) {
+	m := NewManager()
+	a, _ := NewWithMaxBytes(1000, nil, nil)
+	b, _ := NewWithMaxBytes(3000, nil, nil)
+	n, _ := New(10)
+	m.Register("a", a)
+	m.Register("b", b)
+	m.Register("n", n)
+	for i := 0; i < 100; i++ {
+		a.AddWithCost(i, i, 10)
+	}
+
+	m.SetMemoryBudget(2000)
+	if a.MaxCost() != 500 || b.MaxCost() != 1500 || n.Cap() != 10 {
+		t.Fatalf("bad budgets: %d, %d, %d", a.MaxCost(), b.MaxCost(), n.Cap())
+	}
+	if a.Cost() > 500 {
+		t.Fatalf("shrunk cache should fit its budget: %d", a.Cost())
+	}
+
+	m.Unregister("a")
+	if a.MaxCost() != 1000 || b.MaxCost() != 2000 {
+		t.Fatalf("bad budgets: %d, %d", a.MaxCost(), b.MaxCost())
+	}
+
+	m.SetMemoryBudget(0)
+	if b.MaxCost() != 3000 {
+		t.Fatalf("bad budget: %d", b.MaxCost())
+	}
+}
+
+func TestManager_Arbitrate(t *testing.T) {
+	m := NewManager()
+	hot, _ := New(100)
+	cold, _ := New(100)
+	m.Register("hot", hot)
+	m.Register("cold", cold)
+	m.SetBudget(100)
+
+	for i := 0; i < 50; i++ {
+		hot.Add(i, i)
+		cold.Add(i, i)
+	}
+	for i := 0; i < 30; i++ {
+		hot.Get(i % 10)
+	}
+	for i := 0; i < 30; i++ {
+		cold.Get(i + 1000)
+	}
+	cold.Get(0)
+
+	m.Arbitrate()
+	if hot.Cap()+cold.Cap() > 100 {
+		t.Fatalf("over budget: %d, %d", hot.Cap(), cold.Cap())
+	}
+	if hot.Cap() <= cold.Cap() {
+		t.Fatalf("hot cache should get more capacity: %d, %d", hot.Cap(), cold.Cap())
+	}
+
+	// Without new hits the budget is split by nominal capacity again
+	m.Arbitrate()
+	if hot.Cap() != 50 || cold.Cap() != 50 {
+		t.Fatalf("bad caps: %d, %d", hot.Cap(), cold.Cap())
+	}
+}
+
+func TestManager_ArbitrateLeftover(t *testing.T) {
+	m := NewManager()
+	hot, _ := New(10)
+	idle, _ := New(100)
+	m.Register("hot", hot)
+	m.Register("idle", idle)
+
+	// Without pressure the caches keep their nominal capacity
+	m.SetBudget(10000)
+	hot.Get(0)
+	m.Arbitrate()
+	if hot.Cap() != 10 || idle.Cap() != 100 {
+		t.Fatalf("bad caps: %d, %d", hot.Cap(), idle.Cap())
+	}
+
+	// The budget the hot cache cannot use goes to the idle one
+	m.SetBudget(60)
+	hot.Add(0, 0)
+	hot.Get(0)
+	m.Arbitrate()
+	if hot.Cap() != 10 || idle.Cap() != 50 {
+		t.Fatalf("bad caps: %d, %d", hot.Cap(), idle.Cap())
+	}
+}
+
+func TestManager_ArbitrateMemory(t *testing.T) {
+	m := NewManager()
+	a, _ := NewWithMaxBytes(1000, nil, nil)
+	b, _ := NewWithMaxBytes(3000, nil, nil)
+	m.Register("a", a)
+	m.Register("b", b)
+	m.SetMemoryBudget(2000)
+
+	a.AddWithCost(0, 0, 10)
+	a.Get(0)
+	b.Get(0)
+	m.Arbitrate()
+	if a.MaxCost() != 1000 || b.MaxCost() != 1000 {
+		t.Fatalf("bad budgets: %d, %d", a.MaxCost(), b.MaxCost())
+	}
+}
+
+func TestManager_StartArbiter(t *testing.T) {
+	m := NewManager()
+	a, _ := New(10)
+	m.Register("a", a)
+	stop := m.StartArbiter(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	stop()
+	stop()
+}
@@ -0,0 +1,82 @@
+// Package metrics provides simplelru.MetricsRecorder adapters exporting
+// the activity of a cache to expvar and Prometheus.
+package metrics
+
+import (
+	"expvar"
+)
+
+// Expvar is a recorder publishing the counters of a cache as an expvar
+// map with the keys hits, misses, adds, evictions and expirations.
+type Expvar struct {
+	m *expvar.Map
+}
+
+// NewExpvar publishes a new expvar map under name and returns a recorder
+// updating it. Like expvar.NewMap, it panics if name is already in use.
+func NewExpvar(name string) *Expvar {
+	return &Expvar{m: expvar.NewMap(name)}
+}
+
+// Map returns the published map.
+func (e *Expvar) Map() *expvar.Map { return e.m }
+
+// SetCache implements simplelru.LabeledRecorder, publishing the name and
+// labels of the cache under the keys name and labels.
+func (e *Expvar) SetCache(name string, labels map[string]string) {
+	if name != "" {
+		v := new(expvar.String)
+		v.Set(name)
+		e.m.Set("name", v)
+	}
+	if len(labels) > 0 {
+		m := new(expvar.Map)
+		for k, l := range labels {
+			v := new(expvar.String)
+			v.Set(l)
+			m.Set(k, v)
+		}
+		e.m.Set("labels", m)
+	}
+}
+
+// The On methods implement simplelru.MetricsRecorder.
+
+func (e *Expvar) OnHit(interface{})    { e.m.Add("hits", 1) }
+func (e *Expvar) OnMiss(interface{})   { e.m.Add("misses", 1) }
+func (e *Expvar) OnAdd(interface{})    { e.m.Add("adds", 1) }
+func (e *Expvar) OnEvict(interface{})  { e.m.Add("evictions", 1) }
+func (e *Expvar) OnExpire(interface{}) { e.m.Add("expirations", 1) }
+
+// Counter is the subset of prometheus.Counter used by Prometheus, so
+// that this package does not depend on the Prometheus client.
+type Counter interface {
+	Inc()
+}
+
+// Prometheus is a recorder incrementing a Prometheus counter per kind of
+// activity. Any of the counters may be nil to skip it. The counters are
+// typically children of a CounterVec, e.g. vec.WithLabelValues("hit"),
+// labeled with the name and labels of the cache, see
+// simplelru.WithName.
+type Prometheus struct {
+	Hits        Counter
+	Misses      Counter
+	Adds        Counter
+	Evictions   Counter
+	Expirations Counter
+}
+
+// The On methods implement simplelru.MetricsRecorder.
+
+func (p *Prometheus) OnHit(interface{})    { inc(p.Hits) }
+func (p *Prometheus) OnMiss(interface{})   { inc(p.Misses) }
+func (p *Prometheus) OnAdd(interface{})    { inc(p.Adds) }
+func (p *Prometheus) OnEvict(interface{})  { inc(p.Evictions) }
+func (p *Prometheus) OnExpire(interface{}) { inc(p.Expirations) }
+
+func inc(c Counter) {
+	if c != nil {
+		c.Inc()
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+
+	lru "github.com/hnlq715/golang-lru"
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+var (
+	_ simplelru.LabeledRecorder = (*Expvar)(nil)
+	_ simplelru.MetricsRecorder = (*Prometheus)(nil)
+)
+
+type counter int
+
+func (c *counter) Inc() { *c++ }
+
+func TestExpvar(t *testing.T) {
+	e := NewExpvar("lru_test")
+	l, _ := lru.NewWithOptions(1, nil, simplelru.WithMetrics(e))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(2)
+	l.Get(1)
+
+	for key, want := range map[string]string{"adds": "2", "evictions": "1", "hits": "1", "misses": "1"} {
+		if v := e.Map().Get(key); v == nil || v.String() != want {
+			t.Fatalf("bad %s: %v", key, v)
+		}
+	}
+}
+
+func TestExpvarLabels(t *testing.T) {
+	e := NewExpvar("lru_test_labels")
+	lru.NewWithOptions(1, nil, simplelru.WithMetrics(e), simplelru.WithName("sessions"), simplelru.WithLabels(map[string]string{"tier": "edge"}))
+	if v := e.Map().Get("name"); v == nil || v.String() != `"sessions"` {
+		t.Fatalf("bad name: %v", v)
+	}
+	if v := e.Map().Get("labels"); v == nil || v.String() != `{"tier": "edge"}` {
+		t.Fatalf("bad labels: %v", v)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	var hits, misses counter
+	p := &Prometheus{Hits: &hits, Misses: &misses}
+	l, _ := lru.NewWithOptions(1, nil, simplelru.WithMetrics(p))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(2)
+	l.Get(1)
+	if hits != 1 || misses != 1 {
+		t.Fatalf("bad counters: %v %v", hits, misses)
+	}
+}
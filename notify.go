@@ -0,0 +1,83 @@
+package lru
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// EvictionEvent describes an entry leaving the cache.
+type EvictionEvent struct {
+	Key    interface{}
+	Value  interface{}
+	Reason simplelru.EvictReason
+
+	// Cache and Labels are the name and labels of the cache, see
+	// simplelru.WithName and simplelru.WithLabels. Labels is shared by
+	// the events and must not be modified.
+	Cache  string
+	Labels map[string]string
+}
+
+// subscriber is a channel returned by Notify
+type subscriber struct {
+	lock   sync.Mutex
+	ch     chan EvictionEvent
+	closed bool
+}
+
+// Notify subscribes to the entries leaving the cache, including replaced
+// values. The events are queued on a channel of the given capacity after
+// the lock is released, and dropped when the channel is full, so slow
+// consumers such as loggers never block the cache; DroppedEvents counts
+// the losses. cancel ends the subscription and closes the channel.
+func (c *Cache) Notify(capacity int) (events <-chan EvictionEvent, cancel func()) {
+	s := &subscriber{ch: make(chan EvictionEvent, capacity)}
+	c.lock.Lock()
+	c.subscribers = append(c.subscribers[:len(c.subscribers):len(c.subscribers)], s)
+	c.bindCallbacks()
+	c.unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			c.lock.Lock()
+			// Copy on write, as unlock may be sending to the old slice
+			subscribers := make([]*subscriber, 0, len(c.subscribers))
+			for _, o := range c.subscribers {
+				if o != s {
+					subscribers = append(subscribers, o)
+				}
+			}
+			c.subscribers = subscribers
+			c.bindCallbacks()
+			c.unlock()
+
+			s.lock.Lock()
+			s.closed = true
+			close(s.ch)
+			s.lock.Unlock()
+		})
+	}
+}
+
+// DroppedEvents returns the number of events dropped for subscribers of
+// Notify whose channel was full.
+func (c *Cache) DroppedEvents() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// publishEvent queues an event for a subscriber without blocking
+func (c *Cache) publishEvent(s *subscriber, kv keyValue) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- EvictionEvent{Key: kv.key, Value: kv.value, Reason: kv.reason, Cache: c.name, Labels: c.labels}:
+	default:
+		atomic.AddUint64(&c.dropped, 1)
+	}
+}
@@ -0,0 +1,71 @@
+package lru
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestCacheNotify(t *testing.T) {
+	l, _ := New(2)
+	events, cancel := l.Notify(8)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Add(3, 30)
+	l.Remove(2)
+
+	for _, want := range []EvictionEvent{
+		{Key: 1, Value: 1, Reason: simplelru.EvictCapacity},
+		{Key: 3, Value: 3, Reason: simplelru.EvictReplaced},
+		{Key: 2, Value: 2, Reason: simplelru.EvictRemoved},
+	} {
+		if e := <-events; !reflect.DeepEqual(e, want) {
+			t.Fatalf("bad event: %+v, want %+v", e, want)
+		}
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatalf("channel should be closed")
+	}
+	l.Purge()
+}
+
+func TestCacheNotifyDrops(t *testing.T) {
+	evicted := 0
+	l, _ := NewWithEvict(1, func(k, v interface{}) { evicted++ })
+	slow, cancelSlow := l.Notify(1)
+	defer cancelSlow()
+	fast, cancelFast := l.Notify(16)
+	defer cancelFast()
+
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	if len(slow) != 1 || len(fast) != 4 || evicted != 4 {
+		t.Fatalf("bad events: %v %v %v", len(slow), len(fast), evicted)
+	}
+	if n := l.DroppedEvents(); n != 3 {
+		t.Fatalf("bad dropped: %v", n)
+	}
+}
+
+func TestCacheNotifyLabels(t *testing.T) {
+	labels := map[string]string{"tier": "edge"}
+	l, _ := NewWithOptions(1, nil, simplelru.WithName("sessions"), simplelru.WithLabels(labels))
+	labels["tier"] = "origin"
+	if l.Name() != "sessions" || l.Labels()["tier"] != "edge" {
+		t.Fatalf("bad identity: %v %v", l.Name(), l.Labels())
+	}
+	events, cancel := l.Notify(1)
+	defer cancel()
+	l.Add(1, 1)
+	l.Add(2, 2)
+	want := EvictionEvent{Key: 1, Value: 1, Reason: simplelru.EvictCapacity, Cache: "sessions", Labels: map[string]string{"tier": "edge"}}
+	if e := <-events; !reflect.DeepEqual(e, want) {
+		t.Fatalf("bad event: %+v", e)
+	}
+}
@@ -0,0 +1,50 @@
+package lru
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// Dump returns the live entries of the cache from oldest to newest, see
+// simplelru.LRU.Dump.
+func (c *Cache) Dump() []simplelru.Record {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Dump()
+}
+
+// Load adds the records in order, preserving their recency, expire times
+// and priorities. Returns true if an eviction occurred.
+func (c *Cache) Load(records []simplelru.Record) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	if c.dropAdd("Load") {
+		return false
+	}
+	return c.lru.Load(records)
+}
+
+// SaveTo serializes the live entries of the cache to w with gob. The
+// entries are copied under the lock and encoded after it is released.
+// The concrete types of the keys and values must be registered with
+// gob.Register.
+func (c *Cache) SaveTo(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(c.Dump())
+}
+
+// NewFromReader constructs a cache like NewWithOptions and restores the
+// entries serialized to r by SaveTo.
+func NewFromReader(r io.Reader, size int, onEvicted func(key interface{}, value interface{}), opts ...simplelru.Option) (*Cache, error) {
+	var records []simplelru.Record
+	if err := gob.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	c, err := NewWithOptions(size, onEvicted, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.Load(records)
+	return c, nil
+}
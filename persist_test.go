@@ -0,0 +1,32 @@
+package lru
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCacheSaveTo(t *testing.T) {
+	l, _ := New(4)
+	for i := 0; i < 4; i++ {
+		l.Add(i, i*10)
+	}
+	l.Get(0)
+
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, err := NewFromReader(&buf, 2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The most recent entries survive a smaller cache
+	keys := r.Keys()
+	if len(keys) != 2 || keys[0] != 3 || keys[1] != 0 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if v, _ := r.Get(3); v != 30 {
+		t.Fatalf("bad value: %v", v)
+	}
+}
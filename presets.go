@@ -0,0 +1,61 @@
+package lru
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// NewForSessions creates a cache suited to session storage: sessions
+// expire after idle without being accessed, and expired sessions are
+// reaped in the background so onEvicted can release their resources
+// promptly. It is sharded as sessions are looked up on every request.
+// Close stops the reapers.
+func NewForSessions(size int, idle time.Duration, onEvicted func(key interface{}, value interface{})) (*ShardedCache, error) {
+	return NewShardedWithOptions(size, shardCount(size), onEvicted,
+		simplelru.WithSlidingExpire(idle),
+		simplelru.WithJanitor(janitorInterval(idle)))
+}
+
+// NewForMemoization creates a cache suited to memoizing the results of
+// expensive calls with GetOrCompute: results never expire and the least
+// recently used one is evicted. It is not sharded so that concurrent
+// misses of a key share a single computation.
+func NewForMemoization(size int) (*Cache, error) {
+	return NewWithOptions(size, nil)
+}
+
+// NewForHTTP creates a cache suited to HTTP responses: entries expire
+// after ttl at the latest, even when added with a longer expire,
+// expired responses are reaped in the background, and the cache is
+// sharded for concurrent request handling. Close stops the reapers.
+func NewForHTTP(size int, ttl time.Duration) (*ShardedCache, error) {
+	return NewShardedWithOptions(size, shardCount(size), nil,
+		simplelru.WithExpire(ttl),
+		simplelru.WithMaxTTL(ttl),
+		simplelru.WithJanitor(janitorInterval(ttl)))
+}
+
+// shardCount returns a shard count matching the available parallelism,
+// keeping at least 64 entries per shard.
+func shardCount(size int) int {
+	n := runtime.GOMAXPROCS(0)
+	if max := size / 64; n > max {
+		n = max
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// janitorInterval returns how often to reap entries expiring after ttl,
+// bounding the time an expired entry lingers to a fraction of its ttl.
+func janitorInterval(ttl time.Duration) time.Duration {
+	interval := ttl / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
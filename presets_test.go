@@ -0,0 +1,71 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPresets(t *testing.T) {
+	evicted := make(chan interface{}, 1)
+	s, err := NewForSessions(128, time.Millisecond, func(k, v interface{}) {
+		evicted <- k
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer s.Close()
+	if s.Cap() != 128 {
+		t.Fatalf("bad cap: %v", s.Cap())
+	}
+	s.Add("session", 1)
+	time.Sleep(2 * time.Millisecond)
+	if s.Contains("session") {
+		t.Fatalf("session should have expired")
+	}
+	if s.DeleteExpired() != 1 || <-evicted != "session" {
+		t.Fatalf("expired session should be reaped")
+	}
+
+	m, err := NewForMemoization(16)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, _ := m.GetOrCompute(1, func() (interface{}, error) { return 2, nil }); v != 2 {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	h, err := NewForHTTP(1024, time.Minute)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer h.Close()
+	h.AddEx("/", "body", time.Hour)
+	if _, expire, ok := h.PeekWithExpireTime("/"); !ok || expire.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("expire should be capped to the ttl")
+	}
+}
+
+func TestPresetsSessionsSliding(t *testing.T) {
+	s, _ := NewForSessions(16, 40*time.Millisecond, nil)
+	defer s.Close()
+	s.Add("session", 1)
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if _, ok := s.Get("session"); !ok {
+			t.Fatalf("active session should not expire after %d reads", i)
+		}
+	}
+	time.Sleep(60 * time.Millisecond)
+	if s.Contains("session") {
+		t.Fatalf("idle session should expire")
+	}
+}
+
+func TestShardCount(t *testing.T) {
+	if n := shardCount(1); n != 1 {
+		t.Fatalf("bad shard count: %v", n)
+	}
+	if n := shardCount(1 << 20); n < 1 || 1<<20/n < 64 {
+		t.Fatalf("bad shard count: %v", n)
+	}
+}
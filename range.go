@@ -0,0 +1,73 @@
+package lru
+
+// RangeMode selects the consistency of an iteration over the cache.
+type RangeMode int
+
+const (
+	// RangeLocked holds the read lock for the whole iteration: the entries
+	// seen are consistent, but writers are blocked until it returns and the
+	// callback must not modify the cache.
+	RangeLocked RangeMode = iota
+	// RangeSnapshot copies the entries under the read lock and iterates the
+	// copy: the entries seen are consistent and writers are only blocked
+	// while copying.
+	RangeSnapshot
+	// RangeBestEffort only takes the lock briefly to read each entry:
+	// entries added or removed during the iteration may or may not be seen.
+	RangeBestEffort
+)
+
+// Range calls f for each live key and value in the cache, from oldest to
+// newest, without updating their recent-ness. If f returns false, Range
+// stops the iteration. mode selects the consistency/latency tradeoff.
+func (c *Cache) Range(mode RangeMode, f func(key, value interface{}) bool) {
+	switch mode {
+	case RangeLocked:
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+		c.lru.Range(f)
+	case RangeSnapshot:
+		var entries []keyValue
+		c.lock.RLock()
+		c.lru.Range(func(key, value interface{}) bool {
+			entries = append(entries, keyValue{key: key, value: value})
+			return true
+		})
+		c.lock.RUnlock()
+		for _, kv := range entries {
+			if !f(kv.key, kv.value) {
+				return
+			}
+		}
+	default:
+		for _, key := range c.Keys() {
+			value, ok := c.Peek(key)
+			if !ok {
+				continue
+			}
+			if !f(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// RangePromote calls f for each live key and value in the cache, from
+// oldest to newest, marking them as recently used, see
+// simplelru.LRU.RangePromote. It holds the write lock for the whole
+// iteration, so f must not modify the cache.
+func (c *Cache) RangePromote(f func(key, value interface{}) bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	c.lru.RangePromote(f)
+}
+
+// FindKeys returns the keys of the live entries whose value satisfies
+// pred, from oldest to newest, stopping after limit keys if limit > 0.
+// The values are scanned under the read lock, so pred must not modify
+// the cache.
+func (c *Cache) FindKeys(pred func(value interface{}) bool, limit int) []interface{} {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.FindKeys(pred, limit)
+}
@@ -0,0 +1,87 @@
+package lru
+
+import "testing"
+
+func TestCacheRange(t *testing.T) {
+	for _, mode := range []RangeMode{RangeLocked, RangeSnapshot, RangeBestEffort} {
+		l, _ := New(8)
+		for i := 0; i < 4; i++ {
+			l.Add(i, i*10)
+		}
+		l.Get(0)
+
+		var keys []interface{}
+		l.Range(mode, func(k, v interface{}) bool {
+			if v != k.(int)*10 {
+				t.Errorf("mode %d: bad value %v for %v", mode, v, k)
+			}
+			keys = append(keys, k)
+			return len(keys) < 3
+		})
+		if len(keys) != 3 || keys[0] != 1 || keys[2] != 3 {
+			t.Fatalf("mode %d: bad keys: %v", mode, keys)
+		}
+		if k, _, _ := l.GetOldest(); k != 1 {
+			t.Fatalf("mode %d: range updated recent-ness", mode)
+		}
+	}
+
+	// Writers are not blocked outside of RangeLocked
+	for _, mode := range []RangeMode{RangeSnapshot, RangeBestEffort} {
+		l, _ := New(8)
+		l.Add(1, 1)
+		l.Add(2, 2)
+		n := 0
+		l.Range(mode, func(k, v interface{}) bool {
+			l.Remove(2)
+			n++
+			return true
+		})
+		if mode == RangeSnapshot && n != 2 {
+			t.Fatalf("snapshot should see removed entry: %d", n)
+		}
+		if mode == RangeBestEffort && n != 1 {
+			t.Fatalf("best-effort should skip removed entry: %d", n)
+		}
+	}
+}
+
+func TestCacheFindKeys(t *testing.T) {
+	l, _ := New(8)
+	conn := &struct{ id int }{1}
+	l.Add("a", conn)
+	l.Add("b", &struct{ id int }{2})
+	l.Add("c", conn)
+	keys := l.FindKeys(func(v interface{}) bool { return v == conn }, 0)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	for _, k := range keys {
+		l.Remove(k)
+	}
+	if l.Len() != 1 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestCacheRangeDoesNotPromote(t *testing.T) {
+	for _, mode := range []RangeMode{RangeLocked, RangeSnapshot, RangeBestEffort} {
+		l, _ := New(4)
+		for i := 0; i < 4; i++ {
+			l.Add(i, i)
+		}
+		l.Range(mode, func(key, value interface{}) bool { return true })
+		if k, _, _ := l.GetOldest(); k != 0 {
+			t.Fatalf("mode %v should not promote: %v", mode, l.Keys())
+		}
+	}
+
+	l, _ := New(4)
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+	l.RangePromote(func(key, value interface{}) bool { return key != 1 })
+	if keys := l.Keys(); keys[0] != 2 || keys[3] != 1 {
+		t.Fatalf("visited keys should be promoted: %v", keys)
+	}
+}
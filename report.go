@@ -0,0 +1,52 @@
+package lru
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// Health returns a summary of the effectiveness of the cache, see
+// simplelru.LRU.Health. It takes a scan under the read lock.
+func (c *Cache) Health() simplelru.Health {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lru.Health()
+}
+
+// Report writes a human-readable summary of the effectiveness of the
+// cache to w: its hit ratio, churn, the capacity wasted on expired
+// entries, its hot keys and the size recommended by simplelru.WithShadow,
+// if enabled.
+func (c *Cache) Report(w io.Writer) error {
+	h := c.Health()
+	name := h.Name
+	if name == "" {
+		name = "cache"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d/%d entries\n", name, h.Len, h.Cap)
+	fmt.Fprintf(&b, "  hit ratio    %.2f%% (%d hits, %d misses)\n", 100*h.HitRatio, h.Hits, h.Misses)
+	fmt.Fprintf(&b, "  churn        %d evicted, %d churning keys\n", h.Evicted, h.Churning)
+	fmt.Fprintf(&b, "  expired      %d resident (%.2f%% of capacity wasted)\n", h.Expired, 100*h.ExpiredWaste)
+	if len(h.HotKeys) > 0 {
+		keys := make([]string, len(h.HotKeys))
+		for i, k := range h.HotKeys {
+			keys[i] = fmt.Sprintf("%v (%d)", k.Key, k.Hits)
+		}
+		fmt.Fprintf(&b, "  hot keys     %s\n", strings.Join(keys, ", "))
+	}
+	if h.RecommendedSize > 0 {
+		fmt.Fprintf(&b, "  recommended  %d entries\n", h.RecommendedSize)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ReportJSON writes the summary of Report to w as JSON.
+func (c *Cache) ReportJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(c.Health())
+}
@@ -0,0 +1,48 @@
+package lru
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestCache_Report(t *testing.T) {
+	c, err := NewWithOptions(2, nil, simplelru.WithName("users"), simplelru.WithShadow(4))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for round := 0; round < 5; round++ {
+		for _, key := range []string{"a", "b", "c"} {
+			if _, ok := c.Get(key); !ok {
+				c.Add(key, 1)
+			}
+		}
+	}
+	c.Add("hot", 1)
+	c.Get("hot")
+
+	var b bytes.Buffer
+	if err := c.Report(&b); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, want := range []string{"users: 2/2 entries", "hit ratio    6.25% (1 hits, 15 misses)", "hot keys     hot (1)", "recommended  3 entries"} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("report should contain %q:\n%s", want, &b)
+		}
+	}
+
+	b.Reset()
+	if err := c.ReportJSON(&b); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var h map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &h); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if h["name"] != "users" || h["misses"] != 15.0 || h["recommended_size"] != 3.0 {
+		t.Fatalf("bad json report: %s", &b)
+	}
+}
@@ -0,0 +1,303 @@
+package lru
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// ShardedCache is a thread-safe fixed size LRU cache partitioning the keys
+// across several shards, each with its own lock, to reduce contention on
+// servers with many cores. Recent-ness is tracked per shard, so the entry
+// evicted is the least recently used of its shard rather than of the
+// whole cache. Keys are routed to shards by simplelru.HashKey, by value
+// for comparable values and by address for pointers, so that a key is
+// found under any key equal to it by ==.
+type ShardedCache struct {
+	shards []*Cache
+
+	// lock serializes Rebalance, and evicted holds the eviction counters
+	// of the shards at the previous one
+	lock    sync.Mutex
+	evicted []uint64
+}
+
+// NewSharded creates a ShardedCache of the given total size split across
+// the given number of shards.
+func NewSharded(size, shards int) (*ShardedCache, error) {
+	return NewShardedWithOptions(size, shards, nil)
+}
+
+// NewShardedWithOptions creates a ShardedCache of the given total size
+// split across the given number of shards, each configured by the given
+// callback and options. A cost budget set by simplelru.WithMaxCost or
+// WithMaxBytes is split across the shards too. With
+// simplelru.WithJanitor, the sweeps of the shards are spread evenly over
+// the interval.
+func NewShardedWithOptions(size, shards int, onEvicted func(key interface{}, value interface{}), opts ...simplelru.Option) (*ShardedCache, error) {
+	if shards <= 0 {
+		return nil, errors.New("Must provide a positive shard count")
+	}
+	if size < shards {
+		return nil, errors.New("Must provide a size of at least the shard count")
+	}
+	c := &ShardedCache{shards: make([]*Cache, shards), evicted: make([]uint64, shards)}
+	for i := range c.shards {
+		shardSize := size / shards
+		if i < size%shards {
+			shardSize++
+		}
+		// Stagger the janitors so the shards are not swept all at once
+		shardOpts := append(opts[:len(opts):len(opts)], simplelru.WithJanitorPhase(i, shards))
+		shard, err := NewWithOptions(shardSize, onEvicted, shardOpts...)
+		if err != nil {
+			return nil, err
+		}
+		c.shards[i] = shard
+	}
+	// A cost budget, such as one of simplelru.WithMaxBytes, is split
+	// across the shards like the size
+	if maxCost := c.shards[0].lru.MaxCost(); maxCost > 0 {
+		for i, shard := range c.shards {
+			shardCost := maxCost / int64(shards)
+			if int64(i) < maxCost%int64(shards) {
+				shardCost++
+			}
+			shard.lru.SetMaxCost(shardCost)
+		}
+	}
+	return c, nil
+}
+
+// shard returns the shard holding a key.
+func (c *ShardedCache) shard(key interface{}) *Cache {
+	return c.shards[simplelru.HashKey(key)%uint64(len(c.shards))]
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *ShardedCache) Add(key, value interface{}) bool {
+	return c.shard(key).Add(key, value)
+}
+
+// AddEx adds a value to the cache with expire.  Returns true if an
+// eviction occurred.
+func (c *ShardedCache) AddEx(key, value interface{}, expire time.Duration) bool {
+	return c.shard(key).AddEx(key, value, expire)
+}
+
+// Get looks up a key's value from the cache.
+func (c *ShardedCache) Get(key interface{}) (interface{}, bool) {
+	return c.shard(key).Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (c *ShardedCache) Contains(key interface{}) bool {
+	return c.shard(key).Contains(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *ShardedCache) Peek(key interface{}) (interface{}, bool) {
+	return c.shard(key).Peek(key)
+}
+
+// PeekWithExpireTime returns the key value (or undefined if not found)
+// and its associated expire time without updating the "recently
+// used"-ness of the key.
+func (c *ShardedCache) PeekWithExpireTime(key interface{}) (value interface{}, expire *time.Time, ok bool) {
+	return c.shard(key).PeekWithExpireTime(key)
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (c *ShardedCache) ContainsOrAdd(key, value interface{}) (ok, evict bool) {
+	return c.shard(key).ContainsOrAdd(key, value)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *ShardedCache) Remove(key interface{}) bool {
+	return c.shard(key).Remove(key)
+}
+
+// SetMany adds several entries, evicting once per shard after its part of
+// the batch, and returns the evicted entries shard by shard, see
+// Cache.SetMany.
+func (c *ShardedCache) SetMany(entries []simplelru.KV) (victims []simplelru.KV) {
+	batches := make(map[*Cache][]simplelru.KV)
+	for _, e := range entries {
+		shard := c.shard(e.Key)
+		batches[shard] = append(batches[shard], e)
+	}
+	for _, shard := range c.shards {
+		if batch, ok := batches[shard]; ok {
+			victims = append(victims, shard.SetMany(batch)...)
+		}
+	}
+	return victims
+}
+
+// RemoveIf removes the provided key from the cache if pred holds for its
+// current value, see Cache.RemoveIf.
+func (c *ShardedCache) RemoveIf(key interface{}, pred func(value interface{}) bool) bool {
+	return c.shard(key).RemoveIf(key, pred)
+}
+
+// Keys returns a slice of the keys in the cache, shard by shard, each
+// from oldest to newest.
+func (c *ShardedCache) Keys() []interface{} {
+	var keys []interface{}
+	for _, shard := range c.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *ShardedCache) Len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Cap returns the total capacity of the shards.
+func (c *ShardedCache) Cap() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Cap()
+	}
+	return n
+}
+
+// Purge is used to completely clear the cache.
+func (c *ShardedCache) Purge() {
+	for _, shard := range c.shards {
+		shard.Purge()
+	}
+}
+
+// DeleteExpired removes the expired entries from the cache, invoking the
+// eviction callback for each of them, and returns how many were removed.
+func (c *ShardedCache) DeleteExpired() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.DeleteExpired()
+	}
+	return n
+}
+
+// Close stops the background reapers of the shards, if any.
+func (c *ShardedCache) Close() {
+	for _, shard := range c.shards {
+		shard.Close()
+	}
+}
+
+// Shards returns the length, capacity and stats of each shard, showing
+// how evenly the keys are spread.
+func (c *ShardedCache) Shards() []CacheStats {
+	stats := make([]CacheStats, len(c.shards))
+	for i, shard := range c.shards {
+		stats[i] = CacheStats{Len: shard.Len(), Cap: shard.Cap(), Stats: shard.Stats()}
+	}
+	return stats
+}
+
+// Rebalance moves capacity from the shards that evicted nothing since
+// the previous call to the shards that did, so that a skewed key
+// distribution does not leave some shards evicting while others sit half
+// empty. Donor shards give up half of their free capacity, down to a
+// quarter of an even share, and the hot shards receive it in proportion
+// to their evictions. The total capacity is unchanged, and no entry is
+// evicted unless a donor fills up concurrently. Returns the capacity
+// moved.
+func (c *ShardedCache) Rebalance() (moved int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	recent := make([]uint64, len(c.shards))
+	var total uint64
+	for i, shard := range c.shards {
+		evicted := shard.Counters().Evicted
+		recent[i] = evicted - c.evicted[i]
+		c.evicted[i] = evicted
+		total += recent[i]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	floor := c.Cap() / len(c.shards) / 4
+	if floor < 1 {
+		floor = 1
+	}
+	give := make([]int, len(c.shards))
+	for i, shard := range c.shards {
+		if recent[i] > 0 {
+			continue
+		}
+		n := (shard.Cap() - shard.Len()) / 2
+		if room := shard.Cap() - floor; n > room {
+			n = room
+		}
+		if n > 0 {
+			give[i] = n
+			moved += n
+		}
+	}
+	if moved == 0 {
+		return 0
+	}
+
+	// Hand out the capacity by evictions, the rounding left to the
+	// hottest shard
+	hottest, left := 0, moved
+	for i := range c.shards {
+		share := int(uint64(moved) * recent[i] / total)
+		give[i] -= share
+		left -= share
+		if recent[i] > recent[hottest] {
+			hottest = i
+		}
+	}
+	give[hottest] -= left
+	for i, shard := range c.shards {
+		if give[i] > 0 {
+			shard.Resize(shard.Cap() - give[i])
+		}
+	}
+	for i, shard := range c.shards {
+		if give[i] < 0 {
+			shard.Resize(shard.Cap() - give[i])
+		}
+	}
+	return moved
+}
+
+// StartRebalancer runs Rebalance every interval until the returned stop
+// function is called.
+func (c *ShardedCache) StartRebalancer(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				c.Rebalance()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,193 @@
+package lru
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func BenchmarkSharded_Parallel(b *testing.B) {
+	l, _ := NewSharded(8192, 16)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%2 == 0 {
+				l.Add(i%16384, i)
+			} else {
+				l.Get(i % 16384)
+			}
+			i++
+		}
+	})
+}
+
+func TestShardedCache(t *testing.T) {
+	if _, err := NewSharded(2, 4); err == nil {
+		t.Fatalf("should reject a size smaller than the shard count")
+	}
+	if _, err := NewSharded(4, 0); err == nil {
+		t.Fatalf("should reject zero shards")
+	}
+
+	evicted := 0
+	var lock sync.Mutex
+	l, err := NewShardedWithOptions(130, 4, func(k, v interface{}) {
+		lock.Lock()
+		evicted++
+		lock.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if l.Cap() != 130 {
+		t.Fatalf("bad cap: %v", l.Cap())
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Add(g*100+i, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if l.Len()+evicted != 400 || l.Len() > 130 {
+		t.Fatalf("bad len: %v evicted: %v", l.Len(), evicted)
+	}
+	if len(l.Keys()) != l.Len() {
+		t.Fatalf("bad keys: %v", len(l.Keys()))
+	}
+	for _, k := range l.Keys() {
+		if v, ok := l.Get(k); !ok || v != k.(int)%100 {
+			t.Fatalf("bad value for %v: %v", k, v)
+		}
+	}
+
+	if !l.Remove(l.Keys()[0]) {
+		t.Fatalf("bad remove")
+	}
+	if ok, _ := l.ContainsOrAdd(-1, -1); ok || !l.Contains(-1) {
+		t.Fatalf("bad contains or add")
+	}
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+}
+
+func TestShardedCache_StaggeredJanitors(t *testing.T) {
+	l, err := NewShardedWithOptions(40, 4, nil, simplelru.WithJanitor(time.Second))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+	for i, shard := range l.shards {
+		if d := shard.lru.JanitorDelay(); d != time.Duration(i)*250*time.Millisecond {
+			t.Fatalf("bad delay of shard %d: %v", i, d)
+		}
+	}
+}
+
+func TestShardedCache_Rebalance(t *testing.T) {
+	l, _ := NewSharded(40, 4)
+	hot := l.shards[0]
+	for i, n := 0, 0; n < 30; i++ {
+		if l.shard(i) == hot {
+			l.Add(i, i)
+			n++
+		}
+	}
+	for i := 0; ; i++ {
+		if l.shard(-i) != hot {
+			l.Add(-i, 1)
+			break
+		}
+	}
+	if hot.Len() != 10 || hot.Stats().Evicted != 20 {
+		t.Fatalf("bad hot shard: %+v", l.Shards()[0])
+	}
+
+	moved := l.Rebalance()
+	if moved == 0 || hot.Cap() != 10+moved || l.Cap() != 40 {
+		t.Fatalf("bad rebalance: %d %v", moved, l.Shards())
+	}
+	for _, s := range l.Shards()[1:] {
+		if s.Cap < 2 || s.Len > s.Cap {
+			t.Fatalf("bad donor: %+v", s)
+		}
+	}
+
+	// Nothing moves without new evictions
+	if moved := l.Rebalance(); moved != 0 {
+		t.Fatalf("bad rebalance: %d", moved)
+	}
+}
+
+func TestShardedCache_GetAllocs(t *testing.T) {
+	l, _ := NewSharded(128, 4)
+	keys := make([]interface{}, 128)
+	for i := range keys {
+		keys[i] = i * 1000
+		l.Add(keys[i], i)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, key := range keys {
+			l.Get(key)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("bad allocs: %v", allocs)
+	}
+}
+
+func TestShardedCache_CostBudget(t *testing.T) {
+	l, _ := NewShardedWithOptions(1000, 4, nil, simplelru.WithMaxCost(102, nil))
+	for i := 0; i < 1000; i++ {
+		l.Add(i, i)
+	}
+	var cost, budget int64
+	for _, shard := range l.shards {
+		cost += shard.Cost()
+		budget += shard.lru.MaxCost()
+	}
+	if budget != 102 || cost > 102 || l.Len() != int(cost) {
+		t.Fatalf("bad budget: %v cost: %v len: %v", budget, cost, l.Len())
+	}
+}
+
+func TestShardedCache_KeyIdentity(t *testing.T) {
+	l, _ := NewSharded(64, 8)
+	type target struct{ n int }
+	ptrs := make([]*target, 32)
+	for i := range ptrs {
+		ptrs[i] = &target{i}
+		l.Add(ptrs[i], i)
+	}
+	// Pointer keys are routed by address, not by what they point to
+	for i, p := range ptrs {
+		p.n += 1000
+		if v, ok := l.Get(p); !ok || v != i {
+			t.Fatalf("pointer key lost after its target changed: %v %v", v, ok)
+		}
+	}
+
+	type point struct {
+		x, y int
+		name string
+	}
+	l.Add(point{1, 2, "a"}, "p")
+	if v, ok := l.Get(point{1, 2, "a"}); !ok || v != "p" {
+		t.Fatalf("struct key should be found by value: %v %v", v, ok)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Get(ptrs[0])
+	})
+	if allocs != 0 {
+		t.Fatalf("bad allocs: %v", allocs)
+	}
+}
@@ -0,0 +1,426 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+// Package shm is an experimental LRU cache living in a memory mapped
+// file, so that several processes on one host, such as the workers of a
+// pre-fork server, share a single warm cache. Put the file in a memory
+// backed file system such as /dev/shm to keep it out of the disk.
+//
+// The cache holds a fixed number of slots of bounded key and value sizes,
+// laid out like simplelru: a hash index of the slots and a list of them
+// from newest to oldest, linked by slot numbers instead of pointers. The
+// processes serialize their operations with flock, so every operation
+// takes a system call; the cache suits values that are expensive to
+// compute rather than hot paths.
+package shm
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrTooLarge is returned when a key or value exceeds the slot sizes.
+	ErrTooLarge = errors.New("shm: key or value too large")
+
+	// ErrMismatch is returned when opening a file created with other
+	// slot counts or sizes.
+	ErrMismatch = errors.New("shm: file has another layout")
+
+	// ErrClosed is returned by the operations on a closed cache.
+	ErrClosed = errors.New("shm: cache is closed")
+)
+
+const (
+	magic = 0x6c72757368_6d0001 // "lrushm" and the layout version
+
+	// header layout
+	hMagic     = 0
+	hSlots     = 8
+	hKeySize   = 12
+	hValueSize = 16
+	hBuckets   = 20
+	hLen       = 24
+	hHead      = 28
+	hTail      = 32
+	hFree      = 36
+	hHits      = 40
+	hMisses    = 48
+	headerSize = 64
+
+	// slot layout, followed by the key and value bytes
+	sPrev     = 0
+	sNext     = 4
+	sHashNext = 8
+	sKeyLen   = 12
+	sValueLen = 16
+	sHash     = 24
+	sExpire   = 32
+	slotData  = 40
+
+	none = -1
+)
+
+// Cache is an LRU cache shared by the processes mapping the same file.
+// It is safe for concurrent use.
+type Cache struct {
+	lock      sync.Mutex
+	f         *os.File
+	mem       []byte
+	slots     int
+	keySize   int
+	valueSize int
+	buckets   int
+	slotSize  int
+}
+
+// Open maps the cache in the file at path, creating it with the given
+// number of slots and maximum key and value sizes if it does not exist.
+// An existing file must have been created with the same parameters.
+func Open(path string, slots, keySize, valueSize int) (*Cache, error) {
+	if slots <= 0 || keySize <= 0 || valueSize <= 0 {
+		return nil, errors.New("shm: must provide positive sizes")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		f:         f,
+		slots:     slots,
+		keySize:   keySize,
+		valueSize: valueSize,
+		buckets:   slots,
+		slotSize:  (slotData + keySize + valueSize + 7) &^ 7,
+	}
+	if err := c.open(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// open maps the file, initializing it if empty, under the file lock so
+// that concurrent processes see it initialized once
+func (c *Cache) open() error {
+	if err := syscall.Flock(int(c.f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(c.f.Fd()), syscall.LOCK_UN)
+
+	size := headerSize + 4*c.buckets + c.slots*c.slotSize
+	fi, err := c.f.Stat()
+	if err != nil {
+		return err
+	}
+	fresh := fi.Size() == 0
+	if fresh {
+		if err := c.f.Truncate(int64(size)); err != nil {
+			return err
+		}
+	} else if fi.Size() != int64(size) {
+		return ErrMismatch
+	}
+	mem, err := syscall.Mmap(int(c.f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	c.mem = mem
+	if fresh {
+		c.init()
+		return nil
+	}
+	if c.u64(hMagic) != magic || c.u32(hSlots) != uint32(c.slots) ||
+		c.u32(hKeySize) != uint32(c.keySize) || c.u32(hValueSize) != uint32(c.valueSize) {
+		syscall.Munmap(mem)
+		c.mem = nil
+		return ErrMismatch
+	}
+	return nil
+}
+
+// init writes an empty cache, the file lock must be held
+func (c *Cache) init() {
+	c.putU32(hSlots, uint32(c.slots))
+	c.putU32(hKeySize, uint32(c.keySize))
+	c.putU32(hValueSize, uint32(c.valueSize))
+	c.putU32(hBuckets, uint32(c.buckets))
+	c.reset()
+	c.putU64(hMagic, magic)
+}
+
+// reset empties the cache, the lock must be held
+func (c *Cache) reset() {
+	c.putU32(hLen, 0)
+	c.putI32(hHead, none)
+	c.putI32(hTail, none)
+	for b := 0; b < c.buckets; b++ {
+		c.putI32(headerSize+4*b, none)
+	}
+	// Chain all the slots in the free list
+	for s := 0; s < c.slots; s++ {
+		next := int32(s + 1)
+		if s == c.slots-1 {
+			next = none
+		}
+		c.putI32(c.slot(int32(s))+sNext, next)
+	}
+	c.putI32(hFree, 0)
+}
+
+// Close unmaps the cache. The file is left in place for the other
+// processes.
+func (c *Cache) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.mem == nil {
+		return nil
+	}
+	err := syscall.Munmap(c.mem)
+	c.mem = nil
+	if cerr := c.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// do runs f holding the process lock and the file lock
+func (c *Cache) do(f func()) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.mem == nil {
+		return ErrClosed
+	}
+	if err := syscall.Flock(int(c.f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(c.f.Fd()), syscall.LOCK_UN)
+	f()
+	return nil
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *Cache) Add(key string, value []byte) (bool, error) {
+	return c.AddEx(key, value, 0)
+}
+
+// AddEx adds a value to the cache with expire, in wall clock time since
+// the processes share no monotonic clock. Returns true if an eviction
+// occurred.
+func (c *Cache) AddEx(key string, value []byte, expire time.Duration) (evicted bool, err error) {
+	if len(key) > c.keySize || len(value) > c.valueSize {
+		return false, ErrTooLarge
+	}
+	var deadline int64
+	if expire > 0 {
+		deadline = time.Now().Add(expire).UnixNano()
+	}
+	h := hash(key)
+	err = c.do(func() {
+		s := c.find(key, h)
+		if s == none {
+			if s = c.i32(hFree); s == none {
+				s = c.i32(hTail)
+				c.remove(s)
+				evicted = true
+				s = c.i32(hFree)
+			}
+			c.putI32(hFree, c.i32(c.slot(s)+sNext))
+			c.insert(s, key, h)
+		} else {
+			c.unlink(s)
+			c.pushFront(s)
+		}
+		off := c.slot(s)
+		c.putU32(off+sValueLen, uint32(len(value)))
+		c.putI64(off+sExpire, deadline)
+		copy(c.mem[off+slotData+c.keySize:], value)
+	})
+	return evicted, err
+}
+
+// Get looks up a key's value from the cache, marking it as recently used.
+// The value is a copy.
+func (c *Cache) Get(key string) (value []byte, ok bool, err error) {
+	h := hash(key)
+	err = c.do(func() {
+		s := c.live(key, h)
+		if s == none {
+			c.putU64(hMisses, c.u64(hMisses)+1)
+			return
+		}
+		c.putU64(hHits, c.u64(hHits)+1)
+		c.unlink(s)
+		c.pushFront(s)
+		value, ok = c.value(s), true
+	})
+	return value, ok, err
+}
+
+// Peek returns a copy of a key's value without updating its recent-ness.
+func (c *Cache) Peek(key string) (value []byte, ok bool, err error) {
+	h := hash(key)
+	err = c.do(func() {
+		if s := c.live(key, h); s != none {
+			value, ok = c.value(s), true
+		}
+	})
+	return value, ok, err
+}
+
+// Remove removes a key from the cache, returning if it was contained.
+func (c *Cache) Remove(key string) (present bool, err error) {
+	h := hash(key)
+	err = c.do(func() {
+		if s := c.find(key, h); s != none {
+			c.remove(s)
+			present = true
+		}
+	})
+	return present, err
+}
+
+// Keys returns the keys in the cache, from oldest to newest, including
+// the expired keys not removed yet.
+func (c *Cache) Keys() (keys []string, err error) {
+	err = c.do(func() {
+		for s := c.i32(hTail); s != none; s = c.i32(c.slot(s) + sPrev) {
+			keys = append(keys, c.key(s))
+		}
+	})
+	return keys, err
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() (n int, err error) {
+	err = c.do(func() { n = int(c.u32(hLen)) })
+	return n, err
+}
+
+// Purge empties the cache for all the processes.
+func (c *Cache) Purge() error {
+	return c.do(c.reset)
+}
+
+// Lookups returns the hits and misses of all the processes.
+func (c *Cache) Lookups() (hits, misses uint64, err error) {
+	err = c.do(func() { hits, misses = c.u64(hHits), c.u64(hMisses) })
+	return hits, misses, err
+}
+
+// find returns the slot of a key, or none
+func (c *Cache) find(key string, h uint64) int32 {
+	for s := c.i32(c.bucket(h)); s != none; s = c.i32(c.slot(s) + sHashNext) {
+		if c.u64(c.slot(s)+sHash) == h && c.key(s) == key {
+			return s
+		}
+	}
+	return none
+}
+
+// live is find removing an expired entry
+func (c *Cache) live(key string, h uint64) int32 {
+	s := c.find(key, h)
+	if s == none {
+		return none
+	}
+	if deadline := c.i64(c.slot(s) + sExpire); deadline != 0 && time.Now().UnixNano() > deadline {
+		c.remove(s)
+		return none
+	}
+	return s
+}
+
+// insert fills a free slot with a key and links it as the newest entry
+func (c *Cache) insert(s int32, key string, h uint64) {
+	off := c.slot(s)
+	c.putU64(off+sHash, h)
+	c.putU32(off+sKeyLen, uint32(len(key)))
+	copy(c.mem[off+slotData:], key)
+	b := c.bucket(h)
+	c.putI32(off+sHashNext, c.i32(b))
+	c.putI32(b, s)
+	c.pushFront(s)
+	c.putU32(hLen, c.u32(hLen)+1)
+}
+
+// remove unlinks a slot from the index and the list and frees it
+func (c *Cache) remove(s int32) {
+	off := c.slot(s)
+	link := c.bucket(c.u64(off + sHash))
+	for p := c.i32(link); p != s; p = c.i32(link) {
+		link = c.slot(p) + sHashNext
+	}
+	c.putI32(link, c.i32(off+sHashNext))
+	c.unlink(s)
+	c.putI32(off+sNext, c.i32(hFree))
+	c.putI32(hFree, s)
+	c.putU32(hLen, c.u32(hLen)-1)
+}
+
+func (c *Cache) pushFront(s int32) {
+	off := c.slot(s)
+	head := c.i32(hHead)
+	c.putI32(off+sPrev, none)
+	c.putI32(off+sNext, head)
+	if head != none {
+		c.putI32(c.slot(head)+sPrev, s)
+	} else {
+		c.putI32(hTail, s)
+	}
+	c.putI32(hHead, s)
+}
+
+func (c *Cache) unlink(s int32) {
+	off := c.slot(s)
+	prev, next := c.i32(off+sPrev), c.i32(off+sNext)
+	if prev != none {
+		c.putI32(c.slot(prev)+sNext, next)
+	} else {
+		c.putI32(hHead, next)
+	}
+	if next != none {
+		c.putI32(c.slot(next)+sPrev, prev)
+	} else {
+		c.putI32(hTail, prev)
+	}
+}
+
+func (c *Cache) key(s int32) string {
+	off := c.slot(s)
+	n := int(c.u32(off + sKeyLen))
+	return string(c.mem[off+slotData : off+slotData+n])
+}
+
+func (c *Cache) value(s int32) []byte {
+	off := c.slot(s) + slotData + c.keySize
+	n := int(c.u32(c.slot(s) + sValueLen))
+	return append([]byte(nil), c.mem[off:off+n]...)
+}
+
+func (c *Cache) slot(s int32) int {
+	return headerSize + 4*c.buckets + int(s)*c.slotSize
+}
+
+func (c *Cache) bucket(h uint64) int {
+	return headerSize + 4*int(h%uint64(c.buckets))
+}
+
+func (c *Cache) u32(off int) uint32       { return binary.LittleEndian.Uint32(c.mem[off:]) }
+func (c *Cache) u64(off int) uint64       { return binary.LittleEndian.Uint64(c.mem[off:]) }
+func (c *Cache) i32(off int) int32        { return int32(c.u32(off)) }
+func (c *Cache) i64(off int) int64        { return int64(c.u64(off)) }
+func (c *Cache) putU32(off int, v uint32) { binary.LittleEndian.PutUint32(c.mem[off:], v) }
+func (c *Cache) putU64(off int, v uint64) { binary.LittleEndian.PutUint64(c.mem[off:], v) }
+func (c *Cache) putI32(off int, v int32)  { c.putU32(off, uint32(v)) }
+func (c *Cache) putI64(off int, v int64)  { c.putU64(off, uint64(v)) }
+
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
@@ -0,0 +1,113 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package shm
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	a, err := Open(path, 2, 8, 8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer a.Close()
+	// A second mapping stands for another process
+	b, err := Open(path, 2, 8, 8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer b.Close()
+
+	a.Add("a", []byte("1"))
+	a.Add("b", []byte("2"))
+	if v, ok, _ := b.Get("a"); !ok || string(v) != "1" {
+		t.Fatalf("bad value: %q %v", v, ok)
+	}
+	if evicted, _ := b.Add("c", []byte("3")); !evicted {
+		t.Fatalf("should evict")
+	}
+	if _, ok, _ := a.Peek("b"); ok {
+		t.Fatalf("b should be evicted")
+	}
+	if keys, _ := a.Keys(); !reflect.DeepEqual(keys, []string{"a", "c"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if hits, misses, _ := a.Lookups(); hits != 1 || misses != 0 {
+		t.Fatalf("bad lookups: %v %v", hits, misses)
+	}
+
+	a.Add("a", []byte("10"))
+	if v, _, _ := b.Peek("a"); string(v) != "10" {
+		t.Fatalf("bad value: %q", v)
+	}
+	if ok, _ := b.Remove("a"); !ok {
+		t.Fatalf("should remove")
+	}
+	if n, _ := a.Len(); n != 1 {
+		t.Fatalf("bad len: %v", n)
+	}
+	b.Purge()
+	if n, _ := a.Len(); n != 0 {
+		t.Fatalf("bad len: %v", n)
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	c, err := Open(path, 4, 4, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Add("toolong", nil); err != ErrTooLarge {
+		t.Fatalf("bad err: %v", err)
+	}
+	if _, err := Open(path, 8, 4, 4); err != ErrMismatch {
+		t.Fatalf("bad err: %v", err)
+	}
+	c.AddEx("a", []byte("1"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := c.Get("a"); ok {
+		t.Fatalf("a should be expired")
+	}
+	c.Add("b", []byte("2"))
+	c.Close()
+	if _, err := c.Add("a", nil); err != ErrClosed {
+		t.Fatalf("bad err: %v", err)
+	}
+	// The entries outlive the mappings
+	c, _ = Open(path, 4, 4, 4)
+	defer c.Close()
+	if v, ok, _ := c.Get("b"); !ok || string(v) != "2" {
+		t.Fatalf("bad value: %q %v", v, ok)
+	}
+}
+
+func TestCacheChurn(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "cache"), 16, 8, 8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c.Close()
+	for i := 0; i < 1000; i++ {
+		k := string(rune('a' + i%40))
+		c.Add(k, []byte(k))
+		if i%3 == 0 {
+			c.Remove(string(rune('a' + i%7)))
+		}
+	}
+	keys, _ := c.Keys()
+	n, _ := c.Len()
+	if len(keys) != n || n > 16 {
+		t.Fatalf("bad len: %v %v", len(keys), n)
+	}
+	for _, k := range keys {
+		if v, ok, _ := c.Peek(k); !ok || string(v) != k {
+			t.Fatalf("bad value for %v: %q", k, v)
+		}
+	}
+}
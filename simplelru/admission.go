@@ -0,0 +1,150 @@
+package simplelru
+
+// AdmissionPolicy decides if a new key may take the place of the entry
+// an LRU would evict to make room for it, so that keys requested once do
+// not push out the entries requested often. The LRU reports every key
+// passed to Add or Get. An AdmissionPolicy is only used under the LRU, so
+// it needs no locking of its own.
+type AdmissionPolicy interface {
+	// Record is called for every key added or looked up.
+	Record(key interface{})
+
+	// Admit returns if candidate may be added in place of victim.
+	Admit(candidate, victim interface{}) bool
+}
+
+// WithAdmissionPolicy consults the policy returned by newPolicy before
+// adding a new key to a full cache: if it declines, the Add is dropped
+// and counted in the Rejected stat, leaving the cache unchanged. Expired
+// victims are always replaced. newPolicy is called again by Clone to
+// start from an empty policy.
+func WithAdmissionPolicy(newPolicy func() AdmissionPolicy) Option {
+	return func(c *LRU) {
+		c.newAdmission = newPolicy
+		c.admission = newPolicy()
+	}
+}
+
+// admit returns if a new key may be added, evicting the victim
+func (c *LRU) admit(key interface{}) bool {
+	if c.admission == nil || c.evictList.Len() < c.size {
+		return true
+	}
+	ent := c.victim()
+	if ent == nil {
+		return true
+	}
+	if kv := ent.Value.(*entry); c.expired(kv) || c.admission.Admit(key, kv.key) {
+		return true
+	}
+	c.stats.Rejected++
+	return false
+}
+
+// TinyLFU is an AdmissionPolicy admitting a key unless it was requested
+// less often than the victim, after TinyLFU (Einziger et al.). The
+// frequencies are estimated by a count-min sketch of 4 bit counters, in
+// front of which a doorkeeper bloom filter absorbs the keys seen once.
+// The counters are halved periodically, so that the estimates follow
+// changes of the workload.
+type TinyLFU struct {
+	rows      [4][]uint8
+	mask      uint64
+	door      []uint64
+	additions int
+	resetAt   int
+}
+
+// NewTinyLFU constructs a TinyLFU sized for about counters distinct keys,
+// typically the capacity of the cache or a few times it.
+func NewTinyLFU(counters int) *TinyLFU {
+	width := 64
+	for width < counters {
+		width <<= 1
+	}
+	t := &TinyLFU{
+		mask:    uint64(width - 1),
+		door:    make([]uint64, width/64),
+		resetAt: 10 * width,
+	}
+	for i := range t.rows {
+		t.rows[i] = make([]uint8, width)
+	}
+	return t
+}
+
+// Record counts a request of key.
+func (t *TinyLFU) Record(key interface{}) {
+	h := HashKey(key)
+	if !t.doorkeep(h) {
+		return
+	}
+	for i := range t.rows {
+		if c := &t.rows[i][t.index(h, i)]; *c < 15 {
+			*c++
+		}
+	}
+	if t.additions++; t.additions >= t.resetAt {
+		t.reset()
+	}
+}
+
+// Admit returns if candidate was requested at least as often as victim.
+func (t *TinyLFU) Admit(candidate, victim interface{}) bool {
+	return t.Estimate(candidate) >= t.Estimate(victim)
+}
+
+// Estimate returns the estimated number of requests of key since the
+// last reset, at most 16.
+func (t *TinyLFU) Estimate(key interface{}) int {
+	h := HashKey(key)
+	min := uint8(15)
+	for i := range t.rows {
+		if c := t.rows[i][t.index(h, i)]; c < min {
+			min = c
+		}
+	}
+	if t.seen(h) {
+		return int(min) + 1
+	}
+	return int(min)
+}
+
+// index returns the counter of h in row i, by double hashing
+func (t *TinyLFU) index(h uint64, i int) uint64 {
+	return (h + uint64(i)*(h>>32|1)) & t.mask
+}
+
+// doorBits returns the two doorkeeper bits of h
+func (t *TinyLFU) doorBits(h uint64) (a, b uint64) {
+	return h & t.mask, (h >> 32) & t.mask
+}
+
+func (t *TinyLFU) seen(h uint64) bool {
+	a, b := t.doorBits(h)
+	return t.door[a/64]&(1<<(a%64)) != 0 && t.door[b/64]&(1<<(b%64)) != 0
+}
+
+// doorkeep returns if h was seen before, marking it as seen
+func (t *TinyLFU) doorkeep(h uint64) bool {
+	if t.seen(h) {
+		return true
+	}
+	a, b := t.doorBits(h)
+	t.door[a/64] |= 1 << (a % 64)
+	t.door[b/64] |= 1 << (b % 64)
+	return false
+}
+
+// reset halves the counters and clears the doorkeeper
+func (t *TinyLFU) reset() {
+	for i := range t.rows {
+		for j := range t.rows[i] {
+			t.rows[i][j] >>= 1
+		}
+	}
+	for i := range t.door {
+		t.door[i] = 0
+	}
+	t.additions = 0
+}
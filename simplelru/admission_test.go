@@ -0,0 +1,101 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func tinyLFU() AdmissionPolicy {
+	return NewTinyLFU(64)
+}
+
+func TestTinyLFU(t *testing.T) {
+	f := NewTinyLFU(64)
+	if n := f.Estimate("a"); n != 0 {
+		t.Fatalf("bad estimate: %d", n)
+	}
+	f.Record("a")
+	if n := f.Estimate("a"); n != 1 {
+		t.Fatalf("bad estimate: %d", n)
+	}
+	for i := 0; i < 4; i++ {
+		f.Record("a")
+	}
+	f.Record("b")
+	if n := f.Estimate("a"); n != 5 {
+		t.Fatalf("bad estimate: %d", n)
+	}
+	if f.Admit("b", "a") || !f.Admit("a", "b") || !f.Admit("b", "b") {
+		t.Fatalf("bad admission")
+	}
+
+	// The counters are halved after 10 samples per counter
+	for i := 0; i < 10*64; i++ {
+		f.Record("c")
+	}
+	if n := f.Estimate("a"); n != 2 {
+		t.Fatalf("should decay: %d", n)
+	}
+}
+
+func TestLRU_AdmissionPolicy(t *testing.T) {
+	l, err := NewLRUWithOptions(4, nil, WithAdmissionPolicy(tinyLFU))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+		for j := 0; j < 3; j++ {
+			l.Get(i)
+		}
+	}
+
+	// A scan of keys requested once leaves the hot entries in place
+	for i := 100; i < 200; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("should not evict")
+		}
+	}
+	for i := 0; i < 4; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("should be admitted: %d", i)
+		}
+	}
+	if s := l.Stats(); s.Rejected != 100 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	// A key requested as often as the victim replaces it
+	for j := 0; j < 4; j++ {
+		l.Get(300)
+	}
+	if !l.Add(300, 300) || !l.Contains(300) || l.Contains(0) {
+		t.Fatalf("should be admitted: %v", l.Keys())
+	}
+
+	// Updates of present keys are never rejected
+	l.Add(300, 301)
+	if v, _ := l.Peek(300); v != 301 {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	if c := l.Clone(); c.admission == nil || c.admission == l.admission {
+		t.Fatalf("clone should get a fresh policy")
+	}
+}
+
+func TestLRU_AdmissionExpiredVictim(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l, err := NewLRUWithOptions(2, nil, WithAdmissionPolicy(tinyLFU), WithClock(clock))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.AddEx(1, 1, time.Second)
+	l.AddEx(2, 2, time.Second)
+	l.Get(1)
+	l.Get(2)
+	clock.Advance(2 * time.Second)
+	if !l.Add(3, 3) || !l.Contains(3) {
+		t.Fatalf("should replace an expired victim")
+	}
+}
@@ -0,0 +1,137 @@
+package simplelru
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Sizer returns the memory held by an entry in bytes.
+type Sizer func(key, value interface{}) int64
+
+// mapSlotOverhead is the share of a bucket of the items map held per
+// entry: a bucket holds 8 keys, 8 values and their top hashes plus an
+// overflow pointer, and the map grows once its buckets average 6.5
+// entries
+const mapSlotOverhead = (8*(unsafe.Sizeof(interface{}(nil))+unsafe.Sizeof(uintptr(0))+1) + unsafe.Sizeof(uintptr(0))) * 2 / 13
+
+// entryOverhead is the memory the cache itself holds per entry: the list
+// element, the entry and its share of the items map
+const entryOverhead = int64(unsafe.Sizeof(Element{}) + unsafe.Sizeof(entry{}) + mapSlotOverhead)
+
+// NewLRUWithMaxBytes constructs an LRU bounded by the estimated memory of
+// its entries, see WithMaxBytes.
+func NewLRUWithMaxBytes(maxBytes int64, sizer Sizer, onEvict EvictCallback) (*LRU, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("Must provide a positive byte budget")
+	}
+	return NewLRUWithOptions(math.MaxInt, onEvict, WithMaxBytes(maxBytes, sizer))
+}
+
+// WithMaxBytes bounds the estimated memory of the entries to maxBytes.
+// sizer estimates the memory of each entry added; if it is nil, the key
+// and value are measured with EstimateSize. The overhead of the cache per
+// entry is added to either estimate, see WithEntryOverhead. It uses the
+// cost budget, so it cannot be combined with WithMaxCost.
+func WithMaxBytes(maxBytes int64, sizer Sizer) Option {
+	if sizer == nil {
+		sizer = func(key, value interface{}) int64 {
+			return EstimateSize(key) + EstimateSize(value)
+		}
+	}
+	return func(c *LRU) {
+		WithMaxCost(maxBytes, sizer)(c)
+		WithEntryOverhead()(c)
+	}
+}
+
+// WithEntryOverhead adds the memory the cache itself holds per entry, its
+// list element, entry struct and share of the items map, to the cost of
+// every entry, including those added with an explicit cost. Combined with
+// WithMaxCost and costs in bytes, the budget then approximates the memory
+// the cache grows the process by rather than the size of the values.
+func WithEntryOverhead() Option {
+	return func(c *LRU) {
+		c.overhead = entryOverhead
+	}
+}
+
+// EntryOverhead returns the cost added to every entry by
+// WithEntryOverhead, or 0.
+func (c *LRU) EntryOverhead() int64 {
+	return c.overhead
+}
+
+// BytesUsed returns the estimated memory of the entries in the cache when
+// it is bounded by WithMaxBytes.
+func (c *LRU) BytesUsed() int64 {
+	return c.cost
+}
+
+// EstimateSize returns an estimate of the memory held by v in bytes,
+// following pointers, slices, maps and interfaces and counting memory
+// shared by several of them once. It is a fallback for types without a
+// better measure, and costs a walk of the whole value.
+func EstimateSize(v interface{}) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + indirectSize(rv, make(map[uintptr]bool))
+}
+
+// indirectSize returns the memory v refers to beyond its own size
+func indirectSize(v reflect.Value, seen map[uintptr]bool) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		return int64(v.Type().Elem().Size()) + indirectSize(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + indirectSize(e, seen)
+	case reflect.Slice:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += indirectSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += indirectSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || seen[v.Pointer()] {
+			return 0
+		}
+		seen[v.Pointer()] = true
+		t := v.Type()
+		entry := int64(t.Key().Size() + t.Elem().Size())
+		var size int64
+		for it := v.MapRange(); it.Next(); {
+			size += entry + indirectSize(it.Key(), seen) + indirectSize(it.Value(), seen)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += indirectSize(v.Field(i), seen)
+		}
+		return size
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestEstimateSize(t *testing.T) {
+	type node struct {
+		name string
+		next *node
+	}
+	n := &node{name: "abcd"}
+	n.next = n
+	shared := make([]byte, 10, 16)
+
+	for _, c := range []struct {
+		v    interface{}
+		want int64
+	}{
+		{nil, 0},
+		{int64(1), 8},
+		{"abcd", 16 + 4},
+		{shared, 24 + 16},
+		{[][]byte{shared, shared}, 24 + 2*24 + 16},
+		{n, 8 + 24 + 4},
+		{map[string]int{"ab": 1}, 8 + 16 + 8 + 2},
+		{[]interface{}{"ab"}, 24 + 16 + 16 + 2},
+	} {
+		if got := EstimateSize(c.v); got != c.want {
+			t.Fatalf("bad size of %#v: %v, want %v", c.v, got, c.want)
+		}
+	}
+}
+
+func TestLRU_MaxBytes(t *testing.T) {
+	value := make([]byte, 1000)
+	perEntry := EstimateSize(0) + EstimateSize(value) + entryOverhead
+	l, err := NewLRUWithMaxBytes(3*perEntry, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Add(i, value)
+	}
+	if l.Len() != 3 || l.BytesUsed() != 3*perEntry {
+		t.Fatalf("bad len: %v bytes: %v", l.Len(), l.BytesUsed())
+	}
+	if l.Contains(1) || !l.Contains(4) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+
+	l, _ = NewLRUWithMaxBytes(1000, func(key, value interface{}) int64 {
+		return int64(len(value.(string)))
+	}, nil)
+	l.Add(1, string(make([]byte, 600)))
+	l.Add(2, string(make([]byte, 600)))
+	if l.Len() != 1 || l.BytesUsed() != 600+entryOverhead {
+		t.Fatalf("bad len: %v bytes: %v", l.Len(), l.BytesUsed())
+	}
+	if _, err := NewLRUWithMaxBytes(0, nil, nil); err == nil {
+		t.Fatalf("should reject an empty budget")
+	}
+}
+
+func TestLRU_EntryOverhead(t *testing.T) {
+	l, _ := NewLRUWithOptions(100, nil, WithMaxCost(1000+2*entryOverhead, func(key, value interface{}) int64 {
+		return int64(len(value.(string)))
+	}), WithEntryOverhead())
+	if l.EntryOverhead() != entryOverhead || entryOverhead < 100 {
+		t.Fatalf("bad overhead: %v", l.EntryOverhead())
+	}
+	l.Add(1, string(make([]byte, 400)))
+	l.AddWithCost(2, "", 600)
+	if l.Len() != 2 || l.Cost() != 1000+2*entryOverhead {
+		t.Fatalf("bad len: %v cost: %v", l.Len(), l.Cost())
+	}
+	// The overhead alone of a third entry does not fit
+	l.Add(3, "")
+	if l.Len() != 2 || l.Contains(1) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+	l.PeekAndUpdate(3, "abc")
+	if l.Cost() != 603+2*entryOverhead {
+		t.Fatalf("bad cost: %v", l.Cost())
+	}
+	if c := l.Clone(); c.Cost() != l.Cost() || c.EntryOverhead() != entryOverhead {
+		t.Fatalf("bad clone: %v %v", c.Cost(), c.EntryOverhead())
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
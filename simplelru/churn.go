@@ -0,0 +1,93 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// churnTracker counts the keys evicted for capacity without ever being
+// hit, to spot keys that are added and evicted over and over
+type churnTracker struct {
+	window    time.Duration
+	threshold int
+	limit     int
+	keys      map[interface{}]*churnRecord
+}
+
+// churnRecord counts the wasted insertions of a key within a window
+type churnRecord struct {
+	count int
+	since time.Time
+}
+
+func newChurnTracker(window time.Duration, threshold, limit int) *churnTracker {
+	return &churnTracker{
+		window:    window,
+		threshold: threshold,
+		limit:     limit,
+		keys:      make(map[interface{}]*churnRecord),
+	}
+}
+
+// WithChurnTracking flags the keys that are evicted for capacity at least
+// threshold times within window without a single hit in between. Such
+// keys waste capacity and usually call for admission control or a larger
+// cache. At most as many keys as the cache size are tracked.
+func WithChurnTracking(window time.Duration, threshold int) Option {
+	return func(c *LRU) {
+		c.churn = newChurnTracker(window, threshold, c.size)
+	}
+}
+
+// ChurningKeys returns the keys flagged by churn tracking, or nil if
+// churn tracking is disabled.
+func (c *LRU) ChurningKeys() []interface{} {
+	if c.churn == nil {
+		return nil
+	}
+	return c.churn.churning(c.now())
+}
+
+// record counts an eviction of key without hits
+func (t *churnTracker) record(key interface{}, now time.Time) {
+	r, ok := t.keys[key]
+	if ok && now.Sub(r.since) > t.window {
+		r.count = 0
+		r.since = now
+	}
+	if !ok {
+		if len(t.keys) >= t.limit {
+			t.prune(now)
+			if len(t.keys) >= t.limit {
+				return
+			}
+		}
+		r = &churnRecord{since: now}
+		t.keys[key] = r
+	}
+	r.count++
+}
+
+// forget drops the record of a key that got a hit
+func (t *churnTracker) forget(key interface{}) {
+	delete(t.keys, key)
+}
+
+// prune drops the records whose window has passed
+func (t *churnTracker) prune(now time.Time) {
+	for key, r := range t.keys {
+		if now.Sub(r.since) > t.window {
+			delete(t.keys, key)
+		}
+	}
+}
+
+// churning returns the keys over the threshold within their window
+func (t *churnTracker) churning(now time.Time) []interface{} {
+	var keys []interface{}
+	for key, r := range t.keys {
+		if r.count >= t.threshold && now.Sub(r.since) <= t.window {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,61 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_Churn(t *testing.T) {
+	l, err := NewLRUWithOptions(2, nil, WithChurnTracking(time.Minute, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// 1 is re-added and pushed out three times without being read
+	for i := 0; i < 3; i++ {
+		l.Add(1, 1)
+		l.Add(2, 2)
+		l.Get(2)
+		l.Add(3, 3)
+		l.Get(3)
+	}
+	keys := l.ChurningKeys()
+	if len(keys) != 1 || keys[0] != 1 {
+		t.Fatalf("bad churning keys: %v", keys)
+	}
+	if s := l.Stats(); s.Churning != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	// A hit clears the key
+	l.Add(1, 1)
+	l.Get(1)
+	if keys := l.ChurningKeys(); len(keys) != 0 {
+		t.Fatalf("bad churning keys: %v", keys)
+	}
+
+	// Explicit removals are not churn
+	l.Remove(3)
+	if keys := l.ChurningKeys(); len(keys) != 0 {
+		t.Fatalf("bad churning keys: %v", keys)
+	}
+}
+
+func TestLRU_ChurnDisabled(t *testing.T) {
+	l, _ := NewLRU(1, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if keys := l.ChurningKeys(); keys != nil {
+		t.Fatalf("bad churning keys: %v", keys)
+	}
+}
+
+func TestLRU_ChurnLimit(t *testing.T) {
+	l, _ := NewLRUWithOptions(2, nil, WithChurnTracking(time.Minute, 1))
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+	}
+	if n := len(l.churn.keys); n > 2 {
+		t.Fatalf("tracked too many keys: %v", n)
+	}
+}
@@ -0,0 +1,148 @@
+package simplelru
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of an LRU, set by WithClock so that tests can
+// drive expiration deterministically instead of sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a channel receiving the time once d has passed,
+	// and a function stopping the timer.
+	NewTimer(d time.Duration) (<-chan time.Time, func())
+
+	// NewTicker returns a channel receiving the time every d, dropping
+	// ticks for slow receivers, and a function stopping the ticker.
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+// SystemClock is the real clock, used by default.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+func (systemClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// WithClock sets the time source of the expire times, the statistics
+// windows and the janitor.
+func WithClock(clock Clock) Option {
+	return func(c *LRU) {
+		c.clock = clock
+	}
+}
+
+// Clock returns the time source of the cache.
+func (c *LRU) Clock() Clock {
+	if c.clock == nil {
+		return SystemClock
+	}
+	return c.clock
+}
+
+// now returns the current time of the clock
+func (c *LRU) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// expired returns if an entry is expired at the current time
+func (c *LRU) expired(kv *entry) bool {
+	return kv.expire != nil && c.now().After(*kv.expire)
+}
+
+// ManualClock is a Clock whose time only moves with Advance, for tests.
+// It is safe for concurrent use.
+type ManualClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// manualTimer is a timer or, with a period, a ticker of a ManualClock
+type manualTimer struct {
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewManualClock creates a ManualClock set to start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now implements Clock.
+func (m *ManualClock) Now() time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.now
+}
+
+// NewTimer implements Clock.
+func (m *ManualClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	return m.start(d, 0)
+}
+
+// NewTicker implements Clock.
+func (m *ManualClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	return m.start(d, d)
+}
+
+func (m *ManualClock) start(d, period time.Duration) (<-chan time.Time, func()) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	t := &manualTimer{at: m.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	m.timers = append(m.timers, t)
+	return t.c, func() { m.stop(t) }
+}
+
+func (m *ManualClock) stop(t *manualTimer) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, o := range m.timers {
+		if o == t {
+			m.timers = append(m.timers[:i], m.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Advance moves the clock forward by d, firing the timers and tickers
+// due by then.
+func (m *ManualClock) Advance(d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.now = m.now.Add(d)
+	timers := m.timers[:0]
+	for _, t := range m.timers {
+		if !t.at.After(m.now) {
+			select {
+			case t.c <- m.now:
+			default:
+			}
+			if t.period <= 0 {
+				continue
+			}
+			for !t.at.After(m.now) {
+				t.at = t.at.Add(t.period)
+			}
+		}
+		timers = append(timers, t)
+	}
+	m.timers = timers
+}
@@ -0,0 +1,71 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_ManualClock(t *testing.T) {
+	clock := NewManualClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	l, _ := NewLRUWithOptions(10, nil, WithClock(clock), WithExpire(time.Minute))
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Hour)
+
+	clock.Advance(59 * time.Second)
+	if !l.Contains(1) {
+		t.Fatalf("should not be expired yet")
+	}
+	clock.Advance(2 * time.Second)
+	if l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("bad expiration: %v", l.Keys())
+	}
+	if _, expire, _ := l.PeekWithExpireTime(2); !expire.Equal(clock.Now().Add(time.Hour - 61*time.Second)) {
+		t.Fatalf("bad expire time: %v", expire)
+	}
+	if n := l.DeleteExpired(); n != 1 {
+		t.Fatalf("bad removed: %v", n)
+	}
+	if s := l.Stats(); s.MaxExpiryLag != time.Second {
+		t.Fatalf("lag should follow the clock: %v", s.MaxExpiryLag)
+	}
+	if l.Clone().Clock() != clock {
+		t.Fatalf("clone should keep the clock")
+	}
+}
+
+func TestManualClock_Timers(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	timer, _ := clock.NewTimer(time.Second)
+	ticker, stop := clock.NewTicker(time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-timer:
+		t.Fatalf("timer fired early")
+	case <-ticker:
+		t.Fatalf("ticker fired early")
+	default:
+	}
+	clock.Advance(500 * time.Millisecond)
+	<-timer
+	<-ticker
+	clock.Advance(3 * time.Second)
+	<-ticker
+	select {
+	case <-timer:
+		t.Fatalf("timer fired twice")
+	case <-ticker:
+		t.Fatalf("ticker should drop ticks")
+	default:
+	}
+	stop()
+	clock.Advance(time.Second)
+	select {
+	case <-ticker:
+		t.Fatalf("stopped ticker fired")
+	default:
+	}
+	if SystemClock.Now().IsZero() {
+		t.Fatalf("bad system clock")
+	}
+}
@@ -0,0 +1,61 @@
+package simplelru
+
+import "time"
+
+// DefaultClockJumpThreshold is a threshold for WithClockJumpThreshold
+// detecting the steps of NTP while ignoring its slewing.
+const DefaultClockJumpThreshold = time.Second
+
+// WithClockJumpThreshold enables the detection of wall clock jumps, such
+// as NTP steps, detecting a difference of d between the wall clock and
+// the monotonic clock as a jump. The detection is disabled by default,
+// and by a d of 0 or less, since handling a jump walks all the entries.
+//
+// Expire times read from the system clock carry a monotonic reading, so
+// expiration is not affected by wall clock jumps. Their wall clock part
+// is however what Dump and SaveTo persist, so on a jump the expire times
+// of the entries are rebased on the current wall clock, keeping their
+// remaining time to live, and counted in Stats.ClockJumpEntries.
+func WithClockJumpThreshold(d time.Duration) Option {
+	return func(c *LRU) {
+		c.jumpThreshold = d
+	}
+}
+
+// monotonic returns if t carries a monotonic clock reading
+func monotonic(t time.Time) bool {
+	return t != t.Round(0)
+}
+
+// checkClock detects a jump of the wall clock since the first call, by
+// comparing the wall clock and monotonic time elapsed until now, and
+// rebases the expire times of the entries if so. Virtual times without a
+// monotonic reading are ignored.
+func (c *LRU) checkClock(now time.Time) {
+	threshold := c.jumpThreshold
+	if threshold <= 0 || !monotonic(now) {
+		return
+	}
+	if c.clockRef.IsZero() {
+		c.clockRef, c.clockWall = now, now.UnixNano()
+		return
+	}
+	skew := time.Duration(now.UnixNano()-c.clockWall) - now.Sub(c.clockRef)
+	jump := skew - c.clockSkew
+	if jump > -threshold && jump < threshold {
+		return
+	}
+	c.clockSkew = skew
+	c.stats.ClockJumps++
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		kv := ent.Value.(*entry)
+		if kv.expire == nil || !monotonic(*kv.expire) {
+			continue
+		}
+		// Sub uses the monotonic readings, and Add keeps both readings
+		// of now, correcting the wall clock part only
+		ex := now.Add(kv.expire.Sub(now))
+		kv.expire = &ex
+		c.stats.ClockJumpEntries++
+	}
+}
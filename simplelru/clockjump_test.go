@@ -0,0 +1,86 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_ClockJump(t *testing.T) {
+	l, err := NewLRUWithOptions(8, nil, WithClockJumpThreshold(DefaultClockJumpThreshold))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.AddEx(1, 1, time.Hour)
+	l.Add(2, 2)
+	l.AddEx(3, 3, time.Hour)
+	before := *l.items[1].Value.(*entry).expire
+
+	// A small drift is not a jump
+	l.clockWall -= int64(100 * time.Millisecond)
+	l.DeleteExpired()
+	if s := l.Stats(); s.ClockJumps != 0 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	// Step the wall clock back an hour, as seen from the reference
+	l.clockWall += int64(time.Hour)
+	if n := l.DeleteExpired(); n != 0 {
+		t.Fatalf("bad: %d", n)
+	}
+	if s := l.Stats(); s.ClockJumps != 1 || s.ClockJumpEntries != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	after := *l.items[1].Value.(*entry).expire
+	if !monotonic(after) || after.Sub(before) != 0 {
+		t.Fatalf("bad expire: %v %v", before, after)
+	}
+	if _, ok := l.Get(1); !ok {
+		t.Fatalf("should not be expired")
+	}
+
+	// The skew is now the baseline
+	l.DeleteExpired()
+	if s := l.Stats(); s.ClockJumps != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
+
+func TestLRU_ClockJumpDisabled(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithClockJumpThreshold(-1)}} {
+		l, err := NewLRUWithOptions(8, nil, opts...)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		l.AddEx(1, 1, time.Hour)
+		l.clockWall += int64(time.Hour)
+		l.DeleteExpired()
+		if s := l.Stats(); s.ClockJumps != 0 || s.ClockJumpEntries != 0 {
+			t.Fatalf("bad stats: %+v", s)
+		}
+	}
+}
+
+func TestLRU_LoadRebasesExpire(t *testing.T) {
+	l, err := NewLRU(8, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	wall := time.Now().Round(0).Add(time.Hour)
+	l.Load([]Record{{Key: 1, Value: 1, Expire: &wall}})
+	ex := *l.items[1].Value.(*entry).expire
+	if !monotonic(ex) || !ex.Equal(wall) {
+		t.Fatalf("bad expire: %v", ex)
+	}
+
+	// Virtual times are left alone
+	m := NewManualClock(time.Unix(0, 0))
+	l, err = NewLRUWithOptions(8, nil, WithClock(m))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	virtual := time.Unix(60, 0)
+	l.Load([]Record{{Key: 1, Value: 1, Expire: &virtual}})
+	if ex := *l.items[1].Value.(*entry).expire; monotonic(ex) || !ex.Equal(virtual) {
+		t.Fatalf("bad expire: %v", ex)
+	}
+}
@@ -0,0 +1,75 @@
+package simplelru
+
+import (
+	"errors"
+	"math"
+)
+
+// NewLRUWithCost constructs an LRU bounded by the total cost of its
+// entries, such as their size in bytes, rather than by their number.
+// costFn returns the cost of the entries added without an explicit cost.
+func NewLRUWithCost(maxCost int64, costFn func(key, value interface{}) int64, onEvict EvictCallback) (*LRU, error) {
+	if maxCost <= 0 {
+		return nil, errors.New("Must provide a positive cost")
+	}
+	return NewLRUWithOptions(math.MaxInt, onEvict, WithMaxCost(maxCost, costFn))
+}
+
+// WithMaxCost bounds the total cost of the entries in addition to their
+// number. costFn returns the cost of the entries added without an
+// explicit cost; if it is nil they cost 1.
+func WithMaxCost(maxCost int64, costFn func(key, value interface{}) int64) Option {
+	return func(c *LRU) {
+		c.maxCost = maxCost
+		c.costFn = costFn
+	}
+}
+
+// AddWithCost adds a value to the cache with the given cost, evicting
+// entries until the total cost fits the budget. An entry costing more
+// than the whole budget is not added, and does not replace the value of
+// a key already in the cache. Returns true if an eviction occurred.
+func (c *LRU) AddWithCost(key, value interface{}, cost int64) bool {
+	return c.add(key, value, 0, addOptions{priority: PriorityNormal, cost: cost})
+}
+
+// Cost returns the total cost of the entries in the cache.
+func (c *LRU) Cost() int64 {
+	return c.cost
+}
+
+// SetMaxCost changes the cost budget, evicting entries until their total
+// cost fits it. Returns the number of evicted entries.
+func (c *LRU) SetMaxCost(maxCost int64) (evicted int) {
+	c.maxCost = maxCost
+	n := c.evictList.Len()
+	c.fitCost(0)
+	return n - c.evictList.Len()
+}
+
+// MaxCost returns the cost budget of the cache, or 0 if it is only
+// bounded by the number of entries.
+func (c *LRU) MaxCost() int64 {
+	return c.maxCost
+}
+
+// costOf returns the cost of an entry added without an explicit cost.
+func (c *LRU) costOf(key, value interface{}) int64 {
+	if c.costFn == nil {
+		return 1
+	}
+	return c.costFn(key, value)
+}
+
+// fitCost evicts entries until cost more fits the budget, returning
+// true if an eviction occurred.
+func (c *LRU) fitCost(cost int64) bool {
+	if c.batch {
+		return false
+	}
+	evict := false
+	for c.maxCost > 0 && c.cost+cost > c.maxCost && c.removeVictim() {
+		evict = true
+	}
+	return evict
+}
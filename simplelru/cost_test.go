@@ -0,0 +1,103 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_Cost(t *testing.T) {
+	evicted := 0
+	l, err := NewLRUWithCost(10, func(k, v interface{}) int64 {
+		return int64(len(v.(string)))
+	}, func(k, v interface{}) { evicted++ })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := NewLRUWithCost(0, nil, nil); err == nil {
+		t.Fatalf("should reject zero cost")
+	}
+
+	l.Add(1, "aaaa")
+	l.Add(2, "bbbb")
+	if l.Cost() != 8 || evicted != 0 {
+		t.Fatalf("bad cost: %v", l.Cost())
+	}
+	if !l.Add(3, "cccc") || l.Contains(1) || l.Cost() != 8 {
+		t.Fatalf("should evict from the tail: %v", l.Keys())
+	}
+
+	// Explicit costs override costFn
+	if !l.AddWithCost(4, "d", 5) || l.Contains(2) || l.Cost() != 9 {
+		t.Fatalf("bad explicit cost: %v %v", l.Keys(), l.Cost())
+	}
+
+	// Growing an entry evicts others
+	if !l.AddWithCost(4, "dd", 8) || l.Len() != 1 || l.Cost() != 8 {
+		t.Fatalf("bad update: %v %v", l.Keys(), l.Cost())
+	}
+
+	// An entry larger than the budget is not added
+	if l.AddWithCost(5, "e", 11) || l.Contains(5) || !l.Contains(4) {
+		t.Fatalf("oversized entry should be rejected")
+	}
+	if l.AddWithCost(4, "huge", 11) || l.Cost() != 8 {
+		t.Fatalf("oversized update should be rejected: %v", l.Cost())
+	}
+	if v, ok := l.Peek(4); !ok || v != "dd" {
+		t.Fatalf("oversized update should keep the old value: %v %v", v, ok)
+	}
+
+	// An oversized new entry evicts nothing
+	o, _ := NewLRUWithOptions(2, nil, WithMaxCost(10, nil))
+	o.AddWithCost(1, 1, 1)
+	o.AddWithCost(2, 2, 1)
+	if o.AddWithCost(3, 3, 100) || o.Len() != 2 || !o.Contains(1) || o.Contains(3) {
+		t.Fatalf("oversized entry should not evict: %v", o.Keys())
+	}
+
+	// The updated entry is never the victim making room for itself
+	m, _ := NewLRUWithOptions(10, nil, WithMaxCost(10, nil), WithEvictionPolicy(EvictMRU))
+	m.AddWithCost("a", 1, 4)
+	m.AddWithCost("b", 2, 4)
+	if !m.AddWithCost("b", 3, 8) || m.Contains("a") || m.Cost() != 8 {
+		t.Fatalf("bad update: %v %v", m.Keys(), m.Cost())
+	}
+	if v, ok := m.Peek("b"); !ok || v != 3 {
+		t.Fatalf("update should be kept: %v %v", v, ok)
+	}
+
+	l.Remove(4)
+	if l.Cost() != 0 {
+		t.Fatalf("bad cost after remove: %v", l.Cost())
+	}
+	l.Add(6, "ff")
+	l.Purge()
+	if l.Cost() != 0 || l.MaxCost() != 10 {
+		t.Fatalf("bad cost after purge: %v", l.Cost())
+	}
+}
+
+func TestLRU_CostWithSize(t *testing.T) {
+	l, _ := NewLRUWithOptions(2, nil, WithMaxCost(100, nil))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Add(3, 3) || l.Len() != 2 || l.Cost() != 2 {
+		t.Fatalf("both bounds should apply: %v %v", l.Len(), l.Cost())
+	}
+	if !l.AddWithCost(4, 4, 99) || l.Len() != 2 || l.Cost() != 100 {
+		t.Fatalf("bad cost: %v %v", l.Len(), l.Cost())
+	}
+	c := l.Clone()
+	if c.Cost() != 100 || c.MaxCost() != 100 {
+		t.Fatalf("bad clone cost: %v", c.Cost())
+	}
+}
+
+func TestLRU_SetMaxCost(t *testing.T) {
+	l, _ := NewLRUWithCost(10, nil, nil)
+	for i := 0; i < 10; i++ {
+		l.Add(i, i)
+	}
+	if n := l.SetMaxCost(4); n != 6 || l.Cost() != 4 || l.MaxCost() != 4 || !l.Contains(9) {
+		t.Fatalf("bad shrink: %v %v", n, l.Keys())
+	}
+}
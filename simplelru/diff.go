@@ -0,0 +1,53 @@
+package simplelru
+
+import (
+	"reflect"
+)
+
+// Difference reports how the live entries of two caches differ. Keys
+// are listed from oldest to newest in the cache they were found in.
+type Difference struct {
+	OnlyInA []interface{} // OnlyInA holds the keys missing from b
+	OnlyInB []interface{} // OnlyInB holds the keys missing from a
+
+	// ValueMismatch holds the keys whose values are not deeply equal
+	ValueMismatch []interface{}
+
+	// ExpireMismatch holds the keys whose expire times differ
+	ExpireMismatch []interface{}
+}
+
+// Equal returns true if no difference was found.
+func (d Difference) Equal() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0 &&
+		len(d.ValueMismatch) == 0 && len(d.ExpireMismatch) == 0
+}
+
+// Diff compares the live entries of a and b without updating their
+// recent-ness. Values are compared with reflect.DeepEqual.
+func Diff(a, b *LRU) Difference {
+	var d Difference
+	for _, key := range a.Keys() {
+		va, ea, ok := a.PeekWithExpireTime(key)
+		if !ok {
+			continue
+		}
+		vb, eb, ok := b.PeekWithExpireTime(key)
+		if !ok {
+			d.OnlyInA = append(d.OnlyInA, key)
+			continue
+		}
+		if !reflect.DeepEqual(va, vb) {
+			d.ValueMismatch = append(d.ValueMismatch, key)
+		}
+		if (ea == nil) != (eb == nil) || (ea != nil && !ea.Equal(*eb)) {
+			d.ExpireMismatch = append(d.ExpireMismatch, key)
+		}
+	}
+	for _, key := range b.Keys() {
+		if b.Contains(key) && !a.Contains(key) {
+			d.OnlyInB = append(d.OnlyInB, key)
+		}
+	}
+	return d
+}
@@ -0,0 +1,41 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	a, _ := NewLRU(8, nil)
+	a.Add(1, 1)
+	a.Add(2, []int{2})
+	a.AddEx(3, 3, time.Minute)
+	a.Add(4, 4)
+
+	b := a.Clone()
+	if d := Diff(a, b); !d.Equal() {
+		t.Fatalf("clone should be equal: %+v", d)
+	}
+
+	b.Remove(1)
+	b.Add(2, []int{20})
+	b.AddEx(3, 3, time.Hour)
+	b.Add(5, 5)
+
+	d := Diff(a, b)
+	if d.Equal() {
+		t.Fatalf("should differ")
+	}
+	if len(d.OnlyInA) != 1 || d.OnlyInA[0] != 1 {
+		t.Fatalf("bad only in a: %v", d.OnlyInA)
+	}
+	if len(d.OnlyInB) != 1 || d.OnlyInB[0] != 5 {
+		t.Fatalf("bad only in b: %v", d.OnlyInB)
+	}
+	if len(d.ValueMismatch) != 1 || d.ValueMismatch[0] != 2 {
+		t.Fatalf("bad value mismatch: %v", d.ValueMismatch)
+	}
+	if len(d.ExpireMismatch) != 1 || d.ExpireMismatch[0] != 3 {
+		t.Fatalf("bad expire mismatch: %v", d.ExpireMismatch)
+	}
+}
@@ -0,0 +1,128 @@
+package simplelru_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func ExampleLRU() {
+	l, _ := simplelru.NewLRU(2, nil)
+	l.Add("a", 1)
+	l.Add("b", 2)
+	l.Get("a")
+	l.Add("c", 3)
+
+	fmt.Println(l.Keys())
+	// Output: [a c]
+}
+
+func ExampleLRU_AddEx() {
+	l, _ := simplelru.NewLRUWithExpire(2, time.Hour, nil)
+	l.Add("default", 1)
+	l.AddEx("short", 2, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := l.Get("short")
+	fmt.Println("short:", ok)
+	_, ok = l.Get("default")
+	fmt.Println("default:", ok)
+
+	// Expired entries are hidden but keep their slot until evicted
+	fmt.Println("len:", l.Len())
+	// Output:
+	// short: false
+	// default: true
+	// len: 2
+}
+
+func ExampleEvictCallback() {
+	l, _ := simplelru.NewLRU(2, func(key, value interface{}) {
+		fmt.Println("evicted", key)
+	})
+	l.Add(1, 1)
+	l.Add(2, 2)
+
+	// Updating a key does not evict it
+	l.Add(1, 10)
+
+	// Capacity evictions, removals and purges all invoke the callback
+	l.Add(3, 3)
+	l.Remove(1)
+	l.Purge()
+	// Output:
+	// evicted 2
+	// evicted 1
+	// evicted 3
+}
+
+func ExampleLRU_Resize() {
+	l, _ := simplelru.NewLRU(3, func(key, value interface{}) {
+		fmt.Println("evicted", key)
+	})
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+
+	fmt.Println("shrink evicted", l.Resize(1))
+	fmt.Println("grow evicted", l.Resize(2))
+	l.Add(4, 4)
+	fmt.Println(l.Keys())
+	// Output:
+	// evicted 1
+	// evicted 2
+	// shrink evicted 2
+	// grow evicted 0
+	// [3 4]
+}
+
+func ExampleWithEvictionOrder() {
+	for _, order := range []simplelru.EvictionOrder{simplelru.AccessOrder, simplelru.InsertionOrder} {
+		l, _ := simplelru.NewLRUWithOptions(2, nil, simplelru.WithEvictionOrder(order))
+		l.Add("a", 1)
+		l.Add("b", 2)
+		l.Get("a")
+		l.Add("c", 3)
+		fmt.Println(l.Keys())
+	}
+	// Output:
+	// [a c]
+	// [b c]
+}
+
+func ExampleWithEvictionPolicy() {
+	for _, policy := range []simplelru.EvictionPolicy{simplelru.EvictLRU, simplelru.EvictMRU} {
+		l, _ := simplelru.NewLRUWithOptions(2, nil, simplelru.WithEvictionPolicy(policy))
+		l.Add("a", 1)
+		l.Add("b", 2)
+		l.Add("c", 3)
+		fmt.Println(l.Keys())
+	}
+	// Output:
+	// [b c]
+	// [a c]
+}
+
+func ExampleLRU_AddWithPriority() {
+	l, _ := simplelru.NewLRU(2, nil)
+	l.AddWithPriority("expensive", 1, simplelru.PriorityHigh)
+	l.Add("b", 2)
+	l.Add("c", 3)
+	l.Add("d", 4)
+	fmt.Println(l.Keys())
+	// Output: [expensive d]
+}
+
+func ExampleLRU_Peek() {
+	l, _ := simplelru.NewLRU(2, nil)
+	l.Add("a", 1)
+	l.Add("b", 2)
+
+	// Peek and Contains do not update recent-ness, so a is still evicted
+	l.Peek("a")
+	l.Contains("a")
+	l.Add("c", 3)
+	fmt.Println(l.Keys())
+	// Output: [b c]
+}
@@ -0,0 +1,47 @@
+package simplelru
+
+// generationPolicy is the built-in policy of GenerationOrder, a CLOCK
+// whose hand turns from the oldest entry to the newest. It evicts the
+// first candidate whose counter is zero, halving the counters it passes
+// over, so a saturated counter reaches zero within eight turns. The hand
+// is kept in the LRU, so the next eviction resumes where this one stopped.
+type generationPolicy struct {
+	builtinHooks
+	c *LRU
+}
+
+// Victim implements Policy.
+func (p generationPolicy) Victim(evictable func(key interface{}) bool) (interface{}, bool) {
+	return builtinVictim(p, evictable)
+}
+
+func (p generationPolicy) victim(priority Priority, candidate func(*Element) bool) *Element {
+	c := p.c
+	start := c.hand
+	if start == nil {
+		start = c.evictList.Back()
+	}
+	aged := false
+	for ent := start; ent != nil; {
+		if candidate(ent) {
+			kv := ent.Value.(*entry)
+			if kv.gen == 0 {
+				c.hand = c.evictList.Prev(ent)
+				return ent
+			}
+			kv.gen >>= 1
+			aged = true
+		}
+		if ent = c.evictList.Prev(ent); ent == nil {
+			ent = c.evictList.Back()
+		}
+		if ent == start {
+			// A turn without a candidate to age finds none
+			if !aged {
+				return nil
+			}
+			aged = false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_GenerationOrder(t *testing.T) {
+	l, _ := NewLRUWithOptions(3, nil, WithEvictionOrder(GenerationOrder))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+
+	// Accesses do not reorder the list
+	l.Get(1)
+	l.Get(1)
+	l.Get(2)
+	if k, _, _ := l.GetOldest(); k != 1 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+
+	// 3 has never been accessed
+	l.Add(4, 4)
+	if l.Contains(3) || !l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("bad eviction: %v", l.Keys())
+	}
+
+	// 4 is new, the counters of 1 and 2 are aged until 2 reaches zero
+	l.Get(4)
+	l.Add(5, 5)
+	if l.Contains(2) || !l.Contains(1) {
+		t.Fatalf("bad eviction: %v", l.Keys())
+	}
+}
+
+func TestLRU_GenerationOrderSaturated(t *testing.T) {
+	l, _ := NewLRUWithOptions(2, nil, WithEvictionOrder(GenerationOrder))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	for i := 0; i < 1000; i++ {
+		l.Get(1)
+		l.Get(2)
+	}
+	if !l.Add(3, 3) || l.Contains(1) || l.Len() != 2 {
+		t.Fatalf("bad eviction: %v", l.Keys())
+	}
+
+	l.Pin(2)
+	l.Pin(3)
+	if l.Add(4, 4) || l.Len() != 3 {
+		t.Fatalf("pinned entries should not be evicted: %v", l.Keys())
+	}
+}
+
+func TestLRU_GenerationOrderHand(t *testing.T) {
+	l, _ := NewLRUWithOptions(3, nil, WithEvictionOrder(GenerationOrder))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+
+	// The hand ages 1 and evicts 2, stopping at 3
+	l.Add(4, 4)
+	if l.Contains(2) {
+		t.Fatalf("bad eviction: %v", l.Keys())
+	}
+	// The next eviction resumes at 3 rather than restarting at 1
+	l.Add(5, 5)
+	if l.Contains(3) || !l.Contains(1) {
+		t.Fatalf("bad eviction: %v", l.Keys())
+	}
+	// Removing the entry under the hand moves it along
+	l.Remove(4)
+	l.Add(6, 6)
+	l.Add(7, 7)
+	if l.Len() != 3 || l.Contains(5) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
@@ -0,0 +1,84 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// Handle refers to an entry of an LRU, letting hot loops operate on the
+// same entry repeatedly without hashing its key again. A handle goes
+// stale once its entry leaves the cache; its methods then report false.
+type Handle struct {
+	c   *LRU
+	ent *Element
+	key interface{}
+
+	// epoch and version are those of the cache and the entry when the
+	// handle was taken
+	epoch   uint64
+	version uint64
+}
+
+// GetHandle looks up a key like Get, updating its recent-ness, and
+// returns a handle to its entry.
+func (c *LRU) GetHandle(key interface{}) (Handle, bool) {
+	ent, ok := c.lookup(key)
+	if !ok {
+		return Handle{}, false
+	}
+	c.touch(ent)
+	return Handle{c: c, ent: ent, key: key, epoch: c.epoch, version: ent.Value.(*entry).version}, true
+}
+
+// Valid returns if the entry is still in the cache and not expired.
+func (h Handle) Valid() bool {
+	return h.resident() && !h.c.expired(h.ent.Value.(*entry))
+}
+
+// resident returns if the entry of the handle is still in the cache,
+// without looking its key up: Purge bumps the epoch of the cache, and
+// removing the entry bumps its version
+func (h Handle) resident() bool {
+	return h.c != nil && h.c.epoch == h.epoch && h.ent.Value.(*entry).version == h.version
+}
+
+// Key returns the key of the entry.
+func (h Handle) Key() interface{} {
+	return h.key
+}
+
+// Value returns the value of the entry without updating its recent-ness.
+func (h Handle) Value() (interface{}, bool) {
+	if !h.Valid() {
+		return nil, false
+	}
+	return h.ent.Value.(*entry).load(), true
+}
+
+// Touch marks the entry as recently used.
+func (h Handle) Touch() bool {
+	if !h.Valid() {
+		return false
+	}
+	h.c.touch(h.ent)
+	return true
+}
+
+// UpdateExpire sets the entry to expire after the given duration, clamped
+// to the TTL bounds of the cache. A duration <= 0 applies the default
+// expire of the cache.
+func (h Handle) UpdateExpire(expire time.Duration) bool {
+	if !h.Valid() {
+		return false
+	}
+	h.c.setExpire(h.ent.Value.(*entry), expire)
+	return true
+}
+
+// Remove removes the entry from the cache.
+func (h Handle) Remove() bool {
+	if !h.resident() {
+		return false
+	}
+	h.c.removeElement(h.ent, EvictRemoved)
+	return true
+}
@@ -0,0 +1,105 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_Handle(t *testing.T) {
+	l, err := NewLRU(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, ok := l.GetHandle(1); ok {
+		t.Fatalf("should not be found")
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+
+	h, ok := l.GetHandle(1)
+	if !ok || h.Key() != 1 {
+		t.Fatalf("should be found")
+	}
+	if v, ok := h.Value(); !ok || v != 1 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+
+	l.Add(3, 3)
+	if !l.Contains(1) {
+		t.Fatalf("GetHandle should promote")
+	}
+	l.Add(4, 4)
+	if l.Contains(1) {
+		t.Fatalf("1 should be evicted")
+	}
+
+	// The element is recycled for key 4 but the handle must not see it
+	if h.Valid() || h.Touch() || h.Remove() {
+		t.Fatalf("handle should be stale")
+	}
+	if _, ok := h.Value(); ok {
+		t.Fatalf("handle should be stale")
+	}
+	var zero Handle
+	if zero.Valid() || zero.Remove() {
+		t.Fatalf("zero handle should be stale")
+	}
+}
+
+func TestLRU_HandleOps(t *testing.T) {
+	l, _ := NewLRU(2, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	h, _ := l.GetHandle(1)
+
+	l.Get(2)
+	if !h.Touch() {
+		t.Fatalf("should touch")
+	}
+	l.Add(3, 3)
+	if !l.Contains(1) || l.Contains(2) {
+		t.Fatalf("Touch should promote")
+	}
+
+	if !h.UpdateExpire(time.Nanosecond) {
+		t.Fatalf("should update")
+	}
+	time.Sleep(time.Millisecond)
+	if h.Valid() || l.Contains(1) {
+		t.Fatalf("1 should be expired")
+	}
+
+	h, _ = l.GetHandle(3)
+	if !h.Remove() || l.Contains(3) || h.Remove() {
+		t.Fatalf("bad remove")
+	}
+}
+
+func TestLRU_HandlePurge(t *testing.T) {
+	l, _ := NewLRUWithOptions(4, nil, WithEvictionPolicy(EvictRandom))
+	l.Add(1, 1)
+	h, _ := l.GetHandle(1)
+	l.Purge()
+	if h.Valid() || h.Remove() {
+		t.Fatalf("handle should be stale after Purge")
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestLRU_HandleReuse(t *testing.T) {
+	l, _ := NewLRU(1, nil)
+	l.Add("k", 1)
+	h, _ := l.GetHandle("k")
+	l.Remove("k")
+	// The re-added key takes the same recycled element
+	l.Add("k", 2)
+	if v, ok := h.Value(); ok || h.Valid() || h.Remove() {
+		t.Fatalf("handle should be stale after re-add: %v, %v", v, ok)
+	}
+	if v, ok := l.Peek("k"); !ok || v != 2 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+}
@@ -0,0 +1,102 @@
+package simplelru
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// reportHotKeys is the number of hot keys summarized by Health
+const reportHotKeys = 10
+
+// reportMinGain is the share of the lookups that growing the cache must
+// turn into hits to be recommended by Health
+const reportMinGain = 0.01
+
+// HotKey is a key and the number of lookups it was hit by.
+type HotKey struct {
+	Key  interface{}
+	Hits uint32
+}
+
+// MarshalJSON encodes the key in its default format, as keys are not
+// necessarily encodable.
+func (k HotKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key  string `json:"key"`
+		Hits uint32 `json:"hits"`
+	}{fmt.Sprint(k.Key), k.Hits})
+}
+
+// HotKeys returns the n live keys hit the most since they were added,
+// the hottest first.
+func (c *LRU) HotKeys(n int) []HotKey {
+	var keys []HotKey
+	for ent := c.evictList.Front(); ent != nil; ent = c.evictList.Next(ent) {
+		kv := ent.Value.(*entry)
+		if kv.hits > 0 && !c.expired(kv) {
+			keys = append(keys, HotKey{Key: kv.key, Hits: kv.hits})
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Hits > keys[j].Hits
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// Health summarizes the effectiveness of a cache, see LRU.Health.
+type Health struct {
+	Name string `json:"name,omitempty"`
+	Len  int    `json:"len"`
+	Cap  int    `json:"cap"`
+
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+
+	// Evicted is the number of live entries evicted to make room, and
+	// Churning the number of keys flagged by WithChurnTracking
+	Evicted  uint64 `json:"evicted"`
+	Churning int    `json:"churning"`
+
+	// Expired is the number of expired entries still resident, and
+	// ExpiredWaste the share of the capacity they occupy
+	Expired      int     `json:"expired"`
+	ExpiredWaste float64 `json:"expired_waste"`
+
+	HotKeys []HotKey `json:"hot_keys"`
+
+	// RecommendedSize is the size suggested by WithShadow, see
+	// RecommendedSize, or 0 without the shadow
+	RecommendedSize int `json:"recommended_size,omitempty"`
+}
+
+// Health returns a summary of the effectiveness of the cache since its
+// stats were last reset: its hit ratio, churn, the capacity wasted on
+// expired entries, its hot keys and, with WithShadow, the size it should
+// have to turn at least 1% more of the lookups into hits.
+func (c *LRU) Health() Health {
+	stats := c.Stats()
+	h := Health{
+		Name:     c.name,
+		Len:      c.Len(),
+		Cap:      c.size,
+		Hits:     stats.Hits,
+		Misses:   stats.Misses,
+		Evicted:  stats.Evicted,
+		Churning: stats.Churning,
+		Expired:  stats.Expired,
+		HotKeys:  c.HotKeys(reportHotKeys),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		h.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	if c.size > 0 {
+		h.ExpiredWaste = float64(stats.Expired) / float64(c.size)
+	}
+	h.RecommendedSize, _ = c.RecommendedSize(reportMinGain)
+	return h
+}
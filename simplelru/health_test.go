@@ -0,0 +1,80 @@
+package simplelru
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLRU_Shadow(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithShadow(32))
+	// A loop over 20 keys never hits a cache of 10 entries, but would hit
+	// one of 20
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 20; i++ {
+			if _, ok := l.Get(i); !ok {
+				l.Add(i, i)
+			}
+		}
+	}
+	if hits, _ := l.Lookups(); hits != 0 {
+		t.Fatalf("bad hits: %v", hits)
+	}
+	if hits := l.ShadowHits(6); hits != 0 {
+		t.Fatalf("a slightly larger cache should not hit: %v", hits)
+	}
+	if hits := l.ShadowHits(12); hits < 170 {
+		t.Fatalf("a cache of 22 entries should hit: %v", hits)
+	}
+	if size, ok := l.RecommendedSize(0.01); !ok || size < 20 || size > 22 {
+		t.Fatalf("bad recommended size: %v %v", size, ok)
+	}
+
+	l.ResetStats()
+	if _, ok := l.RecommendedSize(0.01); ok {
+		t.Fatalf("no size should be recommended without lookups")
+	}
+	l.Get(100)
+	if size, ok := l.RecommendedSize(0.01); !ok || size != 10 {
+		t.Fatalf("bad recommended size: %v %v", size, ok)
+	}
+
+	d, _ := NewLRU(10, nil)
+	if _, ok := d.RecommendedSize(0.01); ok || d.ShadowHits(10) != 0 {
+		t.Fatalf("shadow should be disabled")
+	}
+}
+
+func TestLRU_Health(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l, _ := NewLRUWithOptions(4, nil, WithClock(clock), WithName("sessions"), WithShadow(8))
+	l.Add("a", 1)
+	l.Add("b", 2)
+	l.AddEx("c", 3, time.Minute)
+	for i := 0; i < 3; i++ {
+		l.Get("a")
+	}
+	l.Get("b")
+	l.Get("missing")
+	clock.Advance(2 * time.Minute)
+
+	if keys := l.HotKeys(1); len(keys) != 1 || keys[0] != (HotKey{"a", 3}) {
+		t.Fatalf("bad hot keys: %v", keys)
+	}
+	h := l.Health()
+	if h.Name != "sessions" || h.Len != 3 || h.Cap != 4 || h.Hits != 4 || h.Misses != 1 || h.HitRatio != 0.8 {
+		t.Fatalf("bad health: %+v", h)
+	}
+	if h.Expired != 1 || h.ExpiredWaste != 0.25 || len(h.HotKeys) != 2 || h.RecommendedSize != 4 {
+		t.Fatalf("bad health: %+v", h)
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(string(data), `"hot_keys":[{"key":"a","hits":3},{"key":"b","hits":1}]`) {
+		t.Fatalf("bad json: %s", data)
+	}
+}
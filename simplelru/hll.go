@@ -0,0 +1,183 @@
+package simplelru
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"reflect"
+)
+
+// hllPrecision is the number of hash bits selecting a register, giving
+// 2^14 registers and a standard error of about 0.8%
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct keys it has seen
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+// WithCardinalityEstimate maintains a HyperLogLog sketch of every key
+// passed to Add or Get, so the number of distinct keys requested can be
+// compared to the capacity of the cache. The sketch takes 16KB.
+func WithCardinalityEstimate() Option {
+	return func(c *LRU) {
+		c.hll = &hyperLogLog{}
+	}
+}
+
+// KeyspaceEstimate returns the estimated number of distinct keys
+// requested from the cache, or 0 if the estimate is disabled.
+func (c *LRU) KeyspaceEstimate() uint64 {
+	if c.hll == nil {
+		return 0
+	}
+	return c.hll.estimate()
+}
+
+// observe adds a key to the sketch and reports it to the admission
+// policy if enabled
+func (c *LRU) observe(key interface{}) {
+	if c.hll != nil {
+		c.hll.add(HashKey(key))
+	}
+	if c.admission != nil {
+		c.admission.Record(key)
+	}
+}
+
+func (h *hyperLogLog) add(x uint64) {
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+func (h *hyperLogLog) estimate() uint64 {
+	const m = float64(1 << hllPrecision)
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// Small range correction
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(e + 0.5)
+}
+
+// hashSeed seeds HashKey for the life of the process
+var hashSeed = maphash.MakeSeed()
+
+// HashKey returns a 64 bit hash of a key, spread over all the bits so
+// that any subset of them can be used, e.g. to pick a shard. Hashes are
+// only stable within a process. Keys equal by == hash the same: keys are
+// hashed by value, except pointers and channels which are hashed by
+// address, never by what they point to. String and integer keys are
+// hashed without allocating, other keys without formatting them.
+func HashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return mixInt(uint64(k))
+	case int64:
+		return mixInt(uint64(k))
+	case int32:
+		return mixInt(uint64(k))
+	case uint:
+		return mixInt(uint64(k))
+	case uint64:
+		return mixInt(k)
+	case uint32:
+		return mixInt(uint64(k))
+	case nil:
+		return mixInt(0)
+	}
+	return hashValue(reflect.ValueOf(key))
+}
+
+// hashString hashes a string key
+func hashString(s string) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	h.WriteString(s)
+	return h.Sum64()
+}
+
+// hashValue hashes a comparable value the way == compares it, reading
+// unexported fields too. Values of kinds that cannot be map keys hash
+// to 0.
+func hashValue(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return mixInt(1)
+		}
+		return mixInt(0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return mixInt(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return mixInt(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return hashFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return mix64(hashFloat(real(c)) ^ hashFloat(imag(c))*31)
+	case reflect.String:
+		return hashString(v.String())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return mixInt(uint64(v.Pointer()))
+	case reflect.Interface:
+		if v.IsNil() {
+			return mixInt(0)
+		}
+		return hashValue(v.Elem())
+	case reflect.Array:
+		h := mixInt(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			h = mix64(h*31 + hashValue(v.Index(i)))
+		}
+		return h
+	case reflect.Struct:
+		h := mixInt(uint64(v.NumField()))
+		for i := 0; i < v.NumField(); i++ {
+			// == ignores blank fields
+			if v.Type().Field(i).Name != "_" {
+				h = mix64(h*31 + hashValue(v.Field(i)))
+			}
+		}
+		return h
+	}
+	return 0
+}
+
+// hashFloat hashes a float key, 0 and -0 being equal
+func hashFloat(f float64) uint64 {
+	if f == 0 {
+		return mixInt(0)
+	}
+	return mixInt(math.Float64bits(f))
+}
+
+// mixInt hashes an integer key, offset by the splitmix64 increment so
+// that 0 does not hash to 0
+func mixInt(x uint64) uint64 {
+	return mix64(x + 0x9e3779b97f4a7c15)
+}
+
+// mix64 is the splitmix64 finalizer, spreading its input over all bits
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,70 @@
+package simplelru
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLRU_KeyspaceEstimate(t *testing.T) {
+	l, err := NewLRUWithOptions(128, nil, WithCardinalityEstimate())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 50000; i++ {
+		l.Add(i, i)
+		l.Get(i % 1000)
+	}
+	for i := 0; i < 1000; i++ {
+		l.Get(i)
+	}
+
+	est := l.KeyspaceEstimate()
+	if est < 48500 || est > 51500 {
+		t.Fatalf("bad estimate: %v", est)
+	}
+}
+
+func TestLRU_KeyspaceEstimateSmall(t *testing.T) {
+	l, _ := NewLRUWithOptions(8, nil, WithCardinalityEstimate())
+	for i := 0; i < 10; i++ {
+		l.Get("key" + string(rune('a'+i)))
+	}
+	if est := l.KeyspaceEstimate(); est != 10 {
+		t.Fatalf("bad estimate: %v", est)
+	}
+
+	d, _ := NewLRU(8, nil)
+	d.Add(1, 1)
+	if est := d.KeyspaceEstimate(); est != 0 {
+		t.Fatalf("bad estimate: %v", est)
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	type key struct {
+		a int
+		_ int
+		s string
+		f float64
+		i interface{}
+	}
+	x, y := 1, 1
+	for _, c := range []struct{ a, b interface{} }{
+		{key{a: 1, s: "s", f: 0, i: 2}, key{a: 1, s: "s", f: math.Copysign(0, -1), i: 2}},
+		{[2]string{"a", "b"}, [2]string{"a", "b"}},
+		{&x, &x},
+		{int8(3), int8(3)},
+		{true, true},
+	} {
+		if c.a != c.b || HashKey(c.a) != HashKey(c.b) {
+			t.Fatalf("equal keys should hash the same: %v %v", c.a, c.b)
+		}
+	}
+	if HashKey(&x) == HashKey(&y) {
+		t.Fatalf("pointers should be hashed by address")
+	}
+	if HashKey(key{a: 1}) == HashKey(key{a: 2}) {
+		t.Fatalf("struct keys should be hashed by value")
+	}
+}
@@ -0,0 +1,50 @@
+package simplelru
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrImmutable is returned when adding a key held by an immutable
+	// entry.
+	ErrImmutable = errors.New("key is immutable")
+
+	// ErrNotAdded is returned when the cache declines to add a value:
+	// the key is tombstoned, the admission policy rejects it or its cost
+	// exceeds the whole budget.
+	ErrNotAdded = errors.New("value not added")
+)
+
+// AddImmutable adds a write-once value to the cache with expire. Until
+// it is removed, evicted or expires, the entry cannot be overwritten:
+// AddImmutable returns ErrImmutable and Add leaves it untouched. This
+// protects content-addressed data from accidental cache poisoning.
+// Returns true if an eviction occurred, and ErrNotAdded if the cache
+// declined the value.
+func (c *LRU) AddImmutable(key, value interface{}, expire time.Duration) (bool, error) {
+	if ent, ok := c.find(key); ok {
+		if kv := ent.Value.(*entry); kv.immutable && !c.expired(kv) {
+			return false, ErrImmutable
+		}
+	}
+	evict, stored := c.store(key, value, expire, addOptions{
+		priority:  PriorityNormal,
+		cost:      c.costOf(key, value),
+		immutable: true,
+	})
+	if !stored {
+		return evict, ErrNotAdded
+	}
+	return evict, nil
+}
+
+// IsImmutable returns if the key is held by a live immutable entry.
+func (c *LRU) IsImmutable(key interface{}) bool {
+	ent, ok := c.find(key)
+	if !ok {
+		return false
+	}
+	kv := ent.Value.(*entry)
+	return kv.immutable && !c.expired(kv)
+}
@@ -0,0 +1,95 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_AddImmutable(t *testing.T) {
+	l, err := NewLRU(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	if _, err := l.AddImmutable(1, 10, 0); err != nil {
+		t.Fatalf("mutable entries can be made immutable: %v", err)
+	}
+	if !l.IsImmutable(1) {
+		t.Fatalf("1 should be immutable")
+	}
+	if _, err := l.AddImmutable(1, 20, 0); err != ErrImmutable {
+		t.Fatalf("bad err: %v", err)
+	}
+	l.Add(1, 30)
+	if v, _ := l.Peek(1); v != 10 {
+		t.Fatalf("immutable value was overwritten: %v", v)
+	}
+
+	// Removal lifts the protection
+	l.Remove(1)
+	l.Add(1, 40)
+	if v, _ := l.Peek(1); v != 40 || l.IsImmutable(1) {
+		t.Fatalf("bad: %v", v)
+	}
+}
+
+func TestLRU_AddImmutableExpired(t *testing.T) {
+	l, _ := NewLRU(2, nil)
+	l.AddImmutable(1, 1, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if l.IsImmutable(1) {
+		t.Fatalf("expired entries are not immutable")
+	}
+	l.Add(1, 2)
+	if v, ok := l.Get(1); !ok || v != 2 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+
+	// Recycled entries do not inherit the flag
+	l.AddImmutable(2, 2, 0)
+	l.Add(3, 3)
+	l.Add(4, 4)
+	l.Add(2, 20)
+	if v, _ := l.Peek(2); v != 20 {
+		t.Fatalf("bad: %v", v)
+	}
+}
+
+func TestLRU_AddImmutableRejected(t *testing.T) {
+	l, _ := NewLRUWithOptions(2, nil, WithTombstones(time.Hour, nil))
+	l.Add(1, 1)
+	l.Remove(1)
+	if _, err := l.AddImmutable(1, 1, 0); err != ErrNotAdded || l.Contains(1) {
+		t.Fatalf("tombstoned key should be rejected: %v", err)
+	}
+
+	l, _ = NewLRUWithOptions(1, nil, WithAdmissionPolicy(tinyLFU))
+	for i := 0; i < 4; i++ {
+		l.Add(1, 1)
+		l.Get(1)
+	}
+	if _, err := l.AddImmutable(2, 2, 0); err != ErrNotAdded || l.Contains(2) {
+		t.Fatalf("key declined by admission should be rejected: %v", err)
+	}
+
+	l, _ = NewLRUWithOptions(4, nil, WithMaxCost(10, func(key, value interface{}) int64 {
+		return int64(value.(int))
+	}))
+	if _, err := l.AddImmutable(3, 11, 0); err != ErrNotAdded || l.Contains(3) {
+		t.Fatalf("value over the budget should be rejected: %v", err)
+	}
+	if _, err := l.AddImmutable(3, 5, 0); err != nil || !l.IsImmutable(3) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An oversize update of a mutable key keeps the mutable value
+	l.Add(4, 2)
+	if _, err := l.AddImmutable(4, 11, 0); err != ErrNotAdded || l.IsImmutable(4) {
+		t.Fatalf("oversize update should be rejected: %v", err)
+	}
+	if v, ok := l.Peek(4); !ok || v != 2 {
+		t.Fatalf("bad: %v %v", v, ok)
+	}
+}
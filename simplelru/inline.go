@@ -0,0 +1,128 @@
+package simplelru
+
+import "math"
+
+// valueKind is the type of a value stored inline in its entry
+type valueKind uint8
+
+const (
+	// boxed values are held by the value interface of the entry
+	boxed valueKind = iota
+	inlineInt
+	inlineInt64
+	inlineUint64
+	inlineFloat64
+	inlineBool
+)
+
+// WithInlineValues stores the int, int64, uint64, float64 and bool values
+// in the entry itself rather than behind the interface holding them, so
+// that the cache holds no separate allocation for them and the collector
+// has one pointer less to scan per entry. This pays off for counter-style
+// caches with many entries. Get and the other methods returning an
+// interface box the value again on each call, so the values are best
+// read with GetInt64 and PeekInt64, which never allocate. Inline values
+// are not interned, see WithValueInterning.
+func WithInlineValues() Option {
+	return func(c *LRU) {
+		c.inline = true
+	}
+}
+
+// inlineWord encodes the values WithInlineValues stores inline.
+func inlineWord(value interface{}) (uint64, valueKind, bool) {
+	switch v := value.(type) {
+	case int:
+		return uint64(v), inlineInt, true
+	case int64:
+		return uint64(v), inlineInt64, true
+	case uint64:
+		return v, inlineUint64, true
+	case float64:
+		return math.Float64bits(v), inlineFloat64, true
+	case bool:
+		if v {
+			return 1, inlineBool, true
+		}
+		return 0, inlineBool, true
+	}
+	return 0, boxed, false
+}
+
+// load returns the value of the entry, boxing it if stored inline.
+func (kv *entry) load() interface{} {
+	switch kv.kind {
+	case inlineInt:
+		return int(kv.word)
+	case inlineInt64:
+		return int64(kv.word)
+	case inlineUint64:
+		return kv.word
+	case inlineFloat64:
+		return math.Float64frombits(kv.word)
+	case inlineBool:
+		return kv.word != 0
+	}
+	return kv.value
+}
+
+// storeValue sets the value of an entry added with opts, which holds it
+// already encoded if it was added with AddInt64.
+func (c *LRU) storeValue(kv *entry, value interface{}, opts addOptions) {
+	if opts.kind == boxed {
+		c.setValue(kv, value)
+		return
+	}
+	c.release(kv)
+	kv.value, kv.word, kv.kind = nil, opts.word, opts.kind
+}
+
+// AddInt64 adds an int64 value with the default expire, storing it inline
+// in the entry whether or not WithInlineValues is set, so that unlike Add
+// it never allocates to hold the value. The value is only boxed for the
+// cost function, if any. Returns true if an eviction occurred.
+func (c *LRU) AddInt64(key interface{}, value int64) bool {
+	var cost int64 = 1
+	if c.costFn != nil {
+		cost = c.costFn(key, value)
+	}
+	return c.add(key, nil, 0, addOptions{
+		priority: PriorityNormal,
+		cost:     cost,
+		word:     uint64(value),
+		kind:     inlineInt64,
+	})
+}
+
+// GetInt64 looks up the value of a key like Get, without boxing it. ok is
+// false if the key is absent or expired, or if its value is not an int64.
+func (c *LRU) GetInt64(key interface{}) (value int64, ok bool) {
+	ent, ok := c.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	c.touch(ent)
+	return ent.Value.(*entry).int64()
+}
+
+// PeekInt64 returns the value of a key like Peek, without boxing it or
+// updating the recent-ness of the key.
+func (c *LRU) PeekInt64(key interface{}) (value int64, ok bool) {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) {
+		return 0, false
+	}
+	return ent.Value.(*entry).int64()
+}
+
+// int64 returns the value of the entry if it is an int64.
+func (kv *entry) int64() (int64, bool) {
+	if kv.kind == inlineInt64 {
+		return int64(kv.word), true
+	}
+	if kv.kind != boxed {
+		return 0, false
+	}
+	v, ok := kv.value.(int64)
+	return v, ok
+}
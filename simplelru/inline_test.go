@@ -0,0 +1,95 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_InlineValues(t *testing.T) {
+	evicted := map[interface{}]interface{}{}
+	l, err := NewLRUWithOptions(8, func(k, v interface{}) { evicted[k] = v }, WithInlineValues())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	values := []interface{}{-1 << 40, int64(-5), uint64(1 << 63), 2.5, true, false, "boxed"}
+	for i, v := range values {
+		l.Add(i, v)
+	}
+	for i, v := range values {
+		if got, ok := l.Get(i); !ok || got != v {
+			t.Fatalf("bad value %v: %v (%T)", v, got, got)
+		}
+	}
+	if kv := l.items[0].Value.(*entry); kv.value != nil || kv.kind != inlineInt {
+		t.Fatalf("int should be stored inline: %#v", kv)
+	}
+	if kv := l.items[6].Value.(*entry); kv.value != "boxed" || kv.kind != boxed {
+		t.Fatalf("string should be boxed: %#v", kv)
+	}
+
+	// Replacing an inline value with a boxed one and back
+	l.Add(0, "x")
+	if v, _ := l.Peek(0); v != "x" {
+		t.Fatalf("bad value: %v", v)
+	}
+	l.Add(0, 7)
+	if v, _ := l.Peek(0); v != 7 {
+		t.Fatalf("bad value: %v", v)
+	}
+	l.Remove(3)
+	if evicted[3] != 2.5 {
+		t.Fatalf("bad evicted value: %v", evicted[3])
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestLRU_Int64(t *testing.T) {
+	l, _ := NewLRU(2, nil)
+	l.AddInt64("a", 1<<40)
+	l.Add("b", "text")
+	if v, ok := l.GetInt64("a"); !ok || v != 1<<40 {
+		t.Fatalf("bad value: %v %v", v, ok)
+	}
+	if v, ok := l.Get("a"); !ok || v != int64(1<<40) {
+		t.Fatalf("bad boxed value: %v %v", v, ok)
+	}
+	if _, ok := l.PeekInt64("b"); ok {
+		t.Fatalf("string should not read as an int64")
+	}
+	l.Add("b", int64(3))
+	if v, ok := l.PeekInt64("b"); !ok || v != 3 {
+		t.Fatalf("boxed int64 should read as an int64: %v %v", v, ok)
+	}
+	if _, ok := l.GetInt64("missing"); ok {
+		t.Fatalf("missing key should not be found")
+	}
+
+	l.Add("c", 1)
+	if l.Contains("a") {
+		t.Fatalf("a should be evicted")
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestLRU_Int64Allocs(t *testing.T) {
+	l, _ := NewLRU(128, nil)
+	keys := make([]interface{}, 128)
+	for i := range keys {
+		keys[i] = i
+		l.AddInt64(keys[i], 0)
+	}
+	var n int64
+	allocs := testing.AllocsPerRun(100, func() {
+		for _, key := range keys {
+			v, _ := l.GetInt64(key)
+			n += 1 << 20
+			l.AddInt64(key, v+n)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("bad allocs: %v", allocs)
+	}
+}
@@ -0,0 +1,122 @@
+package simplelru
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// digest identifies an interned value
+type digest = [sha256.Size]byte
+
+// interned is a value stored once for all the entries holding an equal
+// value, see WithValueInterning
+type interned struct {
+	digest digest
+	value  interface{}
+	refs   int
+}
+
+// WithValueInterning stores equal values once: the values serialize
+// returns true for are identified by their type and the SHA-256 of their
+// serialized form, and an entry added with a value equal to one already
+// in the cache holds the stored value instead, so the caller's copy can
+// be garbage collected. This pays off when many keys map to identical large
+// values, such as rendered templates. Shared values must not be modified.
+// The cost of each entry, see WithMaxCost, is still charged to it.
+func WithValueInterning(serialize func(value interface{}) ([]byte, bool)) Option {
+	return func(c *LRU) {
+		c.serialize = serialize
+		c.interns = make(map[digest]*interned)
+	}
+}
+
+// InternBytes is a serializer for WithValueInterning interning the string
+// and []byte values of at least minSize bytes, smaller values not being
+// worth hashing.
+func InternBytes(minSize int) func(value interface{}) ([]byte, bool) {
+	return func(value interface{}) ([]byte, bool) {
+		switch v := value.(type) {
+		case []byte:
+			return v, len(v) >= minSize
+		case string:
+			if len(v) < minSize {
+				return nil, false
+			}
+			return []byte(v), true
+		}
+		return nil, false
+	}
+}
+
+// InternedValues returns the number of distinct values stored by
+// WithValueInterning, and the number of entries holding them.
+func (c *LRU) InternedValues() (values, refs int) {
+	for _, s := range c.interns {
+		values++
+		refs += s.refs
+	}
+	return values, refs
+}
+
+// setValue sets the value of an entry, releasing its previous value and
+// interning the new one if enabled
+func (c *LRU) setValue(kv *entry, value interface{}) {
+	c.release(kv)
+	if c.inline {
+		if word, kind, ok := inlineWord(value); ok {
+			kv.value, kv.word, kv.kind = nil, word, kind
+			return
+		}
+	}
+	kv.value, kv.kind = value, boxed
+	if c.interns == nil {
+		return
+	}
+	data, ok := c.serialize(value)
+	if !ok {
+		return
+	}
+	// Values of different types never share their storage
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\x00", value)
+	h.Write(data)
+	var digest digest
+	h.Sum(digest[:0])
+	s, ok := c.interns[digest]
+	if ok {
+		c.stats.Interned++
+	} else {
+		s = &interned{digest: digest, value: value}
+		c.interns[digest] = s
+	}
+	s.refs++
+	kv.value, kv.shared = s.value, s
+}
+
+// release drops the reference of an entry to its interned value
+func (c *LRU) release(kv *entry) {
+	s := kv.shared
+	if s == nil {
+		return
+	}
+	kv.shared = nil
+	if s.refs--; s.refs == 0 {
+		delete(c.interns, s.digest)
+	}
+}
+
+// adopt makes a copy of an entry of another cache share the interned
+// values of c
+func (c *LRU) adopt(kv *entry) {
+	s := kv.shared
+	if s == nil {
+		return
+	}
+	n, ok := c.interns[s.digest]
+	if !ok {
+		n = &interned{digest: s.digest, value: s.value}
+		c.interns[s.digest] = n
+	}
+	n.refs++
+	kv.shared = n
+}
@@ -0,0 +1,58 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_ValueInterning(t *testing.T) {
+	l, err := NewLRUWithOptions(4, nil, WithValueInterning(InternBytes(4)))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	page := func() []byte { return []byte("<html>hello</html>") }
+	a, b := page(), page()
+	l.Add(1, a)
+	l.Add(2, b)
+	l.Add(3, "<html>hello</html>")
+	l.Add(4, []byte("ab"))
+
+	v1, _ := l.Peek(1)
+	v2, _ := l.Peek(2)
+	if &v1.([]byte)[0] != &v2.([]byte)[0] || &v2.([]byte)[0] == &b[0] {
+		t.Fatalf("equal values should share their storage")
+	}
+	if values, refs := l.InternedValues(); values != 2 || refs != 3 {
+		t.Fatalf("bad interned: %d %d", values, refs)
+	}
+	if s := l.Stats(); s.Interned != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	// The stored value outlives the entry that added it
+	l.Remove(1)
+	l.Add(2, []byte("<html>bye</html>"))
+	if values, refs := l.InternedValues(); values != 2 || refs != 2 {
+		t.Fatalf("bad interned: %d %d", values, refs)
+	}
+	l.PeekAndUpdate(3, "<html>bye</html>")
+	if values, refs := l.InternedValues(); values != 2 || refs != 2 {
+		t.Fatalf("bad interned: %d %d", values, refs)
+	}
+	if v, _ := l.Peek(3); v != "<html>bye</html>" {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	c := l.Clone()
+	if values, refs := c.InternedValues(); values != 2 || refs != 2 {
+		t.Fatalf("bad clone: %d %d", values, refs)
+	}
+	c.Remove(2)
+	if values, refs := l.InternedValues(); values != 2 || refs != 2 {
+		t.Fatalf("clone should not share the table: %d %d", values, refs)
+	}
+
+	l.Purge()
+	if values, refs := l.InternedValues(); values != 0 || refs != 0 {
+		t.Fatalf("bad interned: %d %d", values, refs)
+	}
+}
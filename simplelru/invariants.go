@@ -0,0 +1,173 @@
+package simplelru
+
+import (
+	"fmt"
+)
+
+// CheckInvariants validates the internal consistency of the cache: the
+// links of the list of entries and of the list of free entries, that no
+// entry is in both, that the map indexes exactly the listed entries, and
+// that the counters kept alongside them match. It returns an error
+// describing the first violation found, or nil. It walks every entry, so
+// it is meant for tests, fuzzing and debugging rather than production
+// paths.
+func (c *LRU) CheckInvariants() error {
+	used := make(map[*Element]bool, c.evictList.Len())
+	visit := func(e *Element) { used[e] = true }
+	if l, ok := c.evictList.(*List); ok {
+		if err := checkList(l, "list", visit); err != nil {
+			return err
+		}
+	} else if err := checkOrderList(c.evictList, visit); err != nil {
+		return err
+	}
+	if err := checkList(c.freeList, "free list", func(e *Element) {}); err != nil {
+		return err
+	}
+	for e := c.freeList.Front(); e != nil; e = e.Next() {
+		if used[e] {
+			return fmt.Errorf("simplelru: entry %v is in both lists", e.Value.(*entry).key)
+		}
+	}
+	if n := len(c.items); n != c.evictList.Len() {
+		return fmt.Errorf("simplelru: %d keys indexed but %d entries listed", n, c.evictList.Len())
+	}
+	if c.maxCost <= 0 && !c.deferred && c.evictList.Len() > c.size {
+		return fmt.Errorf("simplelru: %d entries over a size of %d", c.evictList.Len(), c.size)
+	}
+	if len(c.slots) != len(c.items) {
+		return fmt.Errorf("simplelru: %d sampled slots for %d entries", len(c.slots), len(c.items))
+	}
+
+	var counts [numPriorities]int
+	var cost int64
+	origins := make(map[string]int)
+	refs := make(map[*interned]int)
+	for e := c.evictList.Front(); e != nil; e = c.evictList.Next(e) {
+		kv := e.Value.(*entry)
+		if ent, ok := c.items[kv.key]; !ok || ent != e {
+			return fmt.Errorf("simplelru: entry %v is not indexed", kv.key)
+		}
+		if kv.slot < 0 || kv.slot >= len(c.slots) || c.slots[kv.slot] != e {
+			return fmt.Errorf("simplelru: entry %v has a bad slot %d", kv.key, kv.slot)
+		}
+		if kv.priority < 0 || kv.priority >= numPriorities {
+			return fmt.Errorf("simplelru: entry %v has a bad priority %d", kv.key, kv.priority)
+		}
+		counts[kv.priority]++
+		cost += kv.cost
+		origins[kv.origin]++
+		if kv.shared != nil {
+			refs[kv.shared]++
+		}
+	}
+	if counts != c.counts {
+		return fmt.Errorf("simplelru: priority counts %v, want %v", c.counts, counts)
+	}
+	if err := c.checkClasses(); err != nil {
+		return err
+	}
+	if cost != c.cost {
+		return fmt.Errorf("simplelru: cost %d, want %d", c.cost, cost)
+	}
+	for origin, s := range c.origins {
+		if s.Entries != origins[origin] {
+			return fmt.Errorf("simplelru: origin %q has %d entries, want %d", origin, s.Entries, origins[origin])
+		}
+	}
+	if len(refs) != len(c.interns) {
+		return fmt.Errorf("simplelru: %d interned values, want %d", len(c.interns), len(refs))
+	}
+	for _, s := range c.interns {
+		if refs[s] != s.refs {
+			return fmt.Errorf("simplelru: interned value has %d refs, want %d", s.refs, refs[s])
+		}
+	}
+	return nil
+}
+
+// checkList validates the links of a list, calling visit for each of its
+// elements. The walk is bounded by the length of the list, so a cycle
+// cannot hang it.
+func checkList(l *List, name string, visit func(*Element)) error {
+	n := 0
+	prev := &l.root
+	for e := l.root.next; e != &l.root; e = e.next {
+		if n++; n > l.len {
+			return fmt.Errorf("simplelru: %s is longer than its length %d", name, l.len)
+		}
+		if e == nil {
+			return fmt.Errorf("simplelru: %s is broken after %d elements", name, n-1)
+		}
+		if e.list != l {
+			return fmt.Errorf("simplelru: element %d of the %s belongs to another list", n, name)
+		}
+		if e.prev != prev {
+			return fmt.Errorf("simplelru: element %d of the %s has a bad back link", n, name)
+		}
+		if _, ok := e.Value.(*entry); !ok {
+			return fmt.Errorf("simplelru: element %d of the %s holds a %T", n, name, e.Value)
+		}
+		visit(e)
+		prev = e
+	}
+	if n != l.len {
+		return fmt.Errorf("simplelru: %s has %d elements but a length of %d", name, n, l.len)
+	}
+	if l.root.prev != prev {
+		return fmt.Errorf("simplelru: %s has a bad back link to its last element", name)
+	}
+	return nil
+}
+
+// checkOrderList validates the links of an OrderList other than a List
+// through its methods, calling visit for each of its elements
+func checkOrderList(l OrderList, visit func(*Element)) error {
+	n := 0
+	var prev *Element
+	for e := l.Front(); e != nil; e = l.Next(e) {
+		if n++; n > l.Len() {
+			return fmt.Errorf("simplelru: list is longer than its length %d", l.Len())
+		}
+		if l.Prev(e) != prev || !l.Contains(e) {
+			return fmt.Errorf("simplelru: element %d of the list has a bad back link", n)
+		}
+		if _, ok := e.Value.(*entry); !ok {
+			return fmt.Errorf("simplelru: element %d of the list holds a %T", n, e.Value)
+		}
+		visit(e)
+		prev = e
+	}
+	if n != l.Len() {
+		return fmt.Errorf("simplelru: list has %d elements but a length of %d", n, l.Len())
+	}
+	if l.Back() != prev {
+		return fmt.Errorf("simplelru: list has a bad back link to its last element")
+	}
+	return nil
+}
+
+// checkClasses validates that the lists of the priorities, if kept, hold
+// the entries of the list in the same order.
+func (c *LRU) checkClasses() error {
+	if c.classes == nil {
+		return nil
+	}
+	var at [numPriorities]*Element
+	for i, l := range c.classes {
+		at[i] = l.Front()
+	}
+	for e := c.evictList.Front(); e != nil; e = c.evictList.Next(e) {
+		kv := e.Value.(*entry)
+		if at[kv.priority] == nil || at[kv.priority].Value != e || kv.class != at[kv.priority] {
+			return fmt.Errorf("simplelru: entry %v is out of order in its priority", kv.key)
+		}
+		at[kv.priority] = at[kv.priority].Next()
+	}
+	for i := range at {
+		if at[i] != nil {
+			return fmt.Errorf("simplelru: priority %d lists a removed entry", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package simplelru
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runOps interprets program as a sequence of operations on l, checking
+// the invariants after each of them
+func runOps(t *testing.T, l *LRU, program []byte) {
+	t.Helper()
+	for i := 0; i+1 < len(program); i += 2 {
+		op, key := program[i]%13, int(program[i+1]%16)
+		switch op {
+		case 0, 1:
+			l.Add(key, key)
+		case 2:
+			l.AddEx(key, key, time.Duration(key)*time.Microsecond)
+		case 3:
+			l.AddWithPriority(key, key, Priority(key)%numPriorities)
+		case 4:
+			l.Get(key)
+		case 5:
+			l.Remove(key)
+		case 6:
+			l.RemoveOldest()
+		case 7:
+			l.Resize(key%8 + 1)
+		case 8:
+			if key == 0 {
+				l.Purge()
+			}
+		case 9:
+			l.AddIfAbsent(key, key)
+		case 10:
+			l.PeekAndUpdate(key, key+1)
+		case 11:
+			l.DeleteExpired()
+		case 12:
+			l.ResizeDeferred(key%8 + 1)
+		}
+		if err := l.CheckInvariants(); err != nil {
+			t.Fatalf("after op %d of %v: %v", i/2, program[:i+2], err)
+		}
+	}
+}
+
+func newInvariantsLRU(t *testing.T) *LRU {
+	l, err := NewLRUWithOptions(4, nil,
+		WithOriginStats(),
+		WithValueInterning(func(v interface{}) ([]byte, bool) {
+			return []byte{byte(v.(int) % 3)}, true
+		}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return l
+}
+
+func TestLRU_InvariantsRandomOps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		program := make([]byte, 200)
+		r.Read(program)
+		runOps(t, newInvariantsLRU(t), program)
+	}
+}
+
+func TestLRU_CheckInvariantsDetectsCorruption(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An entry left in the free list while still listed
+	ent := l.items[1]
+	l.freeList.insert(&Element{Value: ent.Value}, &l.freeList.root)
+	l.freeList.Front().list = l.evictList.(*List)
+	if err := l.CheckInvariants(); err == nil || !strings.Contains(err.Error(), "another list") {
+		t.Fatalf("bad err: %v", err)
+	}
+	l.freeList.Init()
+
+	delete(l.items, 2)
+	if err := l.CheckInvariants(); err == nil || !strings.Contains(err.Error(), "indexed") {
+		t.Fatalf("bad err: %v", err)
+	}
+	l.items[2] = ent
+	if err := l.CheckInvariants(); err == nil || !strings.Contains(err.Error(), "not indexed") {
+		t.Fatalf("bad err: %v", err)
+	}
+}
+
+func FuzzLRU(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 2, 7, 0, 0, 3, 8, 0, 1, 4})
+	f.Add([]byte{3, 5, 3, 6, 10, 5, 6, 0, 9, 7, 11, 0})
+	f.Fuzz(func(t *testing.T, program []byte) {
+		runOps(t, newInvariantsLRU(t), program)
+	})
+}
@@ -0,0 +1,121 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// WithJanitorBudget caps the work of each background sweep to examining
+// entries entries and to maxTime, whichever comes first; 0 leaves a
+// limit off. A sweep stopping early resumes where it stopped on the next
+// interval, so a large cache is reaped over several sweeps rather than
+// stalling its users for a whole pass.
+func WithJanitorBudget(entries int, maxTime time.Duration) Option {
+	return func(c *LRU) {
+		c.sweepEntries = entries
+		c.sweepTime = maxTime
+	}
+}
+
+// WithJanitorPhase delays the first sweep of the janitor by i/n of its
+// interval. Giving each of n caches its own i spreads their sweeps over
+// the interval instead of running them all at once.
+func WithJanitorPhase(i, n int) Option {
+	return func(c *LRU) {
+		if n > 0 {
+			c.sweepPhase = float64(i%n) / float64(n)
+		}
+	}
+}
+
+// WithExpiryPrecision runs the janitor at least every d, so that expired
+// entries are reaped, and their eviction callbacks invoked, within d of
+// their expire time give or take the scheduling of the goroutine. This
+// suits lease semantics, at the cost of sweeping the cache every d. A
+// budget set by WithJanitorBudget voids the guarantee once it is hit.
+func WithExpiryPrecision(d time.Duration) Option {
+	return func(c *LRU) {
+		c.precision = d
+	}
+}
+
+// JanitorDelay returns how long the janitor waits before its first sweep,
+// as set by WithJanitorPhase.
+func (c *LRU) JanitorDelay() time.Duration {
+	return time.Duration(float64(c.JanitorInterval()) * c.sweepPhase)
+}
+
+// SweepExpired removes expired entries within the budget set by
+// WithJanitorBudget, from the oldest entry or from where the previous
+// sweep stopped, and returns how many were removed. Without a budget it
+// is DeleteExpired. Entries moved by accesses between two sweeps may be
+// skipped until the next pass.
+func (c *LRU) SweepExpired() int {
+	return c.SweepExpiredN(0)
+}
+
+// SweepExpiredN is like SweepExpired but examines at most max entries,
+// still within the time budget of WithJanitorBudget. A max of 0 or less
+// keeps the entries budget of WithJanitorBudget.
+func (c *LRU) SweepExpiredN(max int) int {
+	entries := c.sweepEntries
+	if max > 0 {
+		entries = max
+	}
+	if entries <= 0 && c.sweepTime <= 0 {
+		return c.DeleteExpired()
+	}
+
+	c.checkClock(c.now())
+	ent := c.evictList.Back()
+	if c.sweeping {
+		if e, ok := c.find(c.sweepFrom); ok {
+			ent = e
+		}
+	}
+	var deadline time.Time
+	if c.sweepTime > 0 {
+		deadline = c.now().Add(c.sweepTime)
+	}
+
+	removed := 0
+	for examined := 0; ent != nil; examined++ {
+		if entries > 0 && examined >= entries {
+			break
+		}
+		// Reading the clock is not free, so only check it now and then
+		if c.sweepTime > 0 && examined%32 == 31 && c.now().After(deadline) {
+			break
+		}
+		prev := c.evictList.Prev(ent)
+		if c.reap(ent) {
+			removed++
+		}
+		ent = prev
+	}
+
+	c.sweeping = ent != nil
+	c.sweepFrom = nil
+	if ent != nil {
+		c.sweepFrom = ent.Value.(*entry).key
+	}
+	return removed
+}
+
+// reap removes ent if it is expired, returning if it did.
+func (c *LRU) reap(ent *Element) bool {
+	kv := ent.Value.(*entry)
+	if !c.expired(kv) || c.inStaleWindow(kv) {
+		return false
+	}
+	if c.metrics != nil {
+		c.metrics.OnExpire(kv.key)
+	}
+	lag := c.now().Sub(*kv.expire)
+	c.stats.Reaped++
+	c.stats.ExpiryLag += lag
+	if lag > c.stats.MaxExpiryLag {
+		c.stats.MaxExpiryLag = lag
+	}
+	c.removeElement(ent, EvictExpired)
+	return true
+}
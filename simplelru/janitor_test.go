@@ -0,0 +1,76 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_SweepExpiredBudget(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithJanitorBudget(4, 0))
+	for i := 0; i < 10; i++ {
+		l.AddEx(i, i, time.Millisecond)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// Each sweep resumes where the previous one stopped
+	for _, want := range []int{4, 4, 2, 0} {
+		if n := l.SweepExpired(); n != want {
+			t.Fatalf("bad removed: %v, want %v", n, want)
+		}
+	}
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	l.Add("live", 1)
+	l.AddEx("dead", 1, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if n := l.SweepExpired(); n != 1 || l.Len() != 1 {
+		t.Fatalf("bad sweep: %v, len %v", n, l.Len())
+	}
+}
+
+func TestLRU_SweepExpiredUnlimited(t *testing.T) {
+	l, _ := NewLRU(10, nil)
+	for i := 0; i < 10; i++ {
+		l.AddEx(i, i, time.Millisecond)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if n := l.SweepExpired(); n != 10 {
+		t.Fatalf("bad removed: %v", n)
+	}
+}
+
+func TestLRU_JanitorDelay(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithJanitor(time.Second), WithJanitorPhase(3, 4))
+	if d := l.JanitorDelay(); d != 750*time.Millisecond {
+		t.Fatalf("bad delay: %v", d)
+	}
+	if d := l.Clone().JanitorDelay(); d != 750*time.Millisecond {
+		t.Fatalf("bad cloned delay: %v", d)
+	}
+	l, _ = NewLRU(10, nil)
+	if d := l.JanitorDelay(); d != 0 {
+		t.Fatalf("bad delay: %v", d)
+	}
+}
+
+func TestLRU_ExpiryLag(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithJanitor(time.Hour), WithExpiryPrecision(10*time.Millisecond))
+	if d := l.JanitorInterval(); d != 10*time.Millisecond {
+		t.Fatalf("precision should lower the interval: %v", d)
+	}
+	l.AddEx(1, 1, time.Millisecond)
+	l.AddEx(2, 2, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	l.DeleteExpired()
+
+	s := l.Stats()
+	if s.Reaped != 2 || s.MaxExpiryLag < 4*time.Millisecond || s.ExpiryLag < 2*s.MaxExpiryLag-time.Millisecond {
+		t.Fatalf("bad lag stats: %+v", s)
+	}
+	l.ResetStats()
+	if s := l.Stats(); s.Reaped != 0 || s.MaxExpiryLag != 0 {
+		t.Fatalf("bad reset: %+v", s)
+	}
+}
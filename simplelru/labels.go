@@ -0,0 +1,57 @@
+package simplelru
+
+// LabeledRecorder is a MetricsRecorder told the name and labels of the
+// cache it records, set by WithName and WithLabels, when the cache is
+// constructed.
+type LabeledRecorder interface {
+	MetricsRecorder
+
+	// SetCache is called with the name and labels of the cache.
+	SetCache(name string, labels map[string]string)
+}
+
+// WithName sets the name of the cache, passed along with its labels to
+// a LabeledRecorder, and reported in the eviction events and the stats
+// of the thread-safe caches, so that the caches of a service can be told
+// apart.
+func WithName(name string) Option {
+	return func(c *LRU) {
+		c.name = name
+	}
+}
+
+// WithLabels sets labels describing the cache, such as its tenant or
+// tier, reported like the name set by WithName. labels is copied.
+func WithLabels(labels map[string]string) Option {
+	return func(c *LRU) {
+		c.labels = copyLabels(labels)
+	}
+}
+
+// Name returns the name set by WithName.
+func (c *LRU) Name() string {
+	return c.name
+}
+
+// Labels returns a copy of the labels set by WithLabels, nil if none.
+func (c *LRU) Labels() map[string]string {
+	return copyLabels(c.labels)
+}
+
+// labelRecorder tells a LabeledRecorder the name and labels of the cache
+func (c *LRU) labelRecorder() {
+	if r, ok := c.metrics.(LabeledRecorder); ok {
+		r.SetCache(c.name, c.Labels())
+	}
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	m := make(map[string]string, len(labels))
+	for k, v := range labels {
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,37 @@
+package simplelru
+
+import (
+	"reflect"
+	"testing"
+)
+
+type labeledRecorder struct {
+	countingRecorder
+	name   string
+	labels map[string]string
+}
+
+func (r *labeledRecorder) SetCache(name string, labels map[string]string) {
+	r.name, r.labels = name, labels
+}
+
+func TestLRU_NameAndLabels(t *testing.T) {
+	r := &labeledRecorder{}
+	labels := map[string]string{"tier": "edge"}
+	l, err := NewLRUWithOptions(2, nil, WithMetrics(r), WithName("sessions"), WithLabels(labels))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	labels["tier"] = "origin"
+	want := map[string]string{"tier": "edge"}
+	if l.Name() != "sessions" || !reflect.DeepEqual(l.Labels(), want) {
+		t.Fatalf("bad identity: %v %v", l.Name(), l.Labels())
+	}
+	if r.name != "sessions" || !reflect.DeepEqual(r.labels, want) {
+		t.Fatalf("recorder not told: %v %v", r.name, r.labels)
+	}
+	l.Labels()["tier"] = "origin"
+	if c := l.Clone(); c.Name() != "sessions" || !reflect.DeepEqual(c.Labels(), want) {
+		t.Fatalf("bad clone: %v %v", c.Name(), c.Labels())
+	}
+}
@@ -0,0 +1,71 @@
+package simplelru
+
+import "time"
+
+// lifetimes records the TTLs assigned to the entries and how long they
+// lived, in the log-scale histograms of reuse observation
+type lifetimes struct {
+	ttls reuseHistogram
+	// lived holds a histogram per reason an entry can leave the cache
+	// for, EvictReplaced excepted
+	lived [EvictReplaced]reuseHistogram
+}
+
+// WithLifetimeHistograms records the TTLs assigned by Add and its
+// variants and the time each entry spent in the cache, from its insertion
+// to its eviction, expiry or removal. TTLQuantile and LifetimeQuantile
+// then tell whether entries are evicted for capacity long before their
+// TTLs, the sign that the cache is undersized; see also
+// Stats.EvictedBeforeExpiry.
+func WithLifetimeHistograms() Option {
+	return func(c *LRU) {
+		c.lifetimes = &lifetimes{}
+	}
+}
+
+// TTLQuantile returns the q-quantile, with q between 0 and 1, of the TTLs
+// assigned to the entries added, within a factor of about 1.4 as for
+// ReuseQuantile. Entries added without expire are not counted. ok is
+// false if nothing was recorded.
+func (c *LRU) TTLQuantile(q float64) (d time.Duration, ok bool) {
+	if c.lifetimes == nil || c.lifetimes.ttls.total == 0 {
+		return 0, false
+	}
+	return c.lifetimes.ttls.quantile(q), true
+}
+
+// LifetimeQuantile returns the q-quantile, with q between 0 and 1, of the
+// time spent in the cache by the entries that left it for the given
+// reason, within a factor of about 1.4 as for ReuseQuantile. ok is false
+// if nothing was recorded.
+func (c *LRU) LifetimeQuantile(reason EvictReason, q float64) (d time.Duration, ok bool) {
+	if c.lifetimes == nil || reason < 0 || reason >= EvictReplaced {
+		return 0, false
+	}
+	h := &c.lifetimes.lived[reason]
+	if h.total == 0 {
+		return 0, false
+	}
+	return h.quantile(q), true
+}
+
+// born records the TTL of an entry added, and its insertion time if new
+func (l *lifetimes) born(kv *entry, ttl time.Duration, now time.Time, inserted bool) {
+	if ttl > 0 {
+		l.ttls.counts[reuseBucket(ttl)]++
+		l.ttls.total++
+	}
+	if inserted {
+		kv.inserted = now.UnixNano()
+	}
+}
+
+// died records the lifetime of an entry leaving the cache
+func (l *lifetimes) died(kv *entry, reason EvictReason, now time.Time) {
+	if reason < 0 || reason >= EvictReplaced {
+		return
+	}
+	h := &l.lived[reason]
+	h.counts[reuseBucket(time.Duration(now.UnixNano()-kv.inserted))]++
+	h.total++
+}
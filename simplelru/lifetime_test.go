@@ -0,0 +1,61 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_LifetimeHistograms(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l, err := NewLRUWithOptions(2, nil, WithLifetimeHistograms(), WithClock(clock))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := l.TTLQuantile(0.5); ok {
+		t.Fatalf("nothing recorded yet")
+	}
+
+	l.AddEx(1, 1, time.Hour)
+	l.AddEx(2, 2, time.Hour)
+	clock.Advance(time.Second)
+	// Evicted for capacity long before its TTL
+	l.AddEx(3, 3, time.Minute)
+	clock.Advance(2 * time.Minute)
+	l.DeleteExpired()
+	l.Add(4, 4)
+	clock.Advance(10 * time.Second)
+	l.Remove(4)
+
+	if d, ok := l.TTLQuantile(1); !ok || d < time.Hour || d > 90*time.Minute {
+		t.Fatalf("bad max ttl: %v %v", d, ok)
+	}
+	if d, _ := l.TTLQuantile(0); d < time.Minute || d > 90*time.Second {
+		t.Fatalf("bad min ttl: %v", d)
+	}
+	if d, ok := l.LifetimeQuantile(EvictCapacity, 0.5); !ok || d < time.Second || d > 2*time.Second {
+		t.Fatalf("bad capacity lifetime: %v %v", d, ok)
+	}
+	if d, ok := l.LifetimeQuantile(EvictExpired, 0.5); !ok || d < 2*time.Minute || d > 3*time.Minute {
+		t.Fatalf("bad expired lifetime: %v %v", d, ok)
+	}
+	if d, ok := l.LifetimeQuantile(EvictRemoved, 0.5); !ok || d < 10*time.Second || d > 15*time.Second {
+		t.Fatalf("bad removed lifetime: %v %v", d, ok)
+	}
+	if _, ok := l.LifetimeQuantile(EvictReplaced, 0.5); ok {
+		t.Fatalf("replaced values have no lifetime")
+	}
+	if s := l.Stats(); s.Evicted != 1 || s.EvictedBeforeExpiry != 1 {
+		t.Fatalf("bad evictions: %+v", s)
+	}
+
+	// Updates record their TTL too
+	l.AddEx(2, 20, time.Millisecond)
+	clock.Advance(time.Hour)
+	l.Purge()
+	if d, _ := l.LifetimeQuantile(EvictPurged, 1); d < time.Hour {
+		t.Fatalf("bad purged lifetime: %v", d)
+	}
+	if d, _ := l.TTLQuantile(0); d > 2*time.Millisecond {
+		t.Fatalf("bad min ttl: %v", d)
+	}
+}
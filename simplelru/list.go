@@ -5,10 +5,10 @@
 // Package list implements a doubly linked list.
 //
 // To iterate over a list (where l is a *List):
+//
 //	for e := l.Front(); e != nil; e = e.Next() {
 //		// do something with e.Value
 //	}
-//
 package simplelru
 
 // Element is an element of a linked list.
@@ -192,6 +192,38 @@ func (l *List) MoveToFront(e *Element) {
 	l.move(e, &l.root)
 }
 
+// moveToFrontAll moves the elements of l in es to the front of l, in the
+// order successive MoveToFront calls would leave them: the last element
+// of es ends up first. The elements are unlinked and spliced in as one
+// chain, so the front of the list is only rewritten once.
+func (l *List) moveToFrontAll(es []*Element) {
+	if len(es) == 0 {
+		return
+	}
+	var head, tail *Element
+	seen := make(map[*Element]struct{}, len(es))
+	for i := len(es) - 1; i >= 0; i-- {
+		e := es[i]
+		if _, ok := seen[e]; ok || e.list != l {
+			continue
+		}
+		seen[e] = struct{}{}
+		e.prev.next = e.next
+		e.next.prev = e.prev
+		if head == nil {
+			head = e
+		} else {
+			tail.next = e
+			e.prev = tail
+		}
+		tail = e
+	}
+	head.prev = &l.root
+	tail.next = l.root.next
+	l.root.next.prev = tail
+	l.root.next = head
+}
+
 // MoveToBack moves element e to the back of list l.
 // If e is not an element of l, the list is not modified.
 // The element must not be nil.
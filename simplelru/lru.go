@@ -2,6 +2,8 @@ package simplelru
 
 import (
 	"errors"
+	"math"
+	"sort"
 	"time"
 )
 
@@ -11,77 +13,393 @@ type EvictCallback func(key interface{}, value interface{})
 // LRU implements a non-thread safe fixed size LRU cache
 type LRU struct {
 	size      int
-	evictList *List
-	freeList  *List
-	items     map[interface{}]*Element
-	expire    time.Duration
-	onEvict   EvictCallback
+	initial   int
+	evictList OrderList
+	// newOrderList creates evictList, see WithOrderList
+	newOrderList func() OrderList
+	freeList     *List
+	items        map[interface{}]*Element
+	expire       time.Duration
+	onEvict      EvictCallback
+	onReason     EvictCallbackWithReason
+	stats        Stats
+	order        EvictionOrder
+	policy       EvictionPolicy
+	// plugin is the replacement policy, either built-in or the custom
+	// one set by WithPolicy and created by newPlugin
+	plugin    Policy
+	newPlugin func() Policy
+	// hand is where the next scan of GenerationOrder starts, the back of
+	// the list if nil
+	hand *Element
+	// epoch is bumped by Purge, which drops the entries handles refer to
+	epoch uint64
+	// classes orders the entries of each priority, see classify
+	classes   []*List
+	counts    [numPriorities]int
+	minTTL    time.Duration
+	maxTTL    time.Duration
+	cooldown  time.Duration
+	churn     *churnTracker
+	hll       *hyperLogLog
+	reuse     *reuseObserver
+	lifetimes *lifetimes
+	shadow    *shadow
+	janitor   time.Duration
+	clock     Clock
+	precision time.Duration
+	// sweepEntries, sweepTime and sweepPhase configure the janitor, and
+	// sweepFrom is the key where a budgeted sweep resumes if sweeping
+	sweepEntries int
+	sweepTime    time.Duration
+	sweepPhase   float64
+	sweepFrom    interface{}
+	sweeping     bool
+	metrics      MetricsRecorder
+	window       *windowStats
+	tombstones   *tombstones
+	slots        []*Element
+	sliding      time.Duration
+	staleTTL     time.Duration
+	origins      map[string]*OriginStats
+	maxCost      int64
+	cost         int64
+	costFn       func(key, value interface{}) int64
+	// overhead is added to the cost of every entry, see WithEntryOverhead
+	overhead int64
+	// inline stores word-sized values in their entry, see WithInlineValues
+	inline bool
+	// jumpThreshold configures the clock jump detection, and clockRef
+	// and clockWall are the readings it started from, clockSkew the
+	// difference between the wall and monotonic clocks since then
+	jumpThreshold time.Duration
+	clockRef      time.Time
+	clockWall     int64
+	clockSkew     time.Duration
+	// admission is the policy set by WithAdmissionPolicy, if any,
+	// created by newAdmission
+	admission    AdmissionPolicy
+	newAdmission func() AdmissionPolicy
+	strict       bool
+	// name and labels identify the cache in observability outputs
+	name   string
+	labels map[string]string
+	// deferred is set while a shrink by ResizeDeferred is pending
+	deferred bool
+	// batch is set while SetMany adds its entries, deferring evictions
+	batch bool
+	// serialize and interns implement WithValueInterning
+	serialize func(value interface{}) ([]byte, bool)
+	interns   map[digest]*interned
+}
+
+// Stats holds the lookup counters of a cache.
+type Stats struct {
+	Hits   uint64 // Hits is the number of successful Gets
+	Misses uint64 // Misses is the number of Gets of absent or expired keys
+
+	// Expired is the number of expired entries still occupying a slot
+	// of the cache, i.e. the part of the capacity that is wasted until
+	// they are evicted.
+	Expired int
+
+	// ExpiredCost is the total cost of the expired entries, see
+	// WithMaxCost, approximating the memory they waste.
+	ExpiredCost int64
+
+	// Churning is the number of keys currently flagged by churn tracking
+	Churning int
+
+	// Reaped is the number of expired entries removed by DeleteExpired
+	// or the janitor, ExpiryLag the total time they outlived their
+	// expire time and MaxExpiryLag the longest of these times.
+	Reaped       uint64
+	ExpiryLag    time.Duration
+	MaxExpiryLag time.Duration
+
+	// ClockJumps is the number of wall clock jumps detected, see
+	// WithClockJumpThreshold, and ClockJumpEntries the number of expire
+	// times rebased because of them.
+	ClockJumps       uint64
+	ClockJumpEntries uint64
+
+	// Rejected is the number of new keys dropped by the admission
+	// policy, see WithAdmissionPolicy
+	Rejected uint64
+
+	// Interned is the number of values added that were already stored,
+	// see WithValueInterning
+	Interned uint64
+
+	// Evicted is the number of live entries evicted to make room, and
+	// EvictedBeforeExpiry the number of them that had an expire time yet
+	// to come, see WithLifetimeHistograms
+	Evicted             uint64
+	EvictedBeforeExpiry uint64
 }
 
 // entry is used to hold a value in the evictList
 type entry struct {
-	key    interface{}
-	value  interface{}
-	expire *time.Time
+	key      interface{}
+	value    interface{}
+	expire   *time.Time
+	priority Priority
+	// hits counts the lookups of the entry since it was added, see HotKeys
+	hits uint32
+
+	// word holds the value in place of value when kind is not boxed,
+	// see WithInlineValues
+	word uint64
+	kind valueKind
+
+	// softBefore is how long before expire the entry goes stale, see
+	// AddExSoft, so that it follows expire through rebases and sliding
+	softBefore time.Duration
+
+	// immutable entries are ignored by Add until removed or expired
+	immutable bool
+
+	// accessed is the time of the last access in Unix nanoseconds, only
+	// maintained when reuse observation is enabled
+	accessed int64
+
+	// inserted is the time the entry was added in Unix nanoseconds, only
+	// maintained with WithLifetimeHistograms
+	inserted int64
+
+	// pins counts the Pin calls not yet matched by Unpin, and cooldown is
+	// the Unix nanosecond time until which the entry stays protected
+	// after its last Unpin
+	pins     int
+	cooldown int64
+
+	// cost is the weight of the entry against the cost budget
+	cost int64
+
+	// gen is the generation counter of the entry in GenerationOrder
+	gen uint8
+
+	// class is the element of the entry in the list of its priority, see
+	// classify
+	class *Element
+
+	// shared is the interned value held by the entry, if any
+	shared *interned
+
+	// slot is the index of the entry in the slots sampled by SampleKeys
+	slot int
+
+	// origin is the source that produced the entry, see AddWithOrigin
+	origin string
+
+	// version is bumped whenever the entry is removed or reused, so that
+	// handles to its former key go stale, see Handle
+	version uint64
 }
 
-func (e *entry) IsExpired() bool {
-	if e.expire == nil {
-		return false
-	}
-	return time.Now().After(*e.expire)
+// expiredAt returns if the entry is expired at now
+func (e *entry) expiredAt(now time.Time) bool {
+	return e.expire != nil && now.After(*e.expire)
 }
 
 // NewLRU constructs an LRU of the given size
 func NewLRU(size int, onEvict EvictCallback) (*LRU, error) {
+	return NewLRUWithOptions(size, onEvict)
+}
+
+// NewLRUWithExpire contrusts an LRU of the given size and expire time
+func NewLRUWithExpire(size int, expire time.Duration, onEvict EvictCallback) (*LRU, error) {
+	return NewLRUWithOptions(size, onEvict, WithExpire(expire))
+}
+
+// NewLRUWithOptions constructs an LRU of the given size configured by
+// the given options.
+func NewLRUWithOptions(size int, onEvict EvictCallback, opts ...Option) (*LRU, error) {
 	if size <= 0 {
 		return nil, errors.New("Must provide a positive size")
 	}
 	c := &LRU{
-		size:      size,
-		evictList: New(),
-		freeList:  New(),
-		items:     make(map[interface{}]*Element),
-		expire:    0,
-		onEvict:   onEvict,
-	}
-	for i := 0; i < size; i++ {
-		c.freeList.PushFront(&entry{})
+		size:     size,
+		initial:  size,
+		freeList: New(),
+		items:    make(map[interface{}]*Element),
+		expire:   0,
+		onEvict:  onEvict,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.evictList = c.newOrder()
+	if c.plugin == nil {
+		c.plugin = newBuiltinPolicy(c)
 	}
+	c.preallocate()
+	c.labelRecorder()
 	return c, nil
 }
 
-// NewLRUWithExpire contrusts an LRU of the given size and expire time
-func NewLRUWithExpire(size int, expire time.Duration, onEvict EvictCallback) (*LRU, error) {
-	if size <= 0 {
-		return nil, errors.New("Must provide a positive size")
+// Clone returns an independent copy of the cache with the same
+// configuration and entries, preserving their order, expire times and
+// priorities. Values are copied as is, and the lookup counters start
+// from zero.
+func (c *LRU) Clone() *LRU {
+	return c.CloneFunc(nil)
+}
+
+// CloneFunc is like Clone but copies each value with copyValue, allowing
+// a deep copy of the entries. A nil copyValue copies values as is.
+func (c *LRU) CloneFunc(copyValue func(value interface{}) interface{}) *LRU {
+	n := &LRU{
+		size:         c.size,
+		evictList:    c.newOrder(),
+		newOrderList: c.newOrderList,
+		freeList:     New(),
+		initial:      c.initial,
+		items:        make(map[interface{}]*Element, len(c.items)),
+		expire:       c.expire,
+		minTTL:       c.minTTL,
+		sliding:      c.sliding,
+		staleTTL:     c.staleTTL,
+		maxTTL:       c.maxTTL,
+		cooldown:     c.cooldown,
+		onEvict:      c.onEvict,
+		onReason:     c.onReason,
+		order:        c.order,
+		policy:       c.policy,
+		newPlugin:    c.newPlugin,
+		counts:       c.counts,
+		janitor:      c.janitor,
+		clock:        c.clock,
+		precision:    c.precision,
+		sweepEntries: c.sweepEntries,
+		sweepTime:    c.sweepTime,
+		sweepPhase:   c.sweepPhase,
+		metrics:      c.metrics,
+		maxCost:      c.maxCost,
+		cost:         c.cost,
+		costFn:       c.costFn,
+		overhead:     c.overhead,
+		inline:       c.inline,
+
+		jumpThreshold: c.jumpThreshold,
+		newAdmission:  c.newAdmission,
+		strict:        c.strict,
+		name:          c.name,
+		labels:        c.labels,
+		serialize:     c.serialize,
+		deferred:      c.deferred,
 	}
-	c := &LRU{
-		size:      size,
-		evictList: New(),
-		freeList:  New(),
-		items:     make(map[interface{}]*Element),
-		expire:    expire,
-		onEvict:   onEvict,
-	}
-	for i := 0; i < size; i++ {
-		c.freeList.PushFront(&entry{})
+	if c.interns != nil {
+		n.interns = make(map[digest]*interned)
+	}
+	if c.newAdmission != nil {
+		n.admission = c.newAdmission()
+	}
+	if c.hll != nil {
+		hll := *c.hll
+		n.hll = &hll
+	}
+	if c.reuse != nil {
+		n.reuse = newReuseObserver(c.reuse.classify)
+	}
+	if c.lifetimes != nil {
+		n.lifetimes = &lifetimes{}
+	}
+	if c.shadow != nil {
+		n.shadow = newShadow(len(c.shadow.ring))
+	}
+	if c.window != nil {
+		n.window = &windowStats{}
+	}
+	if c.origins != nil {
+		n.origins = make(map[string]*OriginStats, len(c.origins))
+		for origin, s := range c.origins {
+			n.origins[origin] = &OriginStats{Entries: s.Entries}
+		}
 	}
+	if c.tombstones != nil {
+		t := *c.tombstones
+		t.until = make(map[interface{}]int64, len(c.tombstones.until))
+		for k, until := range c.tombstones.until {
+			t.until[k] = until
+		}
+		n.tombstones = &t
+	}
+	if c.churn != nil {
+		n.churn = newChurnTracker(c.churn.window, c.churn.threshold, c.churn.limit)
+	}
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		kv := *ent.Value.(*entry)
+		kv.class = nil
+		if copyValue != nil {
+			kv.shared = nil
+			n.setValue(&kv, copyValue(kv.load()))
+		} else {
+			n.adopt(&kv)
+		}
+		ent := n.evictList.PushElementFront(&Element{Value: &kv})
+		n.items[kv.key] = ent
+		n.index(ent)
+	}
+	if c.classes != nil {
+		n.classify()
+	}
+	if c.newPlugin != nil {
+		n.plugin = c.newPlugin()
+		for ent := n.evictList.Back(); ent != nil; ent = n.evictList.Prev(ent) {
+			n.plugin.RecordAdd(ent.Value.(*entry).key)
+		}
+	} else {
+		n.plugin = newBuiltinPolicy(n)
+	}
+	n.preallocate()
+	return n
+}
 
-	return c, nil
+// SetEvictCallback replaces the eviction callback of the cache.
+func (c *LRU) SetEvictCallback(onEvict EvictCallback) {
+	c.onEvict = onEvict
 }
 
 // Purge is used to completely clear the cache
 func (c *LRU) Purge() {
 	for k, v := range c.items {
-		if c.onEvict != nil {
-			c.onEvict(k, v.Value.(*entry).value)
-		}
+		c.evicted(v.Value.(*entry), EvictPurged)
 		delete(c.items, k)
 	}
-	c.evictList.Init()
+	c.evictList.Clear()
+	c.hand = nil
+	c.epoch++
+	c.classes = nil
 	c.freeList.Init()
-	for i := 0; i < c.size; i++ {
+	c.counts = [numPriorities]int{}
+	c.cost = 0
+	c.slots = nil
+	c.deferred = false
+	if c.interns != nil {
+		c.interns = make(map[digest]*interned)
+	}
+	if c.newPlugin != nil {
+		c.plugin = c.newPlugin()
+	}
+	for _, s := range c.origins {
+		s.Entries = 0
+	}
+	c.preallocate()
+}
+
+// preallocate fills the free list up to the size of the cache, or the
+// initial capacity if lower. A cache bounded by cost allocates its
+// entries as it fills up instead.
+func (c *LRU) preallocate() {
+	if c.maxCost > 0 {
+		return
+	}
+	n := c.size
+	if c.initial < n {
+		n = c.initial
+	}
+	for i := c.evictList.Len() + c.freeList.Len(); i < n; i++ {
 		c.freeList.PushFront(&entry{})
 	}
 }
@@ -92,58 +410,346 @@ func (c *LRU) Add(key, value interface{}) bool {
 }
 
 // AddEx adds a value to the cache with expire.  Returns true if an eviction occurred.
+// An existing key keeps its priority.
 func (c *LRU) AddEx(key, value interface{}, expire time.Duration) bool {
+	return c.add(key, value, expire, addOptions{
+		priority: PriorityNormal,
+		cost:     c.costOf(key, value),
+	})
+}
+
+// addOptions holds the attributes of an entry being added
+type addOptions struct {
+	priority Priority
+	cost     int64
+	origin   string
+
+	// word and kind hold a value stored inline in place of value, see
+	// AddInt64
+	word uint64
+	kind valueKind
+
+	// now is the time the expire counts from, time.Now if zero
+	now time.Time
+
+	// soft is the soft TTL of the entry, see AddExSoft
+	soft time.Duration
+
+	// setPriority and setOrigin apply priority and origin to an existing
+	// key too, rather than only to a new one
+	setPriority bool
+	setOrigin   bool
+
+	// immutable makes the entry write-once, see AddImmutable
+	immutable bool
+}
+
+// add adds a value to the cache with the given attributes, returning if
+// an eviction occurred.
+func (c *LRU) add(key, value interface{}, expire time.Duration, opts addOptions) bool {
+	evict, _ := c.store(key, value, expire, opts)
+	return evict
+}
+
+// store is add, also returning if the value was stored: an add may be
+// declined by a tombstone, an immutable entry, admission or the budget.
+func (c *LRU) store(key, value interface{}, expire time.Duration, opts addOptions) (evict, stored bool) {
+	c.checkAdd(key, expire)
+	c.observe(key)
+	now := opts.now
+	if now.IsZero() {
+		now = c.now()
+		c.checkClock(now)
+	}
+	if c.tombstones != nil && !c.tombstones.admit(key, now) {
+		return false, false
+	}
+	opts.cost += c.overhead
 	var ex *time.Time = nil
-	if expire > 0 {
-		expire := time.Now().Add(expire)
-		ex = &expire
-	} else if c.expire > 0 {
-		expire := time.Now().Add(c.expire)
+	if expire = c.ttl(expire); expire > 0 {
+		expire := now.Add(expire)
 		ex = &expire
 	}
+	var softBefore time.Duration
+	if expire > 0 && opts.soft > 0 && opts.soft < expire {
+		softBefore = expire - opts.soft
+	}
 	// Check for existing item
-	if ent, ok := c.items[key]; ok {
-		c.evictList.MoveToFront(ent)
-		ent.Value.(*entry).value = value
+	if ent, ok := c.find(key); ok {
+		if kv := ent.Value.(*entry); kv.immutable {
+			if !kv.expiredAt(now) {
+				return false, false
+			}
+			kv.immutable = false
+		}
+		if c.maxCost > 0 && opts.cost > c.maxCost {
+			// The new value could never fit in the budget, keep the old one
+			return false, false
+		}
+		if c.metrics != nil {
+			c.metrics.OnAdd(key)
+		}
+		if c.onReason != nil {
+			c.onReason(key, ent.Value.(*entry).load(), EvictReplaced)
+		}
+		c.touchAt(ent, now)
+		c.storeValue(ent.Value.(*entry), value, opts)
 		ent.Value.(*entry).expire = ex
-		return false
+		ent.Value.(*entry).softBefore = softBefore
+		if c.lifetimes != nil {
+			c.lifetimes.born(ent.Value.(*entry), expire, now, false)
+		}
+		if opts.setPriority {
+			c.setPriority(ent, opts.priority)
+		}
+		if opts.setOrigin {
+			c.setOrigin(ent.Value.(*entry), opts.origin)
+		}
+		if opts.immutable {
+			ent.Value.(*entry).immutable = true
+		}
+		c.cost += opts.cost - ent.Value.(*entry).cost
+		ent.Value.(*entry).cost = opts.cost
+		// The updated entry is pinned while the others make room for it
+		ent.Value.(*entry).pins++
+		evict = c.fitCost(0)
+		ent.Value.(*entry).pins--
+		return evict, true
+	}
+
+	if c.maxCost > 0 && opts.cost > c.maxCost {
+		// The entry could never fit in the budget
+		return false, false
+	}
+	if !c.admit(key) {
+		return false, false
 	}
 
-	evict := c.evictList.Len() >= c.size
 	// Verify size not exceeded
-	if evict {
-		c.removeOldest()
+	evict = c.makeRoom()
+	if c.fitCost(opts.cost) {
+		evict = true
 	}
 
 	// Add new item
 	ent := c.freeList.Front()
+	if ent == nil {
+		// The cache has grown past its preallocated entries
+		ent = &Element{Value: &entry{}}
+	} else {
+		c.freeList.Remove(ent)
+	}
 	ent.Value.(*entry).key = key
-	ent.Value.(*entry).value = value
+	ent.Value.(*entry).shared = nil
+	c.storeValue(ent.Value.(*entry), value, opts)
 	ent.Value.(*entry).expire = ex
-	c.freeList.Remove(ent)
+	ent.Value.(*entry).softBefore = softBefore
+	ent.Value.(*entry).priority = opts.priority
+	ent.Value.(*entry).hits = 0
+	ent.Value.(*entry).immutable = opts.immutable
+	ent.Value.(*entry).pins = 0
+	ent.Value.(*entry).cooldown = 0
+	ent.Value.(*entry).cost = opts.cost
+	ent.Value.(*entry).gen = 0
+	ent.Value.(*entry).origin = opts.origin
+	if c.lifetimes != nil {
+		c.lifetimes.born(ent.Value.(*entry), expire, now, true)
+	}
+	if c.origins != nil {
+		c.origin(opts.origin).Entries++
+	}
+	c.cost += opts.cost
+	if c.reuse != nil {
+		ent.Value.(*entry).accessed = now.UnixNano()
+	}
+	c.counts[opts.priority]++
 	c.evictList.PushElementFront(ent)
+	c.classAdd(ent)
 	c.items[key] = ent
+	c.index(ent)
+	c.plugin.RecordAdd(key)
+	if c.metrics != nil {
+		c.metrics.OnAdd(key)
+	}
 
-	return evict
+	return evict, true
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value
+// with the default expire. Returns whether found and whether an eviction
+// occurred.
+func (c *LRU) ContainsOrAdd(key, value interface{}) (ok, evicted bool) {
+	if c.Contains(key) {
+		return true, false
+	}
+	return false, c.Add(key, value)
+}
+
+// PeekOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value
+// with the default expire. Returns the previous value if found, whether
+// found and whether an eviction occurred.
+func (c *LRU) PeekOrAdd(key, value interface{}) (previous interface{}, ok, evicted bool) {
+	if previous, ok := c.Peek(key); ok {
+		return previous, true, false
+	}
+	return nil, false, c.Add(key, value)
 }
 
 // Get looks up a key's value from the cache.
 func (c *LRU) Get(key interface{}) (value interface{}, ok bool) {
-	if ent, ok := c.items[key]; ok {
-		if ent.Value.(*entry).IsExpired() {
-			return nil, false
+	ent, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.touch(ent)
+	return ent.Value.(*entry).load(), true
+}
+
+// GetMany looks up the values of several keys at once, returning them
+// along with whether each key was found. The hits are promoted in one
+// pass over the list, leaving them in the same order as a Get of each
+// key in turn would.
+func (c *LRU) GetMany(keys []interface{}) (values []interface{}, found []bool) {
+	values = make([]interface{}, len(keys))
+	found = make([]bool, len(keys))
+	hits := make([]*Element, 0, len(keys))
+	for i, key := range keys {
+		if ent, ok := c.lookup(key); ok {
+			values[i] = ent.Value.(*entry).load()
+			found[i] = true
+			hits = append(hits, ent)
+		}
+	}
+	if l, ok := c.evictList.(*List); ok && c.order == AccessOrder && c.sliding == 0 && c.newPlugin == nil {
+		l.moveToFrontAll(hits)
+		for _, ent := range hits {
+			c.classMove(ent, true)
+		}
+	} else {
+		for _, ent := range hits {
+			c.touch(ent)
 		}
+	}
+	return values, found
+}
+
+// KV is a key and its value, as added by SetMany.
+type KV struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// SetMany adds several entries at once like an Add of each in turn, but
+// evicts once after the whole batch against the combined overflow, and
+// returns the evicted entries from first to last evicted. Entries of the
+// batch itself are evicted if it holds more than the cache does.
+func (c *LRU) SetMany(entries []KV) (victims []KV) {
+	c.batch = true
+	for _, e := range entries {
+		c.add(e.Key, e.Value, 0, addOptions{priority: PriorityNormal, cost: c.costOf(e.Key, e.Value)})
+	}
+	c.batch = false
+	for c.evictList.Len() > c.size || c.maxCost > 0 && c.cost > c.maxCost {
+		ent := c.victim()
+		if ent == nil {
+			break
+		}
+		kv := ent.Value.(*entry)
+		victims = append(victims, KV{Key: kv.key, Value: kv.load()})
+		c.evict(ent)
+	}
+	if c.deferred && c.evictList.Len() <= c.size {
+		c.deferred = false
+	}
+	c.trimFree()
+	return victims
+}
+
+// touch records an access to an entry according to the eviction order,
+// restarting its expire time if the cache has a sliding expire.
+func (c *LRU) touch(ent *Element) {
+	c.touchAt(ent, time.Time{})
+}
+
+// touchAt is touch restarting a sliding expire at now, or at time.Now if
+// now is zero.
+func (c *LRU) touchAt(ent *Element, now time.Time) {
+	if c.sliding > 0 {
+		c.setExpireAt(ent.Value.(*entry), c.sliding, now)
+	}
+	c.plugin.RecordAccess(ent.Value.(*entry).key)
+	switch c.order {
+	case AccessOrder:
 		c.evictList.MoveToFront(ent)
-		return ent.Value.(*entry).value, true
+		c.classMove(ent, true)
+	case GenerationOrder:
+		if kv := ent.Value.(*entry); kv.gen < 255 {
+			kv.gen++
+		}
 	}
-	return
+}
+
+// find returns the element of a key, live or expired, without recording
+// the access.
+func (c *LRU) find(key interface{}) (*Element, bool) {
+	ent, ok := c.items[key]
+	return ent, ok
+}
+
+// lookup finds the live entry of a key and records the access, leaving
+// the promotion to the caller
+func (c *LRU) lookup(key interface{}) (*Element, bool) {
+	return c.lookupAt(key, c.now())
+}
+
+// lookupAt is lookup with the expiration checked at now
+func (c *LRU) lookupAt(key interface{}, now time.Time) (*Element, bool) {
+	c.observe(key)
+	ent, ok := c.find(key)
+	if !ok || ent.Value.(*entry).expiredAt(now) {
+		c.stats.Misses++
+		if c.window != nil {
+			c.window.record(false, now)
+		}
+		if !ok && c.shadow != nil {
+			c.shadow.miss(key)
+		}
+		if c.metrics != nil {
+			c.metrics.OnMiss(key)
+		}
+		return nil, false
+	}
+	c.stats.Hits++
+	if c.origins != nil {
+		c.origin(ent.Value.(*entry).origin).Hits++
+	}
+	if c.window != nil {
+		c.window.record(true, now)
+	}
+	if c.metrics != nil {
+		c.metrics.OnHit(key)
+	}
+	if c.reuse != nil {
+		c.reuse.observe(ent.Value.(*entry), now)
+	}
+	if kv := ent.Value.(*entry); kv.hits == 0 {
+		if c.churn != nil {
+			c.churn.forget(key)
+		}
+	}
+	if kv := ent.Value.(*entry); kv.hits < math.MaxUint32 {
+		kv.hits++
+	}
+	return ent, true
 }
 
 // Check if a key is in the cache, without updating the recent-ness
 // or deleting it for being stale.
 func (c *LRU) Contains(key interface{}) (ok bool) {
-	if ent, ok := c.items[key]; ok {
-		if ent.Value.(*entry).IsExpired() {
+	if ent, ok := c.find(key); ok {
+		if c.expired(ent.Value.(*entry)) {
 			return false
 		}
 		return ok
@@ -162,90 +768,466 @@ func (c *LRU) Peek(key interface{}) (value interface{}, ok bool) {
 // time without updating the "recently used"-ness of the key.
 func (c *LRU) PeekWithExpireTime(key interface{}) (
 	value interface{}, expire *time.Time, ok bool) {
-	if ent, ok := c.items[key]; ok {
-		if ent.Value.(*entry).IsExpired() {
+	if ent, ok := c.find(key); ok {
+		if c.expired(ent.Value.(*entry)) {
 			return nil, nil, false
 		}
-		return ent.Value.(*entry).value, ent.Value.(*entry).expire, true
+		return ent.Value.(*entry).load(), ent.Value.(*entry).expire, true
 	}
 	return nil, nil, ok
 }
 
+// PeekWithPosition returns the key value (or undefined if not found) and
+// its distance from the most recently used entry, 0 being the front,
+// without updating the "recently used"-ness of the key. It walks the
+// list, so it is meant for debugging rather than hot paths.
+func (c *LRU) PeekWithPosition(key interface{}) (value interface{}, position int, ok bool) {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) {
+		return nil, 0, false
+	}
+	for e := c.evictList.Front(); e != ent; e = c.evictList.Next(e) {
+		position++
+	}
+	return ent.Value.(*entry).load(), position, true
+}
+
 // Remove removes the provided key from the cache, returning if the
 // key was contained.
 func (c *LRU) Remove(key interface{}) bool {
-	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
+	if c.tombstones != nil {
+		c.tombstones.bury(key, c.now())
+	}
+	if ent, ok := c.find(key); ok {
+		c.removeElement(ent, EvictRemoved)
 		return true
 	}
 	return false
 }
 
+// RemoveIf removes the provided key from the cache if pred holds for its
+// value, returning if it was removed. Expired entries are left alone.
+func (c *LRU) RemoveIf(key interface{}, pred func(value interface{}) bool) bool {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) || !pred(ent.Value.(*entry).load()) {
+		return false
+	}
+	if c.tombstones != nil {
+		c.tombstones.bury(key, c.now())
+	}
+	c.removeElement(ent, EvictRemoved)
+	return true
+}
+
 // RemoveOldest removes the oldest item from the cache.
 func (c *LRU) RemoveOldest() (interface{}, interface{}, bool) {
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, EvictRemoved)
 		kv := ent.Value.(*entry)
-		return kv.key, kv.value, true
+		return kv.key, kv.load(), true
 	}
 	return nil, nil, false
 }
 
+// EvictOldestFunc evicts the oldest entry, expired or not, whose key match
+// holds for, as if to make room for a new entry: the eviction callbacks
+// get EvictCapacity, or EvictExpired for an expired entry, and no
+// tombstone is left. It lets a wrapper enforce quotas of its own over
+// part of the keys. Returns the evicted key, or false if none matched.
+func (c *LRU) EvictOldestFunc(match func(key interface{}) bool) (key interface{}, ok bool) {
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		if kv := ent.Value.(*entry); match(kv.key) {
+			key = kv.key
+			c.evict(ent)
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Evict evicts a key like EvictOldestFunc. Returns false if the key is
+// not in the cache.
+func (c *LRU) Evict(key interface{}) bool {
+	ent, ok := c.find(key)
+	if !ok {
+		return false
+	}
+	c.evict(ent)
+	return true
+}
+
 // GetOldest returns the oldest entry
 func (c *LRU) GetOldest() (interface{}, interface{}, bool) {
 	ent := c.evictList.Back()
 	if ent != nil {
 		kv := ent.Value.(*entry)
-		return kv.key, kv.value, true
+		return kv.key, kv.load(), true
 	}
 	return nil, nil, false
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
+// Keys returns a slice of the keys in the cache, from oldest to newest,
+// without updating their recent-ness.
 func (c *LRU) Keys() []interface{} {
 	keys := make([]interface{}, len(c.items))
 	i := 0
-	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
 		keys[i] = ent.Value.(*entry).key
 		i++
 	}
 	return keys
 }
 
+// KeysMRU returns a slice of the keys in the cache, from newest to oldest,
+// without updating their recent-ness.
+func (c *LRU) KeysMRU() []interface{} {
+	keys := make([]interface{}, 0, len(c.items))
+	for ent := c.evictList.Front(); ent != nil; ent = c.evictList.Next(ent) {
+		keys = append(keys, ent.Value.(*entry).key)
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, from oldest to
+// newest, without updating their recent-ness.
+func (c *LRU) Values() []interface{} {
+	values := make([]interface{}, 0, len(c.items))
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		values = append(values, ent.Value.(*entry).load())
+	}
+	return values
+}
+
+// Range calls f for each live key and value in the cache, from oldest to
+// newest, without updating their recent-ness. If f returns false, Range
+// stops the iteration. f must not modify the cache.
+func (c *LRU) Range(f func(key, value interface{}) bool) {
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		kv := ent.Value.(*entry)
+		if c.expired(kv) {
+			continue
+		}
+		if !f(kv.key, kv.load()) {
+			return
+		}
+	}
+}
+
+// RangePromote is like Range but marks each entry passed to f as
+// recently used, as a Get would, for the bulk jobs whose accesses should
+// count. The entries are promoted once the iteration is over, in the
+// order they were visited. f must not modify the cache.
+func (c *LRU) RangePromote(f func(key, value interface{}) bool) {
+	var visited []*Element
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		kv := ent.Value.(*entry)
+		if c.expired(kv) {
+			continue
+		}
+		visited = append(visited, ent)
+		if !f(kv.key, kv.load()) {
+			break
+		}
+	}
+	for _, ent := range visited {
+		c.touch(ent)
+	}
+}
+
+// FindKeys returns the keys of the live entries whose value satisfies
+// pred, from oldest to newest, stopping after limit keys if limit > 0.
+// Like Range, it does not update their recent-ness.
+func (c *LRU) FindKeys(pred func(value interface{}) bool, limit int) []interface{} {
+	var keys []interface{}
+	c.Range(func(key, value interface{}) bool {
+		if pred(value) {
+			keys = append(keys, key)
+		}
+		return limit <= 0 || len(keys) < limit
+	})
+	return keys
+}
+
+// DeleteExpired removes the expired entries from the cache, invoking the
+// eviction callback for each of them, and returns how many were removed.
+func (c *LRU) DeleteExpired() int {
+	c.checkClock(c.now())
+	removed := 0
+	for ent := c.evictList.Back(); ent != nil; {
+		prev := c.evictList.Prev(ent)
+		if c.reap(ent) {
+			removed++
+		}
+		ent = prev
+	}
+	return removed
+}
+
+// JanitorInterval returns the interval set by WithJanitor, lowered to
+// the precision set by WithExpiryPrecision, or 0 if expired entries are
+// not reaped in the background.
+func (c *LRU) JanitorInterval() time.Duration {
+	if c.precision > 0 && (c.janitor <= 0 || c.janitor > c.precision) {
+		return c.precision
+	}
+	return c.janitor
+}
+
+// NextExpiry returns the earliest expire time among the live entries
+// in the cache. ok is false if no live entry has an expire time.
+func (c *LRU) NextExpiry() (next time.Time, ok bool) {
+	for _, ent := range c.items {
+		kv := ent.Value.(*entry)
+		if kv.expire == nil || c.expired(kv) {
+			continue
+		}
+		if !ok || kv.expire.Before(next) {
+			next = *kv.expire
+			ok = true
+		}
+	}
+	return next, ok
+}
+
+// ExpiringBefore returns the keys of the live entries that will expire
+// before t, ordered from soonest to latest expiration.
+func (c *LRU) ExpiringBefore(t time.Time) []interface{} {
+	var ents []*entry
+	for _, ent := range c.items {
+		kv := ent.Value.(*entry)
+		if kv.expire == nil || c.expired(kv) || !kv.expire.Before(t) {
+			continue
+		}
+		ents = append(ents, kv)
+	}
+	sort.Slice(ents, func(i, j int) bool {
+		return ents[i].expire.Before(*ents[j].expire)
+	})
+	keys := make([]interface{}, len(ents))
+	for i, kv := range ents {
+		keys[i] = kv.key
+	}
+	return keys
+}
+
 // Len returns the number of items in the cache.
 func (c *LRU) Len() int {
 	return c.evictList.Len()
 }
 
-// Resize changes the cache size.
+// Stats returns the lookup counters of the cache along with the number
+// and cost of resident expired entries, which takes a scan of the cache.
+func (c *LRU) Stats() Stats {
+	stats := c.stats
+	if c.churn != nil {
+		stats.Churning = len(c.churn.churning(c.now()))
+	}
+	for _, ent := range c.items {
+		if kv := ent.Value.(*entry); c.expired(kv) {
+			stats.Expired++
+			stats.ExpiredCost += kv.cost
+		}
+	}
+	return stats
+}
+
+// Counters returns the counters of Stats without the scan of the cache,
+// leaving Expired, ExpiredCost and Churning zero, for the callers polling
+// them often.
+func (c *LRU) Counters() Stats {
+	return c.stats
+}
+
+// Lookups returns the hit and miss counters of Stats without the scan.
+func (c *LRU) Lookups() (hits, misses uint64) {
+	return c.stats.Hits, c.stats.Misses
+}
+
+// Cap returns the maximum number of items the cache can hold.
+func (c *LRU) Cap() int {
+	return c.size
+}
+
+// Resize changes the cache size, evicting the entries above it at once.
+// Growing a cache that preallocated its entries allocates the new ones
+// up front too, while a cache with WithInitialCapacity keeps allocating
+// them as it fills up. Shrinking releases the free entries above the new
+// size.
 func (c *LRU) Resize(size int) (evicted int) {
-	diff := c.Len() - size
-	if diff < 0 {
-		diff = 0
+	if c.strict && size <= 0 {
+		panic(&MisuseError{Op: "Resize", Err: ErrInvalidSize})
+	}
+	c.deferred = false
+	for c.Len() > size && c.removeVictim() {
+		evicted++
+	}
+	if size > c.size && c.initial >= c.size {
+		c.initial = size
+	}
+	c.size = size
+	c.trimFree()
+	c.preallocate()
+	return evicted
+}
+
+// deferredEvictions is the number of entries an Add evicts while a
+// deferred shrink is pending: one to make room for the new entry and one
+// to shrink the cache
+const deferredEvictions = 2
+
+// ResizeDeferred changes the cache size like Resize, but does not evict
+// the entries above a smaller size at once: each Add of a new key evicts
+// an extra entry until the cache fits, spreading the evictions and their
+// callbacks over the following Adds instead of firing them in a burst.
+// Len may exceed Cap until then.
+func (c *LRU) ResizeDeferred(size int) {
+	if c.Len() <= size {
+		c.Resize(size)
+		return
 	}
-	for i := 0; i < diff; i++ {
-		c.removeOldest()
+	if c.strict && size <= 0 {
+		panic(&MisuseError{Op: "ResizeDeferred", Err: ErrInvalidSize})
 	}
 	c.size = size
-	return diff
+	c.deferred = true
+	c.trimFree()
+}
+
+// makeRoom evicts entries until a new one fits, or only a few of them
+// while a deferred shrink is pending. Returns true if an eviction
+// occurred.
+func (c *LRU) makeRoom() bool {
+	if c.batch {
+		return false
+	}
+	evict := false
+	for n := 0; c.evictList.Len() >= c.size && c.removeVictim(); n++ {
+		evict = true
+		if c.deferred && n+1 == deferredEvictions {
+			break
+		}
+	}
+	if c.deferred && c.evictList.Len() < c.size {
+		c.deferred = false
+	}
+	return evict
+}
+
+// trimFree drops the free entries above the size of the cache
+func (c *LRU) trimFree() {
+	for c.freeList.Len() > 0 && c.evictList.Len()+c.freeList.Len() > c.size {
+		c.freeList.Remove(c.freeList.Front())
+	}
+}
+
+// ttl returns the time to live of an entry added with the given expire,
+// falling back to the default expire, or else the sliding expire, and
+// clamped to the TTL bounds. 0 means the entry never expires.
+func (c *LRU) ttl(expire time.Duration) time.Duration {
+	if expire <= 0 {
+		expire = c.expire
+	}
+	if expire <= 0 {
+		expire = c.sliding
+	}
+	if expire > 0 && expire < c.minTTL {
+		expire = c.minTTL
+	}
+	if c.maxTTL > 0 && (expire <= 0 || expire > c.maxTTL) {
+		expire = c.maxTTL
+	}
+	return expire
+}
+
+// removeVictim removes the item chosen by the eviction policy, returning
+// false if no item can be evicted.
+func (c *LRU) removeVictim() bool {
+	ent := c.victim()
+	if ent == nil {
+		return false
+	}
+	c.evict(ent)
+	return true
+}
+
+// evict removes a victim of the eviction policy
+func (c *LRU) evict(ent *Element) {
+	kv := ent.Value.(*entry)
+	if c.churn != nil && kv.hits == 0 {
+		c.churn.record(kv.key, c.now())
+	}
+	reason := EvictCapacity
+	if c.expired(kv) {
+		reason = EvictExpired
+	} else {
+		c.stats.Evicted++
+		if kv.expire != nil {
+			c.stats.EvictedBeforeExpiry++
+		}
+		if c.shadow != nil {
+			c.shadow.record(kv.key)
+		}
+	}
+	if c.metrics != nil {
+		if reason == EvictExpired {
+			c.metrics.OnExpire(kv.key)
+		} else {
+			c.metrics.OnEvict(kv.key)
+		}
+	}
+	c.removeElement(ent, reason)
+}
+
+// victim returns the item the eviction policy would evict among the
+// evictable items of the lowest priority present in the cache.
+func (c *LRU) victim() *Element {
+	now := c.now()
+	for priority := PriorityLow; priority < numPriorities; priority++ {
+		if c.counts[priority] == 0 {
+			continue
+		}
+		candidate := func(ent *Element) bool {
+			kv := ent.Value.(*entry)
+			return kv.priority == priority && kv.evictable(now)
+		}
+		if ent := c.pluginVictim(priority, candidate); ent != nil {
+			return ent
+		}
+	}
+	return nil
 }
 
 // removeOldest removes the oldest item from the cache.
 func (c *LRU) removeOldest() {
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, EvictRemoved)
 	}
 }
 
 // removeElement is used to remove a given list element from the cache
-func (c *LRU) removeElement(e *Element) {
+func (c *LRU) removeElement(e *Element, reason EvictReason) {
+	if c.hand == e {
+		c.hand = c.evictList.Prev(e)
+	}
 	c.evictList.Remove(e)
+	c.classRemove(e)
 	c.freeList.PushElementFront(e)
 	kv := e.Value.(*entry)
+	kv.version++
 	delete(c.items, kv.key)
-	if c.onEvict != nil {
-		c.onEvict(kv.key, kv.value)
+	c.unindex(e)
+	c.plugin.Remove(kv.key)
+	c.counts[kv.priority]--
+	c.cost -= kv.cost
+	c.release(kv)
+	if c.origins != nil {
+		s := c.origin(kv.origin)
+		s.Entries--
+		if reason == EvictCapacity || reason == EvictExpired {
+			s.Evictions++
+		}
 	}
+	c.evicted(kv, reason)
 }
@@ -1,6 +1,7 @@
 package simplelru
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -252,3 +253,491 @@ func BenchmarkAdd(b *testing.B) {
 		}
 	})
 }
+
+// Test that NextExpiry and ExpiringBefore report upcoming expirations
+func TestLRU_NextExpiry(t *testing.T) {
+	l, err := NewLRU(4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, ok := l.NextExpiry(); ok {
+		t.Fatalf("empty cache should have no expiry")
+	}
+
+	now := time.Now()
+	l.AddEx(1, 1, 3*time.Minute)
+	l.AddEx(2, 2, time.Minute)
+	l.Add(3, 3)
+	l.AddEx(4, 4, 2*time.Minute)
+
+	next, ok := l.NextExpiry()
+	if !ok {
+		t.Fatalf("should have an expiry")
+	}
+	if next.Before(now.Add(time.Minute)) || next.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("bad next expiry: %v", next)
+	}
+
+	keys := l.ExpiringBefore(now.Add(150 * time.Second))
+	if len(keys) != 2 || keys[0] != 2 || keys[1] != 4 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if keys := l.ExpiringBefore(now); len(keys) != 0 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+// Test that Get updates the lookup counters
+func TestLRU_Stats(t *testing.T) {
+	l, err := NewLRU(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	l.Get(1)
+	l.Get(2)
+	l.Get(3)
+	l.Peek(1)
+
+	if s := l.Stats(); s.Hits != 1 || s.Misses != 2 || s.Expired != 1 || s.ExpiredCost != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	if s := l.Counters(); s.Hits != 1 || s.Misses != 2 || s.Expired != 0 {
+		t.Fatalf("bad counters: %+v", s)
+	}
+
+	c, _ := NewLRUWithOptions(4, nil, WithMaxCost(100, func(key, value interface{}) int64 {
+		return int64(len(value.(string)))
+	}))
+	c.AddEx("a", "aaa", time.Nanosecond)
+	c.AddEx("b", "bbbbb", time.Nanosecond)
+	c.Add("c", "cc")
+	time.Sleep(time.Millisecond)
+	if s := c.Stats(); s.Expired != 2 || s.ExpiredCost != 8 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
+
+// Test that a grown cache preallocates and holds the new items
+func TestLRU_ResizeGrow(t *testing.T) {
+	l, err := NewLRU(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+
+	if evicted := l.Resize(4); evicted != 0 {
+		t.Fatalf("bad evicted: %v", evicted)
+	}
+	if l.freeList.Len() != 2 {
+		t.Fatalf("grow should preallocate: %v", l.freeList.Len())
+	}
+	for i := 3; i <= 4; i++ {
+		if l.Add(i, i) {
+			t.Fatalf("should not evict")
+		}
+	}
+	if l.Len() != 4 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if !l.Add(5, 5) || l.Contains(1) {
+		t.Fatalf("should evict oldest")
+	}
+}
+
+// Test that a cache with an initial capacity grows lazily, and that
+// shrinking releases the free entries
+func TestLRU_ResizeFreeList(t *testing.T) {
+	l, _ := NewLRUWithOptions(4, nil, WithInitialCapacity(1))
+	l.Resize(8)
+	if l.freeList.Len() != 1 {
+		t.Fatalf("grow should stay lazy: %v", l.freeList.Len())
+	}
+
+	l, _ = NewLRU(8, nil)
+	l.Add(1, 1)
+	l.Resize(2)
+	if l.freeList.Len() != 1 {
+		t.Fatalf("shrink should release: %v", l.freeList.Len())
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+// Test that ResizeDeferred spreads the evictions over the next Adds
+func TestLRU_ResizeDeferred(t *testing.T) {
+	evicted := 0
+	l, _ := NewLRU(8, func(k, v interface{}) { evicted++ })
+	for i := 0; i < 8; i++ {
+		l.Add(i, i)
+	}
+	l.ResizeDeferred(4)
+	if evicted != 0 || l.Len() != 8 || l.Cap() != 4 {
+		t.Fatalf("should not evict yet: %v %v", evicted, l.Len())
+	}
+	for i := 8; i < 13; i++ {
+		l.Add(i, i)
+		if err := l.CheckInvariants(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if evicted != 9 || l.Len() != 4 || l.deferred {
+		t.Fatalf("bad shrink: %v %v", evicted, l.Len())
+	}
+	if keys := l.Keys(); keys[0] != 9 {
+		t.Fatalf("should evict the oldest: %v", keys)
+	}
+
+	// Updates do not evict
+	l.ResizeDeferred(2)
+	l.Add(12, 12)
+	if evicted != 9 || l.Len() != 4 {
+		t.Fatalf("bad update: %v %v", evicted, l.Len())
+	}
+
+	// Growing back is immediate
+	l.ResizeDeferred(8)
+	if l.deferred || l.Cap() != 8 {
+		t.Fatalf("should not defer a grow")
+	}
+}
+
+// Test that Clone copies entries in order and is independent
+func TestLRU_Clone(t *testing.T) {
+	l, err := NewLRU(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, []int{1})
+	l.AddEx(2, []int{2}, time.Minute)
+	l.AddWithPriority(3, []int{3}, PriorityHigh)
+	l.Get(1)
+
+	c := l.Clone()
+	keys := c.Keys()
+	if len(keys) != 3 || keys[0] != 2 || keys[1] != 3 || keys[2] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if _, exp, _ := c.PeekWithExpireTime(2); exp == nil {
+		t.Fatalf("expire should be kept")
+	}
+
+	c.Add(4, []int{4})
+	if !l.Contains(2) || l.Contains(4) {
+		t.Fatalf("clone should be independent")
+	}
+	if c.Contains(2) || !c.Contains(3) {
+		t.Fatalf("clone should evict by LRU and priority: %v", c.Keys())
+	}
+
+	v, _ := l.Peek(1)
+	v.([]int)[0] = 10
+	if v, _ := c.Peek(1); v.([]int)[0] != 10 {
+		t.Fatalf("clone should be shallow")
+	}
+
+	d := l.CloneFunc(func(v interface{}) interface{} {
+		return append([]int(nil), v.([]int)...)
+	})
+	v, _ = l.Peek(1)
+	v.([]int)[0] = 20
+	if v, _ := d.Peek(1); v.([]int)[0] != 10 {
+		t.Fatalf("clone should be deep")
+	}
+	if d.Len()+d.freeList.Len() != 3 {
+		t.Fatalf("bad free list: %v", d.freeList.Len())
+	}
+}
+
+// Test that PeekWithPosition reports the distance from the front
+func TestLRU_PeekWithPosition(t *testing.T) {
+	l, err := NewLRU(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+
+	if v, pos, ok := l.PeekWithPosition(1); !ok || v != 1 || pos != 2 {
+		t.Fatalf("bad: %v, %v, %v", v, pos, ok)
+	}
+	if _, pos, _ := l.PeekWithPosition(3); pos != 0 {
+		t.Fatalf("bad position: %v", pos)
+	}
+	if _, pos, _ := l.PeekWithPosition(1); pos != 2 {
+		t.Fatalf("peek should not promote: %v", pos)
+	}
+	if _, _, ok := l.PeekWithPosition(4); ok {
+		t.Fatalf("should not be found")
+	}
+}
+
+// Test that GetMany promotes hits like successive Gets
+func TestLRU_GetMany(t *testing.T) {
+	l, err := NewLRU(5, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ref, _ := NewLRU(5, nil)
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+		ref.Add(i, i)
+	}
+
+	keys := []interface{}{1, 7, 3, 1, 0}
+	values, found := l.GetMany(keys)
+	for _, k := range keys {
+		ref.Get(k)
+	}
+	for i, k := range keys {
+		v, ok := ref.Peek(k)
+		if found[i] != ok || values[i] != v {
+			t.Fatalf("bad result for %v: %v, %v", k, values[i], found[i])
+		}
+	}
+	a, b := l.Keys(), ref.Keys()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("bad order: %v, expected %v", a, b)
+		}
+	}
+	if l.Stats().Hits != 4 || l.Stats().Misses != 1 {
+		t.Fatalf("bad stats: %+v", l.Stats())
+	}
+
+	// The list must still be well formed in both directions
+	n := 0
+	for e := l.evictList.Front(); e != nil; e = e.Next() {
+		n++
+	}
+	for e := l.evictList.Back(); e != nil; e = e.Prev() {
+		n--
+	}
+	if n != 0 {
+		t.Fatalf("list is corrupted")
+	}
+	l.GetMany(nil)
+}
+
+func TestLRU_Range(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Millisecond)
+	l.Add(3, 3)
+	time.Sleep(2 * time.Millisecond)
+
+	var keys []interface{}
+	l.Range(func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 2 || keys[0] != 1 || keys[1] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestLRU_DeleteExpired(t *testing.T) {
+	evicted := 0
+	l, _ := NewLRU(4, func(k, v interface{}) { evicted++ })
+	l.AddEx(1, 1, time.Millisecond)
+	l.Add(2, 2)
+	l.AddEx(3, 3, time.Millisecond)
+	l.AddEx(4, 4, time.Hour)
+	time.Sleep(2 * time.Millisecond)
+
+	if n := l.DeleteExpired(); n != 2 || evicted != 2 {
+		t.Fatalf("bad deleted: %d %d", n, evicted)
+	}
+	if l.Len() != 2 || l.Contains(1) || !l.Contains(4) {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if n := l.DeleteExpired(); n != 0 {
+		t.Fatalf("bad deleted: %d", n)
+	}
+}
+
+func TestLRU_FindKeys(t *testing.T) {
+	l, _ := NewLRU(8, nil)
+	for i := 0; i < 8; i++ {
+		l.Add(i, i%2)
+	}
+	odd := func(v interface{}) bool { return v == 1 }
+	if keys := l.FindKeys(odd, 0); len(keys) != 4 || keys[0] != 1 || keys[3] != 7 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if keys := l.FindKeys(odd, 2); len(keys) != 2 || keys[1] != 3 {
+		t.Fatalf("bad limited keys: %v", keys)
+	}
+}
+
+func TestLRU_ValuesKeysMRU(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	for i := 0; i < 3; i++ {
+		l.Add(i, i*10)
+	}
+	l.Get(0)
+	keys := l.KeysMRU()
+	if len(keys) != 3 || keys[0] != 0 || keys[1] != 2 || keys[2] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	values := l.Values()
+	if len(values) != 3 || values[0] != 10 || values[2] != 0 {
+		t.Fatalf("bad values: %v", values)
+	}
+}
+
+func TestLRU_ContainsOrAdd(t *testing.T) {
+	l, _ := NewLRUWithExpire(1, time.Hour, nil)
+	if ok, evicted := l.ContainsOrAdd(1, 1); ok || evicted {
+		t.Fatalf("bad first add")
+	}
+	if _, expire, _ := l.PeekWithExpireTime(1); expire == nil {
+		t.Fatalf("default expire should apply")
+	}
+	if ok, _ := l.ContainsOrAdd(1, 2); !ok {
+		t.Fatalf("key should be found")
+	}
+	if v, _ := l.Peek(1); v != 1 {
+		t.Fatalf("value should not be replaced: %v", v)
+	}
+
+	if prev, ok, evicted := l.PeekOrAdd(1, 3); !ok || evicted || prev != 1 {
+		t.Fatalf("bad peek: %v %v %v", prev, ok, evicted)
+	}
+	if prev, ok, evicted := l.PeekOrAdd(2, 2); ok || !evicted || prev != nil {
+		t.Fatalf("bad add: %v %v %v", prev, ok, evicted)
+	}
+}
+
+func TestLRU_ScansDoNotPromote(t *testing.T) {
+	l, _ := NewLRU(5, nil)
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	want := l.Keys()
+	l.Keys()
+	l.KeysMRU()
+	l.Values()
+	l.Range(func(key, value interface{}) bool { return true })
+	l.FindKeys(func(value interface{}) bool { return true }, 0)
+	l.SampleKeys(3, 1)
+	l.Dump()
+	for i, k := range l.Keys() {
+		if k != want[i] {
+			t.Fatalf("scans should not promote: %v, want %v", l.Keys(), want)
+		}
+	}
+	if s := l.Stats(); s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("scans should not count as lookups: %+v", s)
+	}
+}
+
+func TestLRU_RangePromote(t *testing.T) {
+	l, _ := NewLRU(5, nil)
+	for i := 0; i < 5; i++ {
+		l.Add(i, i)
+	}
+	var seen []interface{}
+	l.RangePromote(func(key, value interface{}) bool {
+		seen = append(seen, key)
+		return len(seen) < 2
+	})
+	if len(seen) != 2 || seen[0] != 0 || seen[1] != 1 {
+		t.Fatalf("bad keys seen: %v", seen)
+	}
+	keys := l.Keys()
+	for i, want := range []interface{}{2, 3, 4, 0, 1} {
+		if keys[i] != want {
+			t.Fatalf("visited keys should be promoted in order: %v", keys)
+		}
+	}
+}
+
+func TestLRU_RemoveIf(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	l.Add(1, "stale")
+	l.Add(2, "fresh")
+	l.AddEx(3, "stale", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	isStale := func(v interface{}) bool { return v == "stale" }
+
+	if !l.RemoveIf(1, isStale) || l.Contains(1) {
+		t.Fatalf("stale value should be removed")
+	}
+	if l.RemoveIf(2, isStale) || !l.Contains(2) {
+		t.Fatalf("fresh value should be kept")
+	}
+	if l.RemoveIf(3, isStale) || l.RemoveIf(4, isStale) {
+		t.Fatalf("expired and absent keys should not be removed")
+	}
+}
+
+func TestLRU_SetMany(t *testing.T) {
+	evicted := 0
+	l, _ := NewLRU(4, func(key, value interface{}) { evicted++ })
+	for i := 0; i < 3; i++ {
+		l.Add(i, i)
+	}
+	victims := l.SetMany([]KV{{Key: 10, Value: 10}, {Key: 0, Value: 100}, {Key: 11, Value: 11}})
+	if len(victims) != 1 || victims[0] != (KV{Key: 1, Value: 1}) || evicted != 1 {
+		t.Fatalf("bad victims: %v", victims)
+	}
+	if keys := l.Keys(); !reflect.DeepEqual(keys, []interface{}{2, 10, 0, 11}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if v, _ := l.Peek(0); v != 100 {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	// A batch larger than the cache evicts its own oldest entries
+	batch := make([]KV, 6)
+	for i := range batch {
+		batch[i] = KV{Key: 20 + i, Value: i}
+	}
+	victims = l.SetMany(batch)
+	if len(victims) != 6 || victims[4] != (KV{Key: 20, Value: 0}) || l.Len() != 4 || l.Contains(21) {
+		t.Fatalf("bad victims: %v keys: %v", victims, l.Keys())
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Cost budgets are enforced once too
+	l, _ = NewLRUWithCost(10, func(key, value interface{}) int64 { return value.(int64) }, nil)
+	l.Add("a", int64(5))
+	victims = l.SetMany([]KV{{Key: "b", Value: int64(4)}, {Key: "c", Value: int64(3)}})
+	if len(victims) != 1 || victims[0].Key != "a" || l.Cost() != 7 {
+		t.Fatalf("bad victims: %v cost: %v", victims, l.Cost())
+	}
+}
+
+func TestLRU_EvictOldestFunc(t *testing.T) {
+	var reasons []EvictReason
+	l, _ := NewLRUWithOptions(4, nil, WithTombstones(time.Hour, nil),
+		WithEvictCallbackWithReason(func(key, value interface{}, reason EvictReason) {
+			reasons = append(reasons, reason)
+		}))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	even := func(key interface{}) bool { return key.(int)%2 == 0 }
+	if key, ok := l.EvictOldestFunc(even); !ok || key != 2 || l.Contains(2) {
+		t.Fatalf("bad evicted key: %v %v", key, ok)
+	}
+	if _, ok := l.EvictOldestFunc(even); ok {
+		t.Fatalf("no key should match")
+	}
+	if len(reasons) != 1 || reasons[0] != EvictCapacity || l.IsTombstoned(2) {
+		t.Fatalf("bad eviction: %v", reasons)
+	}
+	if !l.Evict(3) || l.Evict(3) || l.IsTombstoned(3) || len(reasons) != 2 || reasons[1] != EvictCapacity {
+		t.Fatalf("bad eviction: %v", reasons)
+	}
+}
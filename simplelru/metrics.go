@@ -0,0 +1,25 @@
+package simplelru
+
+// MetricsRecorder is notified of the activity of a cache, e.g. to export
+// it to a monitoring system. It is called synchronously, under the lock
+// of the thread-safe caches, so it should be cheap and must not call
+// back into the cache.
+type MetricsRecorder interface {
+	// OnHit is called when a Get finds a live entry
+	OnHit(key interface{})
+	// OnMiss is called when a Get finds no live entry
+	OnMiss(key interface{})
+	// OnAdd is called when an entry is added or updated
+	OnAdd(key interface{})
+	// OnEvict is called when a live entry is evicted to make room
+	OnEvict(key interface{})
+	// OnExpire is called when an expired entry is removed
+	OnExpire(key interface{})
+}
+
+// WithMetrics sets the recorder notified of the activity of the cache.
+func WithMetrics(r MetricsRecorder) Option {
+	return func(c *LRU) {
+		c.metrics = r
+	}
+}
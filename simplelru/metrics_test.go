@@ -0,0 +1,40 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+type countingRecorder struct {
+	hits, misses, adds, evictions, expirations int
+}
+
+func (r *countingRecorder) OnHit(interface{})    { r.hits++ }
+func (r *countingRecorder) OnMiss(interface{})   { r.misses++ }
+func (r *countingRecorder) OnAdd(interface{})    { r.adds++ }
+func (r *countingRecorder) OnEvict(interface{})  { r.evictions++ }
+func (r *countingRecorder) OnExpire(interface{}) { r.expirations++ }
+
+func TestLRU_Metrics(t *testing.T) {
+	r := &countingRecorder{}
+	l, _ := NewLRUWithOptions(2, nil, WithMetrics(r))
+	l.Add(1, 1)
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Millisecond)
+	l.Get(1)
+	l.Get(3)
+	time.Sleep(2 * time.Millisecond)
+
+	// 2 is the victim but has expired already
+	l.Add(3, 3)
+	l.Add(4, 4)
+	l.AddEx(5, 5, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	l.DeleteExpired()
+	l.Remove(4)
+
+	want := countingRecorder{hits: 1, misses: 1, adds: 6, evictions: 2, expirations: 2}
+	if *r != want {
+		t.Fatalf("bad metrics: %+v", *r)
+	}
+}
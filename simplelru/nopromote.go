@@ -0,0 +1,41 @@
+package simplelru
+
+// PeekAndUpdate replaces the value of a live key without updating its
+// recent-ness or expire time, so that background refreshers do not
+// promote entries no client requested. Returns false, leaving the cache
+// untouched, if the key is absent, expired or immutable.
+func (c *LRU) PeekAndUpdate(key, value interface{}) bool {
+	ent, ok := c.find(key)
+	if !ok {
+		return false
+	}
+	kv := ent.Value.(*entry)
+	if c.expired(kv) || kv.immutable {
+		return false
+	}
+	if c.onReason != nil {
+		c.onReason(key, kv.load(), EvictReplaced)
+	}
+	c.setValue(kv, value)
+	cost := c.costOf(key, value) + c.overhead
+	c.cost += cost - kv.cost
+	kv.cost = cost
+	c.fitCost(0)
+	return true
+}
+
+// AddIfAbsent adds a value with the default expire if the key is absent
+// or expired, as the oldest entry rather than the newest so that it does
+// not displace entries in use. Returns true if the value was added.
+func (c *LRU) AddIfAbsent(key, value interface{}) bool {
+	if c.Contains(key) {
+		return false
+	}
+	c.Add(key, value)
+	ent, ok := c.find(key)
+	if ok {
+		c.evictList.MoveToBack(ent)
+		c.classMove(ent, false)
+	}
+	return ok
+}
@@ -0,0 +1,64 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_PeekAndUpdate(t *testing.T) {
+	replaced := 0
+	l, _ := NewLRUWithOptions(3, nil, WithEvictCallbackWithReason(func(k, v interface{}, reason EvictReason) {
+		if reason == EvictReplaced {
+			replaced++
+		}
+	}))
+	l.AddEx(1, 1, time.Hour)
+	l.Add(2, 2)
+	_, before, _ := l.PeekWithExpireTime(1)
+
+	if !l.PeekAndUpdate(1, 10) || replaced != 1 {
+		t.Fatalf("should update")
+	}
+	if v, expire, _ := l.PeekWithExpireTime(1); v != 10 || *expire != *before {
+		t.Fatalf("bad update: %v %v", v, expire)
+	}
+	if k, _, _ := l.GetOldest(); k != 1 {
+		t.Fatalf("update should not promote: %v", k)
+	}
+	if l.PeekAndUpdate(3, 3) || l.Contains(3) {
+		t.Fatalf("should not insert")
+	}
+
+	l.AddEx(4, 4, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if l.PeekAndUpdate(4, 40) {
+		t.Fatalf("should not update an expired key")
+	}
+}
+
+func TestLRU_AddIfAbsent(t *testing.T) {
+	l, _ := NewLRU(3, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.AddIfAbsent(3, 3) {
+		t.Fatalf("should add")
+	}
+	if k, _, _ := l.GetOldest(); k != 3 {
+		t.Fatalf("should be added as the oldest: %v", l.Keys())
+	}
+	if l.AddIfAbsent(1, 10) {
+		t.Fatalf("should not replace")
+	}
+	if v, _ := l.Peek(1); v != 1 {
+		t.Fatalf("bad value: %v", v)
+	}
+	if k, _, _ := l.GetOldest(); k != 3 {
+		t.Fatalf("should not promote: %v", l.Keys())
+	}
+
+	// The entry added without promotion is the first evicted
+	l.AddIfAbsent(4, 4)
+	if l.Contains(3) || !l.Contains(4) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
@@ -0,0 +1,101 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// Option configures an LRU at construction.
+type Option func(*LRU)
+
+// EvictionOrder selects which entries are considered the oldest.
+type EvictionOrder int
+
+const (
+	// AccessOrder evicts the least recently used entry: Get and updating
+	// Adds move an entry to the front. This is the default.
+	AccessOrder EvictionOrder = iota
+
+	// InsertionOrder evicts the least recently inserted entry: the order
+	// is fixed when a key is first added, turning the cache into a FIFO.
+	InsertionOrder
+
+	// GenerationOrder approximates AccessOrder for write-heavy workloads:
+	// accesses bump an 8-bit generation counter instead of moving the
+	// entry, and the evictor scans from the oldest entry, aging the
+	// counters it passes over until it finds one at zero.
+	GenerationOrder
+)
+
+// EvictionPolicy selects which entry is evicted when the cache is full.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the oldest entry. This is the default.
+	EvictLRU EvictionPolicy = iota
+
+	// EvictMRU evicts the newest entry, which suits cyclic scans larger
+	// than the cache where the entry just used is the last to be reused.
+	EvictMRU
+
+	// EvictRandom evicts an entry chosen uniformly at random. It is the
+	// cheapest policy and mostly useful as a baseline or for uniform
+	// access patterns.
+	EvictRandom
+)
+
+// WithExpire sets the default expire time of the entries.
+func WithExpire(expire time.Duration) Option {
+	return func(c *LRU) {
+		c.expire = expire
+	}
+}
+
+// WithInitialCapacity preallocates n entries rather than the whole size
+// of the cache, so that a cache sized for the worst case starts small.
+// The other entries are allocated as the cache fills up, and are reused
+// once evicted like the preallocated ones.
+func WithInitialCapacity(n int) Option {
+	return func(c *LRU) {
+		c.initial = n
+	}
+}
+
+// WithEvictionOrder sets the order entries are evicted in.
+func WithEvictionOrder(order EvictionOrder) Option {
+	return func(c *LRU) {
+		c.order = order
+	}
+}
+
+// WithEvictionPolicy sets the policy choosing the entry to evict.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *LRU) {
+		c.policy = policy
+	}
+}
+
+// WithMinTTL raises any shorter time to live, passed to AddEx or set as
+// the default expire, to min, guarding against upstream data asking for
+// pathologically short expirations.
+func WithMinTTL(min time.Duration) Option {
+	return func(c *LRU) {
+		c.minTTL = min
+	}
+}
+
+// WithMaxTTL lowers any longer time to live to max. Entries that would
+// never expire get max as well.
+func WithMaxTTL(max time.Duration) Option {
+	return func(c *LRU) {
+		c.maxTTL = max
+	}
+}
+
+// WithJanitor asks for the expired entries to be deleted every interval
+// in the background. The LRU itself is not safe for concurrent use, so
+// the janitor is run by the thread-safe lru.Cache built on top of it.
+func WithJanitor(interval time.Duration) Option {
+	return func(c *LRU) {
+		c.janitor = interval
+	}
+}
@@ -0,0 +1,172 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_InsertionOrder(t *testing.T) {
+	l, err := NewLRUWithOptions(2, nil, WithEvictionOrder(InsertionOrder))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Add(1, 10)
+	l.Add(3, 3)
+
+	if l.Contains(1) {
+		t.Fatalf("1 was inserted first and should be evicted")
+	}
+	if v, ok := l.Peek(2); !ok || v != 2 {
+		t.Fatalf("bad: %v", v)
+	}
+	if keys := l.Keys(); keys[0] != 2 || keys[1] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestLRU_AccessOrder(t *testing.T) {
+	l, err := NewLRUWithOptions(2, nil, WithEvictionOrder(AccessOrder))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Add(3, 3)
+
+	if l.Contains(2) || !l.Contains(1) {
+		t.Fatalf("2 was least recently used and should be evicted")
+	}
+}
+
+func TestLRU_EvictMRU(t *testing.T) {
+	evicted := []interface{}{}
+	l, err := NewLRUWithOptions(2, func(k, v interface{}) {
+		evicted = append(evicted, k)
+	}, WithEvictionPolicy(EvictMRU))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Add(3, 3)
+	if l.Contains(1) || !l.Contains(2) || !l.Contains(3) {
+		t.Fatalf("1 was most recently used and should be evicted")
+	}
+
+	l.Resize(1)
+	if !l.Contains(2) {
+		t.Fatalf("3 was most recently used and should be evicted")
+	}
+	if len(evicted) != 2 || evicted[0] != 1 || evicted[1] != 3 {
+		t.Fatalf("bad evicted: %v", evicted)
+	}
+}
+
+func TestLRU_EvictRandom(t *testing.T) {
+	l, err := NewLRUWithOptions(16, nil, WithEvictionPolicy(EvictRandom))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		l.Add(i, i)
+		if l.Len() > 16 {
+			t.Fatalf("bad len: %v", l.Len())
+		}
+	}
+	if !l.Contains(999) {
+		t.Fatalf("the newest entry should be present")
+	}
+	if len(l.items) != l.evictList.Len() {
+		t.Fatalf("map and list out of sync")
+	}
+
+	// The victims are drawn uniformly
+	counts := make(map[interface{}]int)
+	for i := 0; i < 16000; i++ {
+		key, _ := l.Policy().Victim(func(key interface{}) bool { return true })
+		counts[key]++
+	}
+	for key, n := range counts {
+		if n < 700 || n > 1300 {
+			t.Fatalf("bad draws of %v: %v", key, counts)
+		}
+	}
+	if len(counts) != 16 {
+		t.Fatalf("bad draws: %v", counts)
+	}
+}
+
+func TestLRU_TTLBounds(t *testing.T) {
+	l, err := NewLRUWithOptions(4, nil, WithMinTTL(time.Minute), WithMaxTTL(time.Hour))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	now := time.Now()
+	l.AddEx(1, 1, time.Nanosecond)
+	l.AddEx(2, 2, 10*365*24*time.Hour)
+	l.Add(3, 3)
+	l.AddEx(4, 4, 2*time.Minute)
+
+	expect := map[int]time.Duration{1: time.Minute, 2: time.Hour, 3: time.Hour, 4: 2 * time.Minute}
+	for k, d := range expect {
+		_, exp, ok := l.PeekWithExpireTime(k)
+		if !ok || exp == nil {
+			t.Fatalf("%v should be present with an expire time", k)
+		}
+		if exp.Before(now.Add(d)) || exp.After(time.Now().Add(d)) {
+			t.Fatalf("bad expire of %v: %v", k, exp.Sub(now))
+		}
+	}
+}
+
+func TestLRU_MinTTLOnly(t *testing.T) {
+	l, _ := NewLRUWithOptions(2, nil, WithMinTTL(time.Minute))
+	l.Add(1, 1)
+	if _, exp, _ := l.PeekWithExpireTime(1); exp != nil {
+		t.Fatalf("entries without TTL should not expire")
+	}
+}
+
+func TestLRU_InitialCapacity(t *testing.T) {
+	l, _ := NewLRUWithOptions(1000000, nil, WithInitialCapacity(8))
+	if n := l.freeList.Len(); n != 8 {
+		t.Fatalf("bad preallocated entries: %v", n)
+	}
+	for i := 0; i < 20; i++ {
+		l.Add(i, i)
+	}
+	if l.Len() != 20 || l.freeList.Len() != 0 {
+		t.Fatalf("bad len: %v free: %v", l.Len(), l.freeList.Len())
+	}
+
+	// Removed entries are kept for reuse
+	l.Remove(0)
+	ent := l.freeList.Front()
+	l.Add(100, 100)
+	if l.items[100] != ent {
+		t.Fatalf("removed entry should be reused")
+	}
+
+	l.Purge()
+	if n := l.freeList.Len(); n != 8 {
+		t.Fatalf("bad preallocated entries after purge: %v", n)
+	}
+	if n := l.Clone().freeList.Len(); n != 8 {
+		t.Fatalf("bad preallocated entries of clone: %v", n)
+	}
+
+	l, _ = NewLRU(16, nil)
+	if n := l.freeList.Len(); n != 16 {
+		t.Fatalf("bad default preallocation: %v", n)
+	}
+}
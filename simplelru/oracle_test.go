@@ -0,0 +1,120 @@
+package simplelru
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// stackOracle computes exact LRU stack distances: the stack distance of
+// an access is the number of distinct keys accessed since the previous
+// access of the same key. An LRU of size n hits exactly the accesses
+// whose distance is below n.
+type stackOracle struct {
+	stack []interface{} // most recent first
+}
+
+// access returns the stack distance of key, or -1 for a first access,
+// and moves key to the top of the stack
+func (o *stackOracle) access(key interface{}) int {
+	d := -1
+	for i, k := range o.stack {
+		if k == key {
+			d = i
+			break
+		}
+	}
+	top := d
+	if d < 0 {
+		o.stack = append(o.stack, nil)
+		top = len(o.stack) - 1
+	}
+	copy(o.stack[1:top+1], o.stack[:top])
+	o.stack[0] = key
+	return d
+}
+
+// trace returns n keys drawn from a skewed distribution over keyspace
+// keys, so hits and misses are both common.
+func trace(seed int64, n, keyspace int) []interface{} {
+	r := rand.New(rand.NewSource(seed))
+	z := rand.NewZipf(r, 1.1, 2, uint64(keyspace-1))
+	keys := make([]interface{}, n)
+	for i := range keys {
+		keys[i] = int(z.Uint64())
+	}
+	return keys
+}
+
+// verifyLRU replays keys against l, reading each key and adding it on a
+// miss, and fails if a hit or miss or the final order differs from the
+// theoretical LRU of the same size.
+func verifyLRU(t *testing.T, l *LRU, keys []interface{}) {
+	t.Helper()
+	o := &stackOracle{}
+	for i, k := range keys {
+		d := o.access(k)
+		want := d >= 0 && d < l.Cap()
+		if _, got := l.Get(k); got != want {
+			t.Fatalf("access %d of %v: hit %v, want %v (distance %d)", i, k, got, want, d)
+		}
+		if !want {
+			l.Add(k, k)
+		}
+	}
+
+	n := l.Cap()
+	if n > len(o.stack) {
+		n = len(o.stack)
+	}
+	mru := l.KeysMRU()
+	if len(mru) != n {
+		t.Fatalf("bad len: %v, want %v", len(mru), n)
+	}
+	for i, k := range mru {
+		if k != o.stack[i] {
+			t.Fatalf("bad order at %d: %v, want %v", i, mru, o.stack[:n])
+		}
+	}
+}
+
+func TestStackOracle(t *testing.T) {
+	o := &stackOracle{}
+	for i, c := range []struct {
+		key  int
+		want int
+	}{{1, -1}, {2, -1}, {1, 1}, {1, 0}, {3, -1}, {2, 2}} {
+		if d := o.access(c.key); d != c.want {
+			t.Fatalf("access %d: distance %d, want %d", i, d, c.want)
+		}
+	}
+}
+
+func TestLRU_StrictOrder(t *testing.T) {
+	keys := trace(1, 20000, 1000)
+	for _, size := range []int{1, 16, 128, 1024} {
+		l, _ := NewLRU(size, nil)
+		verifyLRU(t, l, keys)
+	}
+}
+
+func TestLRU_StrictOrderWithOptions(t *testing.T) {
+	keys := trace(2, 20000, 500)
+	for name, opts := range map[string][]Option{
+		"expire":   {WithExpire(time.Hour)},
+		"sliding":  {WithSlidingExpire(time.Hour)},
+		"hll":      {WithCardinalityEstimate()},
+		"window":   {WithWindowedStats()},
+		"origin":   {WithOriginStats()},
+		"cost":     {WithMaxCost(1<<30, nil)},
+		"combined": {WithExpire(time.Hour), WithCardinalityEstimate(), WithWindowedStats()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l, err := NewLRUWithOptions(64, nil, opts...)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			verifyLRU(t, l, keys)
+		})
+	}
+}
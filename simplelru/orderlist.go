@@ -0,0 +1,76 @@
+package simplelru
+
+// OrderList keeps the entries of an LRU in eviction order, from the front,
+// the newest entry, to the back, the oldest one. List implements it, and
+// WithOrderList swaps in another structure, such as a lock-free or
+// slab-indexed list, to experiment without forking the LRU. The LRU owns
+// the elements it passes in and only reads their Value; an implementation
+// links them as it likes, but must not use the other fields of Element,
+// which belong to List.
+type OrderList interface {
+	// Len returns the number of elements in the list.
+	Len() int
+
+	// Front and Back return the first and last elements, or nil.
+	Front() *Element
+	Back() *Element
+
+	// Next and Prev return the neighbours of an element of the list
+	// towards the back and the front, or nil.
+	Next(e *Element) *Element
+	Prev(e *Element) *Element
+
+	// Contains returns if e is an element of the list.
+	Contains(e *Element) bool
+
+	// PushElementFront inserts e, not in any list, at the front.
+	PushElementFront(e *Element) *Element
+
+	// Remove removes e from the list and returns its value.
+	Remove(e *Element) interface{}
+
+	// MoveToFront and MoveToBack move an element of the list to the
+	// front or to the back.
+	MoveToFront(e *Element)
+	MoveToBack(e *Element)
+
+	// Clear removes all the elements.
+	Clear()
+}
+
+// WithOrderList sets the list keeping the entries in eviction order,
+// created by newList for the cache and each of its clones. The default is
+// a List.
+func WithOrderList(newList func() OrderList) Option {
+	return func(c *LRU) {
+		c.newOrderList = newList
+	}
+}
+
+// Next returns the element after e in l, or nil.
+func (l *List) Next(e *Element) *Element {
+	return e.Next()
+}
+
+// Prev returns the element before e in l, or nil.
+func (l *List) Prev(e *Element) *Element {
+	return e.Prev()
+}
+
+// Contains returns if e is an element of l.
+func (l *List) Contains(e *Element) bool {
+	return e.list == l
+}
+
+// Clear removes all the elements of l.
+func (l *List) Clear() {
+	l.Init()
+}
+
+// newOrder returns the list of a new cache or clone
+func (c *LRU) newOrder() OrderList {
+	if c.newOrderList != nil {
+		return c.newOrderList()
+	}
+	return New()
+}
@@ -0,0 +1,103 @@
+package simplelru
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// mapList is an OrderList linking its elements through maps rather than
+// the fields of Element
+type mapList struct {
+	next, prev  map[*Element]*Element
+	front, back *Element
+}
+
+func newMapList() OrderList {
+	l := &mapList{}
+	l.Clear()
+	return l
+}
+
+func (l *mapList) Len() int                 { return len(l.next) }
+func (l *mapList) Front() *Element          { return l.front }
+func (l *mapList) Back() *Element           { return l.back }
+func (l *mapList) Next(e *Element) *Element { return l.next[e] }
+func (l *mapList) Prev(e *Element) *Element { return l.prev[e] }
+func (l *mapList) MoveToFront(e *Element)   { l.Remove(e); l.PushElementFront(e) }
+func (l *mapList) Contains(e *Element) bool {
+	_, ok := l.next[e]
+	return ok
+}
+
+func (l *mapList) Clear() {
+	l.next = make(map[*Element]*Element)
+	l.prev = make(map[*Element]*Element)
+	l.front, l.back = nil, nil
+}
+
+func (l *mapList) PushElementFront(e *Element) *Element {
+	l.next[e], l.prev[e] = l.front, nil
+	if l.front != nil {
+		l.prev[l.front] = e
+	} else {
+		l.back = e
+	}
+	l.front = e
+	return e
+}
+
+func (l *mapList) Remove(e *Element) interface{} {
+	next, prev := l.next[e], l.prev[e]
+	if prev != nil {
+		l.next[prev] = next
+	} else {
+		l.front = next
+	}
+	if next != nil {
+		l.prev[next] = prev
+	} else {
+		l.back = prev
+	}
+	delete(l.next, e)
+	delete(l.prev, e)
+	return e.Value
+}
+
+func (l *mapList) MoveToBack(e *Element) {
+	l.Remove(e)
+	l.next[e], l.prev[e] = nil, l.back
+	if l.back != nil {
+		l.next[l.back] = e
+	} else {
+		l.front = e
+	}
+	l.back = e
+}
+
+func TestLRU_OrderList(t *testing.T) {
+	l, err := NewLRUWithOptions(64, nil, WithOrderList(newMapList))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := l.evictList.(*mapList); !ok {
+		t.Fatalf("bad list: %T", l.evictList)
+	}
+	verifyLRU(t, l, trace(1, 5000, 256))
+	if c := l.Clone(); c.evictList == l.evictList || c.Len() != l.Len() {
+		t.Fatalf("clone should get a fresh list")
+	}
+	l.AddIfAbsent(1000, 1000)
+	if k, _, _ := l.GetOldest(); k != 1000 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	h, _ := l.GetHandle(1000)
+	if l.Remove(1000); h.Valid() {
+		t.Fatalf("handle should be stale")
+	}
+
+	r := rand.New(rand.NewSource(1))
+	program := make([]byte, 2000)
+	r.Read(program)
+	l, _ = NewLRUWithOptions(8, nil, WithOrderList(newMapList))
+	runOps(t, l, program)
+}
@@ -0,0 +1,74 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// OriginStats holds the counters of the entries produced by one source.
+type OriginStats struct {
+	Entries   int    // Entries is the number of entries in the cache
+	Hits      uint64 // Hits is the number of successful Gets
+	Evictions uint64 // Evictions counts the entries evicted or expired
+}
+
+// WithOriginStats enables per origin stats, see AddWithOrigin.
+func WithOriginStats() Option {
+	return func(c *LRU) {
+		c.origins = make(map[string]*OriginStats)
+	}
+}
+
+// AddWithOrigin adds a value to the cache with expire, recording origin
+// as the source that produced it, such as the name of the loader or
+// backing store. Entries added by the other methods have no origin until
+// updated by AddWithOrigin. Returns true if an eviction occurred.
+func (c *LRU) AddWithOrigin(key, value interface{}, expire time.Duration, origin string) bool {
+	return c.add(key, value, expire, addOptions{
+		priority:  PriorityNormal,
+		cost:      c.costOf(key, value),
+		origin:    origin,
+		setOrigin: true,
+	})
+}
+
+// Origin returns the source recorded for a key by AddWithOrigin.
+func (c *LRU) Origin(key interface{}) (origin string, ok bool) {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) {
+		return "", false
+	}
+	return ent.Value.(*entry).origin, true
+}
+
+// OriginStats returns the stats of each origin, including the empty one
+// of the entries added without origin. It returns nil if the cache was
+// not configured with WithOriginStats.
+func (c *LRU) OriginStats() map[string]OriginStats {
+	if c.origins == nil {
+		return nil
+	}
+	stats := make(map[string]OriginStats, len(c.origins))
+	for origin, s := range c.origins {
+		stats[origin] = *s
+	}
+	return stats
+}
+
+// origin returns the stats of an origin, creating them if needed.
+func (c *LRU) origin(origin string) *OriginStats {
+	s, ok := c.origins[origin]
+	if !ok {
+		s = &OriginStats{}
+		c.origins[origin] = s
+	}
+	return s
+}
+
+// setOrigin moves an entry to another origin.
+func (c *LRU) setOrigin(kv *entry, origin string) {
+	if c.origins != nil {
+		c.origin(kv.origin).Entries--
+		c.origin(origin).Entries++
+	}
+	kv.origin = origin
+}
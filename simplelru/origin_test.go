@@ -0,0 +1,50 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_OriginStats(t *testing.T) {
+	l, _ := NewLRUWithOptions(3, nil, WithOriginStats())
+	l.AddWithOrigin(1, 1, 0, "db")
+	l.AddWithOrigin(2, 2, 0, "db")
+	l.AddWithOrigin(3, 3, 0, "api")
+	l.Get(1)
+	l.Get(3)
+	l.Get(3)
+
+	// 2 is evicted and the new entry has no origin
+	l.Add(4, 4)
+	if o, ok := l.Origin(1); !ok || o != "db" {
+		t.Fatalf("bad origin: %v", o)
+	}
+	stats := l.OriginStats()
+	if s := stats["db"]; s.Entries != 1 || s.Hits != 1 || s.Evictions != 1 {
+		t.Fatalf("bad db stats: %+v", s)
+	}
+	if s := stats["api"]; s.Entries != 1 || s.Hits != 2 || s.Evictions != 0 {
+		t.Fatalf("bad api stats: %+v", s)
+	}
+	if s := stats[""]; s.Entries != 1 {
+		t.Fatalf("bad stats without origin: %+v", s)
+	}
+
+	// Updates move entries between origins, plain Adds keep the origin
+	l.AddWithOrigin(1, 1, 0, "api")
+	l.Add(3, 30)
+	if s := l.OriginStats()["api"]; s.Entries != 2 {
+		t.Fatalf("bad api stats: %+v", s)
+	}
+	l.Remove(1)
+	l.Purge()
+	for origin, s := range l.OriginStats() {
+		if s.Entries != 0 {
+			t.Fatalf("bad %s stats after purge: %+v", origin, s)
+		}
+	}
+
+	l, _ = NewLRU(1, nil)
+	if l.OriginStats() != nil {
+		t.Fatalf("origin stats should be disabled")
+	}
+}
@@ -0,0 +1,86 @@
+package simplelru
+
+import (
+	"encoding/gob"
+	"io"
+	"time"
+)
+
+// Record is the serializable form of an entry, as returned by Dump.
+type Record struct {
+	Key      interface{}
+	Value    interface{}
+	Expire   *time.Time
+	Priority Priority
+}
+
+// Dump returns the live entries of the cache from oldest to newest, for
+// them to be serialized with any codec and restored with Load.
+func (c *LRU) Dump() []Record {
+	records := make([]Record, 0, len(c.items))
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		kv := ent.Value.(*entry)
+		if c.expired(kv) {
+			continue
+		}
+		records = append(records, Record{
+			Key:      kv.key,
+			Value:    kv.load(),
+			Expire:   kv.expire,
+			Priority: kv.priority,
+		})
+	}
+	return records
+}
+
+// Load adds the records in order, so that records dumped from oldest to
+// newest keep their recency, along with their priorities. Records that
+// have expired since are skipped, and the others are added with their
+// remaining time to live, clamped like that of Add, see WithMaxTTL, so
+// expire times read from the system clock, as decoded by NewFromReader,
+// are rebased on the monotonic clock. Returns true if an eviction
+// occurred.
+func (c *LRU) Load(records []Record) bool {
+	evict := false
+	now := c.now()
+	c.checkClock(now)
+	for _, r := range records {
+		var expire time.Duration
+		if r.Expire != nil {
+			if expire = r.Expire.Sub(now); expire <= 0 {
+				continue
+			}
+		}
+		if c.add(r.Key, r.Value, expire, addOptions{
+			priority:    r.Priority,
+			cost:        c.costOf(r.Key, r.Value),
+			now:         now,
+			setPriority: true,
+		}) {
+			evict = true
+		}
+	}
+	return evict
+}
+
+// SaveTo serializes the live entries of the cache to w with gob. The
+// concrete types of the keys and values must be registered with
+// gob.Register.
+func (c *LRU) SaveTo(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(c.Dump())
+}
+
+// NewFromReader constructs an LRU like NewLRUWithOptions and restores the
+// entries serialized to r by SaveTo.
+func NewFromReader(r io.Reader, size int, onEvict EvictCallback, opts ...Option) (*LRU, error) {
+	c, err := NewLRUWithOptions(size, onEvict, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := gob.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	c.Load(records)
+	return c, nil
+}
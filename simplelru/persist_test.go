@@ -0,0 +1,59 @@
+package simplelru
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLRU_SaveTo(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	l.Add(1, "a")
+	l.AddEx(2, "b", time.Hour)
+	l.AddExWithPriority(3, "c", 0, PriorityHigh)
+	l.AddEx(4, "d", time.Millisecond)
+	l.Get(1)
+	time.Sleep(2 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := l.SaveTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, err := NewFromReader(&buf, 4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	keys := r.Keys()
+	if len(keys) != 3 || keys[0] != 2 || keys[1] != 3 || keys[2] != 1 {
+		t.Fatalf("recency should be preserved: %v", keys)
+	}
+	// The loaded expire time is rebased on another monotonic reading, so
+	// only its wall clock reading matches
+	_, want, _ := l.PeekWithExpireTime(2)
+	if _, expire, _ := r.PeekWithExpireTime(2); expire == nil || !expire.Round(0).Equal(want.Round(0)) {
+		t.Fatalf("expire should be preserved: %v %v", expire, want)
+	}
+	if kv := r.items[3].Value.(*entry); kv.priority != PriorityHigh {
+		t.Fatalf("priority should be preserved: %v", kv.priority)
+	}
+	if v, _ := r.Peek(1); v != "a" {
+		t.Fatalf("bad value: %v", v)
+	}
+
+	if _, err := NewFromReader(bytes.NewReader(nil), 4, nil); err == nil {
+		t.Fatalf("should fail on empty input")
+	}
+}
+
+func TestLRU_LoadClampsTTL(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l, _ := NewLRUWithOptions(4, nil, WithClock(clock), WithMaxTTL(time.Minute))
+	long := time.Unix(0, 0).Add(time.Hour)
+	l.Load([]Record{{Key: 1, Value: 1}, {Key: 2, Value: 2, Expire: &long}})
+	for _, key := range []interface{}{1, 2} {
+		if _, expire, _ := l.PeekWithExpireTime(key); expire == nil || !expire.Equal(time.Unix(60, 0)) {
+			t.Fatalf("expire of %v should be clamped: %v", key, expire)
+		}
+	}
+}
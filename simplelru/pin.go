@@ -0,0 +1,55 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// WithUnpinCooldown keeps an entry protected from eviction for d after
+// its last Unpin, so briefly dropping the protection between two
+// operations does not expose the entry to an eviction in between.
+func WithUnpinCooldown(d time.Duration) Option {
+	return func(c *LRU) {
+		c.cooldown = d
+	}
+}
+
+// Pin protects a key from being evicted to make room for new entries
+// until a matching Unpin. Pins nest. Explicit removal, Purge and expiry
+// still apply. While every entry is protected, Add grows the cache past
+// its size. Returns false if the key is not in the cache.
+func (c *LRU) Pin(key interface{}) bool {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) {
+		return false
+	}
+	ent.Value.(*entry).pins++
+	return true
+}
+
+// Unpin releases a Pin of the key. Once the last pin is released the
+// entry can be evicted again, after the unpin cooldown if one is set.
+// Returns false if the key is not in the cache or not pinned.
+func (c *LRU) Unpin(key interface{}) bool {
+	ent, ok := c.find(key)
+	if !ok || ent.Value.(*entry).pins == 0 {
+		return false
+	}
+	kv := ent.Value.(*entry)
+	kv.pins--
+	if kv.pins == 0 && c.cooldown > 0 {
+		kv.cooldown = c.now().Add(c.cooldown).UnixNano()
+	}
+	return true
+}
+
+// IsPinned returns if the key is protected from eviction, either pinned
+// or within its unpin cooldown.
+func (c *LRU) IsPinned(key interface{}) bool {
+	ent, ok := c.find(key)
+	return ok && !ent.Value.(*entry).evictable(c.now())
+}
+
+// evictable returns if the entry may be chosen as a victim at now
+func (e *entry) evictable(now time.Time) bool {
+	return e.pins == 0 && (e.cooldown == 0 || now.UnixNano() >= e.cooldown)
+}
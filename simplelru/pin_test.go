@@ -0,0 +1,92 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_Pin(t *testing.T) {
+	l, err := NewLRU(2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Pin(1) || l.Pin(3) {
+		t.Fatalf("bad pin")
+	}
+	l.Add(3, 3)
+	if !l.Contains(1) || l.Contains(2) {
+		t.Fatalf("pinned entry should survive")
+	}
+
+	// With every entry pinned the cache grows
+	l.Pin(3)
+	if l.Add(4, 4) {
+		t.Fatalf("nothing should be evicted")
+	}
+	if l.Len() != 3 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+
+	// Shrinks back once unpinned
+	if !l.Unpin(1) || l.Unpin(1) {
+		t.Fatalf("bad unpin")
+	}
+	l.Add(5, 5)
+	if l.Len() != 2 || l.Contains(1) || l.Contains(4) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+	l.Pin(5)
+	if l.Resize(1) != 0 || l.Len() != 2 {
+		t.Fatalf("resize should skip pinned entries")
+	}
+	l.Unpin(3)
+	if l.Resize(1) != 1 || l.Contains(3) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+}
+
+func TestLRU_PinNested(t *testing.T) {
+	l, _ := NewLRU(1, nil)
+	l.Add(1, 1)
+	l.Pin(1)
+	l.Pin(1)
+	l.Unpin(1)
+	if !l.IsPinned(1) {
+		t.Fatalf("1 should still be pinned")
+	}
+	l.Unpin(1)
+	l.Add(2, 2)
+	if l.Contains(1) {
+		t.Fatalf("1 should be evicted")
+	}
+}
+
+func TestLRU_UnpinCooldown(t *testing.T) {
+	l, err := NewLRUWithOptions(1, nil, WithUnpinCooldown(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Pin(1)
+	l.Unpin(1)
+	if !l.IsPinned(1) {
+		t.Fatalf("1 should be cooling down")
+	}
+	l.Add(2, 2)
+	if !l.Contains(1) {
+		t.Fatalf("1 should survive during the cooldown")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if l.IsPinned(1) {
+		t.Fatalf("cooldown should be over")
+	}
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Fatalf("1 should be evicted after the cooldown")
+	}
+}
@@ -0,0 +1,359 @@
+package simplelru
+
+import (
+	"container/heap"
+	"math/rand"
+	"sort"
+)
+
+// Policy is a replacement policy deciding which entry an LRU evicts,
+// while the LRU keeps the storage, expiration, priorities and pins. The
+// LRU reports every key added, accessed and removed, and asks for a
+// victim when it needs room. A Policy is only used under the LRU, so it
+// needs no locking of its own.
+type Policy interface {
+	// RecordAdd is called when a key enters the cache.
+	RecordAdd(key interface{})
+
+	// RecordAccess is called when a key is read or updated.
+	RecordAccess(key interface{})
+
+	// Remove is called when a key leaves the cache for any reason.
+	Remove(key interface{})
+
+	// Victim returns the key to evict among those for which evictable
+	// returns true, or false if there is none. Keys that are pinned or
+	// outside the priority class being evicted are not evictable.
+	Victim(evictable func(key interface{}) bool) (key interface{}, ok bool)
+}
+
+// WithPolicy replaces the built-in eviction order and policy with the
+// policy returned by newPolicy. newPolicy is called again by Purge and
+// Clone to start from an empty policy, which is then told of the entries
+// kept.
+func WithPolicy(newPolicy func() Policy) Option {
+	return func(c *LRU) {
+		c.newPlugin = newPolicy
+		c.plugin = newPolicy()
+	}
+}
+
+// Policy returns the replacement policy of the cache, the one set by
+// WithPolicy or the built-in one of its EvictionPolicy.
+func (c *LRU) Policy() Policy {
+	return c.plugin
+}
+
+// pluginVictim returns the victim chosen by the policy among the
+// candidates, all of the given priority.
+func (c *LRU) pluginVictim(priority Priority, candidate func(*Element) bool) *Element {
+	if p, ok := c.plugin.(builtinPolicy); ok {
+		return p.victim(priority, candidate)
+	}
+	key, ok := c.plugin.Victim(func(key interface{}) bool {
+		ent, ok := c.find(key)
+		return ok && candidate(ent)
+	})
+	if !ok {
+		return nil
+	}
+	ent, _ := c.find(key)
+	return ent
+}
+
+// builtinPolicy is a built-in policy, bound to the LRU it evicts from.
+// It orders the entries with the order list of the LRU, so it has no
+// bookkeeping of its own, and picks its victim among the elements rather
+// than through key lookups. The candidates are all of the given priority,
+// or of any with anyPriority.
+type builtinPolicy interface {
+	Policy
+	victim(priority Priority, candidate func(*Element) bool) *Element
+}
+
+// newBuiltinPolicy returns the built-in policy of the EvictionPolicy and
+// EvictionOrder of c.
+func newBuiltinPolicy(c *LRU) Policy {
+	switch {
+	case c.policy == EvictMRU:
+		return mruPolicy{c: c}
+	case c.policy == EvictRandom:
+		return randomPolicy{c: c}
+	case c.order == GenerationOrder:
+		return generationPolicy{c: c}
+	}
+	return lruPolicy{c: c}
+}
+
+// builtinHooks implements the hooks of Policy for the built-in policies,
+// the order list of the LRU recording the adds and accesses already
+type builtinHooks struct{}
+
+func (builtinHooks) RecordAdd(key interface{})    {}
+func (builtinHooks) RecordAccess(key interface{}) {}
+func (builtinHooks) Remove(key interface{})       {}
+
+// builtinVictim implements Policy.Victim for a built-in policy
+func builtinVictim(p builtinPolicy, evictable func(key interface{}) bool) (interface{}, bool) {
+	ent := p.victim(anyPriority, func(ent *Element) bool {
+		return evictable(ent.Value.(*entry).key)
+	})
+	if ent == nil {
+		return nil, false
+	}
+	return ent.Value.(*entry).key, true
+}
+
+// lruPolicy evicts from the back of the order list: the least recently
+// used entry in AccessOrder, the first inserted in InsertionOrder, which
+// makes it a FIFO.
+type lruPolicy struct {
+	builtinHooks
+	c *LRU
+}
+
+// Victim implements Policy.
+func (p lruPolicy) Victim(evictable func(key interface{}) bool) (interface{}, bool) {
+	return builtinVictim(p, evictable)
+}
+
+func (p lruPolicy) victim(priority Priority, candidate func(*Element) bool) *Element {
+	if l := p.c.class(priority); l != nil {
+		for e := l.Back(); e != nil; e = e.Prev() {
+			if ent := e.Value.(*Element); candidate(ent) {
+				return ent
+			}
+		}
+		return nil
+	}
+	for ent := p.c.evictList.Back(); ent != nil; ent = p.c.evictList.Prev(ent) {
+		if candidate(ent) {
+			return ent
+		}
+	}
+	return nil
+}
+
+// mruPolicy evicts from the front of the order list, see EvictMRU.
+type mruPolicy struct {
+	builtinHooks
+	c *LRU
+}
+
+// Victim implements Policy.
+func (p mruPolicy) Victim(evictable func(key interface{}) bool) (interface{}, bool) {
+	return builtinVictim(p, evictable)
+}
+
+func (p mruPolicy) victim(priority Priority, candidate func(*Element) bool) *Element {
+	if l := p.c.class(priority); l != nil {
+		for e := l.Front(); e != nil; e = e.Next() {
+			if ent := e.Value.(*Element); candidate(ent) {
+				return ent
+			}
+		}
+		return nil
+	}
+	for ent := p.c.evictList.Front(); ent != nil; ent = p.c.evictList.Next(ent) {
+		if candidate(ent) {
+			return ent
+		}
+	}
+	return nil
+}
+
+// randomDraws is the number of random slots drawn by randomPolicy before
+// it scans for a candidate
+const randomDraws = 8
+
+// randomPolicy evicts an entry drawn uniformly from the slots sampled by
+// SampleKeys, see EvictRandom.
+type randomPolicy struct {
+	builtinHooks
+	c *LRU
+}
+
+// Victim implements Policy.
+func (p randomPolicy) Victim(evictable func(key interface{}) bool) (interface{}, bool) {
+	return builtinVictim(p, evictable)
+}
+
+func (p randomPolicy) victim(priority Priority, candidate func(*Element) bool) *Element {
+	slots := p.c.slots
+	if len(slots) == 0 {
+		return nil
+	}
+	// Drawing slots until a candidate comes up picks uniformly among the
+	// candidates, and the scan from a random slot bounds the draws when
+	// few of the entries are
+	for i := 0; i < randomDraws; i++ {
+		if ent := slots[rand.Intn(len(slots))]; candidate(ent) {
+			return ent
+		}
+	}
+	start := rand.Intn(len(slots))
+	for i := range slots {
+		if ent := slots[(start+i)%len(slots)]; candidate(ent) {
+			return ent
+		}
+	}
+	return nil
+}
+
+// LFUPolicy evicts the least frequently used key, and the least recently
+// used one among equally frequent keys. With aging, the frequencies are
+// halved periodically, so keys that were hot once do not stay in the
+// cache forever.
+type LFUPolicy struct {
+	tick     uint64
+	period   int // period is the number of accesses between agings
+	accesses int
+	items    map[interface{}]*lfuItem
+	heap     lfuHeap
+}
+
+type lfuItem struct {
+	key   interface{}
+	freq  uint64
+	tick  uint64
+	index int
+}
+
+// NewLFUPolicy returns an empty LFUPolicy whose frequencies never decay,
+// for use with WithPolicy.
+func NewLFUPolicy() Policy {
+	return NewLFUPolicyWithAging(0)
+}
+
+// NewLFUPolicyWithAging returns an empty LFUPolicy halving the
+// frequencies of its keys every period accesses. A period of 0 disables
+// aging.
+func NewLFUPolicyWithAging(period int) Policy {
+	return &LFUPolicy{period: period, items: make(map[interface{}]*lfuItem)}
+}
+
+// RecordAdd implements Policy.
+func (p *LFUPolicy) RecordAdd(key interface{}) {
+	it := &lfuItem{key: key}
+	p.items[key] = it
+	heap.Push(&p.heap, it)
+	p.RecordAccess(key)
+}
+
+// RecordAccess implements Policy.
+func (p *LFUPolicy) RecordAccess(key interface{}) {
+	if it, ok := p.items[key]; ok {
+		p.tick++
+		it.freq++
+		it.tick = p.tick
+		heap.Fix(&p.heap, it.index)
+	}
+	if p.accesses++; p.period > 0 && p.accesses >= p.period {
+		p.accesses = 0
+		for _, it := range p.heap {
+			it.freq /= 2
+		}
+		heap.Init(&p.heap)
+	}
+}
+
+// Remove implements Policy.
+func (p *LFUPolicy) Remove(key interface{}) {
+	if it, ok := p.items[key]; ok {
+		heap.Remove(&p.heap, it.index)
+		delete(p.items, key)
+	}
+}
+
+// Frequency returns the current, possibly aged, access count of a key.
+func (p *LFUPolicy) Frequency(key interface{}) (uint64, bool) {
+	if it, ok := p.items[key]; ok {
+		return it.freq, true
+	}
+	return 0, false
+}
+
+// Keys returns the keys from the next to be evicted to the last.
+func (p *LFUPolicy) Keys() []interface{} {
+	items := make([]*lfuItem, len(p.heap))
+	copy(items, p.heap)
+	sort.Slice(items, func(i, j int) bool { return items[i].less(items[j]) })
+	keys := make([]interface{}, len(items))
+	for i, it := range items {
+		keys[i] = it.key
+	}
+	return keys
+}
+
+// Victim implements Policy. Non-evictable keys are skipped by searching
+// the heap best first, so only the keys preferred to the victim are
+// visited.
+func (p *LFUPolicy) Victim(evictable func(key interface{}) bool) (interface{}, bool) {
+	if len(p.heap) == 0 {
+		return nil, false
+	}
+	frontier := lfuFrontier{h: p.heap, idx: []int{0}}
+	for len(frontier.idx) > 0 {
+		i := heap.Pop(&frontier).(int)
+		if evictable(p.heap[i].key) {
+			return p.heap[i].key, true
+		}
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(p.heap) {
+				heap.Push(&frontier, child)
+			}
+		}
+	}
+	return nil, false
+}
+
+// lfuHeap is a min-heap of items ordered by frequency, then recency
+type lfuHeap []*lfuItem
+
+func (h lfuHeap) Len() int           { return len(h) }
+func (h lfuHeap) Less(i, j int) bool { return h[i].less(h[j]) }
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	it := x.(*lfuItem)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
+
+func (it *lfuItem) less(o *lfuItem) bool {
+	if it.freq != o.freq {
+		return it.freq < o.freq
+	}
+	return it.tick < o.tick
+}
+
+// lfuFrontier is a min-heap of indexes into an lfuHeap
+type lfuFrontier struct {
+	h   lfuHeap
+	idx []int
+}
+
+func (f *lfuFrontier) Len() int           { return len(f.idx) }
+func (f *lfuFrontier) Less(i, j int) bool { return f.h[f.idx[i]].less(f.h[f.idx[j]]) }
+func (f *lfuFrontier) Swap(i, j int)      { f.idx[i], f.idx[j] = f.idx[j], f.idx[i] }
+func (f *lfuFrontier) Push(x interface{}) { f.idx = append(f.idx, x.(int)) }
+
+func (f *lfuFrontier) Pop() interface{} {
+	i := f.idx[len(f.idx)-1]
+	f.idx = f.idx[:len(f.idx)-1]
+	return i
+}
@@ -0,0 +1,131 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+// fifoPolicy is a minimal custom policy evicting the oldest added key
+type fifoPolicy struct {
+	keys []interface{}
+}
+
+func (p *fifoPolicy) RecordAdd(key interface{})    { p.keys = append(p.keys, key) }
+func (p *fifoPolicy) RecordAccess(key interface{}) {}
+
+func (p *fifoPolicy) Remove(key interface{}) {
+	for i, k := range p.keys {
+		if k == key {
+			p.keys = append(p.keys[:i], p.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *fifoPolicy) Victim(evictable func(key interface{}) bool) (interface{}, bool) {
+	for _, k := range p.keys {
+		if evictable(k) {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+func TestLRU_CustomPolicy(t *testing.T) {
+	l, _ := NewLRUWithOptions(3, nil, WithPolicy(func() Policy { return &fifoPolicy{} }))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+	l.Add(4, 4)
+	if l.Contains(1) || !l.Contains(2) {
+		t.Fatalf("policy should evict the first added: %v", l.Keys())
+	}
+
+	// Pinned keys are not offered for eviction
+	l.Pin(2)
+	l.Add(5, 5)
+	if !l.Contains(2) || l.Contains(3) {
+		t.Fatalf("pinned key evicted: %v", l.Keys())
+	}
+	l.Unpin(2)
+
+	n := l.Clone()
+	n.Add(6, 6)
+	if n.Contains(2) || !l.Contains(2) {
+		t.Fatalf("clone should have its own policy: %v %v", n.Keys(), l.Keys())
+	}
+
+	l.Purge()
+	l.Add(7, 7)
+	l.Add(8, 8)
+	l.Add(9, 9)
+	l.Add(10, 10)
+	if l.Contains(7) || l.Len() != 3 {
+		t.Fatalf("bad keys after purge: %v", l.Keys())
+	}
+}
+
+func TestLRU_LFUPolicy(t *testing.T) {
+	l, _ := NewLRUWithOptions(3, nil, WithPolicy(NewLFUPolicy))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+	l.Get(1)
+	l.GetMany([]interface{}{2})
+
+	// A scan only replaces the least frequent key
+	for i := 10; i < 20; i++ {
+		l.Add(i, i)
+	}
+	if !l.Contains(1) || !l.Contains(2) || !l.Contains(19) || l.Len() != 3 {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+
+	l.Remove(1)
+	l.Add(20, 20)
+	l.Add(21, 21)
+	if !l.Contains(2) || l.Contains(19) {
+		t.Fatalf("bad keys after remove: %v", l.Keys())
+	}
+
+	// Skipped keys do not hide the next best victim
+	l.Pin(2)
+	l.Pin(21)
+	l.Add(22, 22)
+	if !l.Contains(2) || !l.Contains(21) || l.Contains(20) {
+		t.Fatalf("bad keys with pins: %v", l.Keys())
+	}
+}
+
+func TestLRU_BuiltinPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		opts   []Option
+		victim interface{}
+	}{
+		{"lru", nil, 2},
+		{"fifo", []Option{WithEvictionOrder(InsertionOrder)}, 1},
+		{"mru", []Option{WithEvictionPolicy(EvictMRU)}, 1},
+	} {
+		l, _ := NewLRUWithOptions(3, nil, tc.opts...)
+		l.Add(1, 1)
+		l.Add(2, 2)
+		l.Add(3, 3)
+		l.Get(1)
+		key, ok := l.Policy().Victim(func(key interface{}) bool { return key != 3 })
+		if !ok || key != tc.victim {
+			t.Fatalf("%s: bad victim: %v", tc.name, key)
+		}
+	}
+
+	l, _ := NewLRUWithOptions(2, nil, WithEvictionPolicy(EvictRandom))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if key, ok := l.Clone().Policy().Victim(func(key interface{}) bool { return key == 2 }); !ok || key != 2 {
+		t.Fatalf("bad victim: %v", key)
+	}
+	if _, ok := l.Policy().Victim(func(key interface{}) bool { return false }); ok {
+		t.Fatalf("should find no victim")
+	}
+}
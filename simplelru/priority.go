@@ -0,0 +1,136 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// Priority is the eviction class of an entry. Eviction picks its victim
+// among the entries of the lowest priority present in the cache, so
+// higher priority entries are only evicted once no lower priority entry
+// is left. Within a class the eviction policy applies as usual.
+type Priority int
+
+const (
+	// PriorityLow entries are evicted first.
+	PriorityLow Priority = iota
+
+	// PriorityNormal is the priority of entries added without one.
+	PriorityNormal
+
+	// PriorityHigh entries are evicted last, e.g. for values that are
+	// expensive to regenerate.
+	PriorityHigh
+
+	numPriorities
+)
+
+// AddWithPriority adds a value to the cache with the given priority.
+// Returns true if an eviction occurred.
+func (c *LRU) AddWithPriority(key, value interface{}, priority Priority) bool {
+	return c.AddExWithPriority(key, value, 0, priority)
+}
+
+// AddExWithPriority adds a value to the cache with expire and the given
+// priority. Returns true if an eviction occurred.
+func (c *LRU) AddExWithPriority(key, value interface{}, expire time.Duration, priority Priority) bool {
+	if priority < PriorityLow || priority >= numPriorities {
+		priority = PriorityNormal
+	}
+	return c.add(key, value, expire, addOptions{
+		priority:    priority,
+		cost:        c.costOf(key, value),
+		setPriority: true,
+	})
+}
+
+// anyPriority asks the built-in policies for a victim of any priority
+const anyPriority Priority = -1
+
+// setPriority changes the priority of the entry of ent in the cache
+func (c *LRU) setPriority(ent *Element, priority Priority) {
+	kv := ent.Value.(*entry)
+	if kv.priority == priority {
+		return
+	}
+	c.counts[kv.priority]--
+	if c.classes != nil {
+		c.classes[kv.priority].Remove(kv.class)
+	}
+	kv.priority = priority
+	c.counts[priority]++
+	if c.classes == nil {
+		if priority != PriorityNormal {
+			c.classify()
+		}
+		return
+	}
+	// Insert the entry behind the nearest newer entry of its priority,
+	// the priority of an entry changing far less often than evictions
+	for e := c.evictList.Prev(ent); e != nil; e = c.evictList.Prev(e) {
+		if o := e.Value.(*entry); o.priority == priority {
+			kv.class = c.classes[priority].InsertAfter(ent, o.class)
+			return
+		}
+	}
+	kv.class = c.classes[priority].PushFront(ent)
+}
+
+// classify starts keeping a list per priority of the entries, in the
+// order of the order list, for the victim of a priority to be found
+// without scanning past the entries of the other priorities. It is
+// called once the cache holds an entry of another priority than
+// PriorityNormal, and lasts until Purge.
+func (c *LRU) classify() {
+	c.classes = make([]*List, numPriorities)
+	for i := range c.classes {
+		c.classes[i] = New()
+	}
+	for ent := c.evictList.Back(); ent != nil; ent = c.evictList.Prev(ent) {
+		kv := ent.Value.(*entry)
+		kv.class = c.classes[kv.priority].PushFront(ent)
+	}
+}
+
+// class returns the entries of a priority, from the newest to the oldest,
+// or nil if they are not kept apart
+func (c *LRU) class(priority Priority) *List {
+	if c.classes == nil || priority < PriorityLow || priority >= numPriorities {
+		return nil
+	}
+	return c.classes[priority]
+}
+
+// classAdd adds an entry pushed at the front of the order list to the
+// list of its priority
+func (c *LRU) classAdd(ent *Element) {
+	kv := ent.Value.(*entry)
+	if c.classes != nil {
+		kv.class = c.classes[kv.priority].PushFront(ent)
+	} else if kv.priority != PriorityNormal {
+		c.classify()
+	}
+}
+
+// classMove mirrors a move of an entry to the front or the back of the
+// order list in the list of its priority
+func (c *LRU) classMove(ent *Element, front bool) {
+	if c.classes == nil {
+		return
+	}
+	kv := ent.Value.(*entry)
+	if front {
+		c.classes[kv.priority].MoveToFront(kv.class)
+	} else {
+		c.classes[kv.priority].MoveToBack(kv.class)
+	}
+}
+
+// classRemove removes an entry from the list of its priority
+func (c *LRU) classRemove(ent *Element) {
+	if c.classes == nil {
+		return
+	}
+	kv := ent.Value.(*entry)
+	c.classes[kv.priority].Remove(kv.class)
+	kv.class = nil
+}
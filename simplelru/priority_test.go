@@ -0,0 +1,116 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_Priority(t *testing.T) {
+	l, err := NewLRU(3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.AddWithPriority(1, 1, PriorityHigh)
+	l.Add(2, 2)
+	l.AddWithPriority(3, 3, PriorityLow)
+
+	// The low priority entry goes first even though it is the newest
+	l.Add(4, 4)
+	if l.Contains(3) {
+		t.Fatalf("3 should have been evicted")
+	}
+
+	// Then normal entries in LRU order
+	l.Get(2)
+	l.Add(5, 5)
+	if l.Contains(4) || !l.Contains(2) {
+		t.Fatalf("4 should have been evicted")
+	}
+	l.Add(6, 6)
+	l.Add(7, 7)
+	if !l.Contains(1) {
+		t.Fatalf("high priority entry should survive")
+	}
+
+	// Plain Adds keep the priority of existing keys
+	l.Add(1, 10)
+	l.Add(8, 8)
+	if !l.Contains(1) {
+		t.Fatalf("1 should have kept its priority")
+	}
+
+	// Once only high priority entries are left they are evicted too
+	l.AddWithPriority(8, 8, PriorityHigh)
+	l.AddWithPriority(7, 7, PriorityHigh)
+	l.AddWithPriority(9, 9, PriorityHigh)
+	if l.Contains(1) {
+		t.Fatalf("1 should have been evicted")
+	}
+}
+
+func TestLRU_PriorityResize(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	l.AddWithPriority(1, 1, PriorityLow)
+	l.AddWithPriority(2, 2, PriorityHigh)
+	l.Add(3, 3)
+	l.AddWithPriority(4, 4, PriorityLow)
+
+	l.Resize(2)
+	if l.Contains(1) || l.Contains(4) || !l.Contains(2) || !l.Contains(3) {
+		t.Fatalf("bad keys: %v", l.Keys())
+	}
+
+	l.Purge()
+	if l.counts != [numPriorities]int{} {
+		t.Fatalf("counts should be reset")
+	}
+}
+
+func TestLRU_PriorityClasses(t *testing.T) {
+	l, _ := NewLRU(100, nil)
+	for i := 0; i < 99; i++ {
+		l.AddWithPriority(i, i, PriorityHigh)
+	}
+	l.Add(100, 100)
+	l.AddWithPriority(0, 0, PriorityNormal)
+	l.AddIfAbsent(101, 101)
+	l.GetMany([]interface{}{5, 100, 5})
+	l.AddWithPriority(50, 50, PriorityLow)
+	l.Remove(7)
+	l.Add(102, 102)
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The victims are found without scanning past the high priority
+	// entries at the back
+	if l.classes == nil {
+		t.Fatalf("mixed priorities should be kept apart")
+	}
+	visited := 0
+	l.pluginVictim(PriorityNormal, func(ent *Element) bool {
+		visited++
+		return ent.Value.(*entry).priority == PriorityNormal
+	})
+	if visited != 1 {
+		t.Fatalf("bad visited: %v", visited)
+	}
+	for i, want := range []interface{}{50, 101, 0, 102, 1000} {
+		l.Add(1000+i, 0)
+		if l.Contains(want) {
+			t.Fatalf("%v should have been evicted: %v", want, l.Keys())
+		}
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c := l.Clone()
+	if err := c.CheckInvariants(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Purge()
+	if l.classes != nil {
+		t.Fatalf("classes should be reset")
+	}
+}
@@ -0,0 +1,78 @@
+package simplelru
+
+// EvictReason tells why an entry left the cache.
+type EvictReason int
+
+const (
+	// EvictCapacity is an entry evicted to make room for another.
+	EvictCapacity EvictReason = iota
+
+	// EvictExpired is an expired entry removed from the cache, either by
+	// DeleteExpired or as the victim of an eviction.
+	EvictExpired
+
+	// EvictRemoved is an entry removed by Remove or RemoveOldest.
+	EvictRemoved
+
+	// EvictPurged is an entry removed by Purge.
+	EvictPurged
+
+	// EvictReplaced is the previous value of a key updated by an Add. The
+	// key itself stays in the cache.
+	EvictReplaced
+)
+
+// String returns the name of the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictRemoved:
+		return "removed"
+	case EvictPurged:
+		return "purged"
+	case EvictReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// EvictCallbackWithReason is used to get a callback when a cache entry is
+// evicted, along with the reason it left the cache.
+type EvictCallbackWithReason func(key, value interface{}, reason EvictReason)
+
+// WithEvictCallbackWithReason sets a callback invoked with the reason of
+// each eviction. Unlike the EvictCallback given to the constructor, it is
+// also invoked with the previous value of a key replaced by an Add.
+func WithEvictCallbackWithReason(onEvict EvictCallbackWithReason) Option {
+	return func(c *LRU) {
+		c.onReason = onEvict
+	}
+}
+
+// EvictCallbackWithReason returns the callback set by
+// WithEvictCallbackWithReason or SetEvictCallbackWithReason.
+func (c *LRU) EvictCallbackWithReason() EvictCallbackWithReason {
+	return c.onReason
+}
+
+// SetEvictCallbackWithReason replaces the callback invoked with the
+// reason of each eviction.
+func (c *LRU) SetEvictCallbackWithReason(onEvict EvictCallbackWithReason) {
+	c.onReason = onEvict
+}
+
+// evicted invokes the eviction callbacks for an entry leaving the cache.
+func (c *LRU) evicted(kv *entry, reason EvictReason) {
+	if c.lifetimes != nil {
+		c.lifetimes.died(kv, reason, c.now())
+	}
+	if c.onEvict != nil {
+		c.onEvict(kv.key, kv.load())
+	}
+	if c.onReason != nil {
+		c.onReason(kv.key, kv.load(), reason)
+	}
+}
@@ -0,0 +1,48 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_EvictReason(t *testing.T) {
+	reasons := make(map[interface{}]EvictReason)
+	plain := 0
+	l, _ := NewLRUWithOptions(2, func(k, v interface{}) {
+		plain++
+	}, WithEvictCallbackWithReason(func(k, v interface{}, reason EvictReason) {
+		reasons[k] = reason
+	}))
+
+	l.Add(1, 1)
+	l.Add(1, 2)
+	if reasons[1] != EvictReplaced || plain != 0 || !l.Contains(1) {
+		t.Fatalf("bad replace: %v %v", reasons, plain)
+	}
+	l.Add(2, 2)
+	l.Add(3, 3)
+	if reasons[1] != EvictCapacity {
+		t.Fatalf("bad capacity eviction: %v", reasons)
+	}
+	l.AddEx(4, 4, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	l.DeleteExpired()
+	if reasons[4] != EvictExpired {
+		t.Fatalf("bad expiration: %v", reasons)
+	}
+	l.Remove(3)
+	if reasons[3] != EvictRemoved {
+		t.Fatalf("bad removal: %v", reasons)
+	}
+	l.Add(5, 5)
+	l.Purge()
+	if reasons[5] != EvictPurged {
+		t.Fatalf("bad purge: %v", reasons)
+	}
+	if plain != 5 {
+		t.Fatalf("plain callback should skip replacements: %v", plain)
+	}
+	if EvictExpired.String() != "expired" {
+		t.Fatalf("bad name: %v", EvictExpired)
+	}
+}
@@ -0,0 +1,112 @@
+package simplelru
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// reuseBuckets is the number of buckets of a reuse histogram; bucket
+	// i counts the intervals up to 1ms * 2^(i/2), the last one going up
+	// to about 12 days
+	reuseBuckets = 62
+
+	// reuseBase is the upper bound of the first bucket
+	reuseBase = time.Millisecond
+)
+
+// reuseObserver records the intervals between accesses to the same key
+// in a log-scale histogram per key class
+type reuseObserver struct {
+	classify func(key interface{}) string
+	classes  map[string]*reuseHistogram
+}
+
+// reuseHistogram counts the intervals falling in each bucket
+type reuseHistogram struct {
+	counts [reuseBuckets + 1]uint64
+	total  uint64
+}
+
+func newReuseObserver(classify func(key interface{}) string) *reuseObserver {
+	if classify == nil {
+		classify = func(interface{}) string { return "" }
+	}
+	return &reuseObserver{
+		classify: classify,
+		classes:  make(map[string]*reuseHistogram),
+	}
+}
+
+// WithReuseObserver records the time between consecutive hits on a key,
+// grouped by the class classify assigns to the key (all keys share one
+// class if classify is nil). ReuseQuantile then tells which TTL would
+// cover a given share of the reuses of a class.
+func WithReuseObserver(classify func(key interface{}) string) Option {
+	return func(c *LRU) {
+		c.reuse = newReuseObserver(classify)
+	}
+}
+
+// ReuseQuantile returns the q-quantile, with q between 0 and 1, of the
+// observed intervals between hits on keys of the given class. The value
+// is the upper bound of the histogram bucket the quantile falls in,
+// within a factor of about 1.4. ok is false if nothing was observed.
+func (c *LRU) ReuseQuantile(class string, q float64) (d time.Duration, ok bool) {
+	if c.reuse == nil {
+		return 0, false
+	}
+	h, ok := c.reuse.classes[class]
+	if !ok || h.total == 0 {
+		return 0, false
+	}
+	return h.quantile(q), true
+}
+
+// observe records the interval since the last access of kv
+func (r *reuseObserver) observe(kv *entry, at time.Time) {
+	now := at.UnixNano()
+	interval := time.Duration(now - kv.accessed)
+	kv.accessed = now
+
+	class := r.classify(kv.key)
+	h, ok := r.classes[class]
+	if !ok {
+		h = &reuseHistogram{}
+		r.classes[class] = h
+	}
+	h.counts[reuseBucket(interval)]++
+	h.total++
+}
+
+func (h *reuseHistogram) quantile(q float64) time.Duration {
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, n := range h.counts {
+		seen += n
+		if seen >= rank {
+			return reuseBound(i)
+		}
+	}
+	return reuseBound(reuseBuckets)
+}
+
+// reuseBucket returns the bucket of an interval
+func reuseBucket(d time.Duration) int {
+	if d <= reuseBase {
+		return 0
+	}
+	i := int(math.Ceil(2 * math.Log2(float64(d)/float64(reuseBase))))
+	if i > reuseBuckets {
+		i = reuseBuckets
+	}
+	return i
+}
+
+// reuseBound returns the upper bound of bucket i
+func reuseBound(i int) time.Duration {
+	return time.Duration(float64(reuseBase) * math.Exp2(float64(i)/2))
+}
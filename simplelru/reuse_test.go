@@ -0,0 +1,52 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_ReuseQuantile(t *testing.T) {
+	l, err := NewLRUWithOptions(8, nil, WithReuseObserver(func(key interface{}) string {
+		return key.(string)[:1]
+	}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, ok := l.ReuseQuantile("a", 0.5); ok {
+		t.Fatalf("nothing observed yet")
+	}
+
+	l.Add("a1", 1)
+	l.Add("b1", 1)
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 9; i++ {
+		l.Get("a1")
+	}
+	l.Get("b1")
+
+	// One long interval followed by eight very short ones
+	d, ok := l.ReuseQuantile("a", 0.5)
+	if !ok || d > 2*time.Millisecond {
+		t.Fatalf("bad median: %v, %v", d, ok)
+	}
+	d, _ = l.ReuseQuantile("a", 1)
+	if d < 20*time.Millisecond || d > 60*time.Millisecond {
+		t.Fatalf("bad max: %v", d)
+	}
+	if d, ok := l.ReuseQuantile("b", 0.95); !ok || d < 20*time.Millisecond {
+		t.Fatalf("bad b quantile: %v, %v", d, ok)
+	}
+}
+
+func TestReuseBucket(t *testing.T) {
+	for _, d := range []time.Duration{0, time.Millisecond, 3 * time.Millisecond, time.Second, time.Hour} {
+		i := reuseBucket(d)
+		if reuseBound(i) < d || (i > 0 && reuseBound(i-1) >= d) {
+			t.Fatalf("bad bucket of %v: %v", d, i)
+		}
+	}
+	if i := reuseBucket(365 * 24 * time.Hour); i != reuseBuckets {
+		t.Fatalf("bad overflow bucket: %v", i)
+	}
+}
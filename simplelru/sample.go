@@ -0,0 +1,54 @@
+package simplelru
+
+import (
+	"math/rand"
+)
+
+// SampleKeys returns a uniform random sample of up to n keys resident in
+// the cache, including expired entries still occupying a slot, drawn
+// from a source seeded with seed. It takes O(n) time regardless of the
+// size of the cache.
+func (c *LRU) SampleKeys(n int, seed int64) []interface{} {
+	if n > len(c.slots) {
+		n = len(c.slots)
+	}
+	if n <= 0 {
+		return nil
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	// Partial Fisher-Yates shuffle of the slot indexes, recording the
+	// swaps in a map rather than copying the slots
+	swapped := make(map[int]int, n)
+	at := func(i int) int {
+		if j, ok := swapped[i]; ok {
+			return j
+		}
+		return i
+	}
+	keys := make([]interface{}, n)
+	last := len(c.slots) - 1
+	for i := 0; i < n; i++ {
+		j := i + rnd.Intn(last-i+1)
+		vi, vj := at(i), at(j)
+		swapped[i], swapped[j] = vj, vi
+		keys[i] = c.slots[vj].Value.(*entry).key
+	}
+	return keys
+}
+
+// index makes a new entry available to SampleKeys.
+func (c *LRU) index(ent *Element) {
+	ent.Value.(*entry).slot = len(c.slots)
+	c.slots = append(c.slots, ent)
+}
+
+// unindex removes an entry from the sampled slots, moving the last slot
+// in its place.
+func (c *LRU) unindex(ent *Element) {
+	i := ent.Value.(*entry).slot
+	last := len(c.slots) - 1
+	c.slots[i] = c.slots[last]
+	c.slots[i].Value.(*entry).slot = i
+	c.slots[last] = nil
+	c.slots = c.slots[:last]
+}
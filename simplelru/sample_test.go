@@ -0,0 +1,65 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_SampleKeys(t *testing.T) {
+	l, _ := NewLRU(100, nil)
+	for i := 0; i < 100; i++ {
+		l.Add(i, i)
+	}
+	for i := 0; i < 100; i += 2 {
+		l.Remove(i)
+	}
+
+	keys := l.SampleKeys(10, 1)
+	if len(keys) != 10 {
+		t.Fatalf("bad sample size: %v", len(keys))
+	}
+	seen := make(map[interface{}]bool)
+	for _, k := range keys {
+		if seen[k] || !l.Contains(k) {
+			t.Fatalf("bad sample: %v", keys)
+		}
+		seen[k] = true
+	}
+
+	// The same seed draws the same sample
+	again := l.SampleKeys(10, 1)
+	for i := range keys {
+		if keys[i] != again[i] {
+			t.Fatalf("sample should be reproducible: %v %v", keys, again)
+		}
+	}
+
+	if n := len(l.SampleKeys(1000, 1)); n != 50 {
+		t.Fatalf("sample should be capped to the resident keys: %v", n)
+	}
+	l.Purge()
+	if keys := l.SampleKeys(10, 1); len(keys) != 0 {
+		t.Fatalf("bad sample of an empty cache: %v", keys)
+	}
+}
+
+func TestLRU_SampleKeysUniform(t *testing.T) {
+	l, _ := NewLRU(10, nil)
+	for i := 0; i < 10; i++ {
+		l.Add(i, i)
+	}
+	counts := make(map[interface{}]int)
+	for seed := int64(0); seed < 2000; seed++ {
+		for _, k := range l.SampleKeys(3, seed) {
+			counts[k]++
+		}
+	}
+	// Each key is drawn 600 times on average
+	for k, n := range counts {
+		if n < 450 || n > 750 {
+			t.Fatalf("key %v drawn %d times", k, n)
+		}
+	}
+	if c := l.Clone(); len(c.SampleKeys(10, 1)) != 10 {
+		t.Fatalf("clone should be sampled")
+	}
+}
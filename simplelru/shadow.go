@@ -0,0 +1,111 @@
+package simplelru
+
+// shadowBuckets is the number of ranges of extra capacity the shadow hits
+// are counted in
+const shadowBuckets = 16
+
+// shadow remembers the keys last evicted for capacity, so that a miss on
+// one of them tells how much larger the cache had to be to hit
+type shadow struct {
+	ring []shadowSlot
+	seqs map[interface{}]uint64
+	seq  uint64
+	hits [shadowBuckets]uint64
+}
+
+// shadowSlot is an evicted key and the eviction it was evicted by
+type shadowSlot struct {
+	key interface{}
+	seq uint64
+}
+
+func newShadow(extra int) *shadow {
+	return &shadow{
+		ring: make([]shadowSlot, extra),
+		seqs: make(map[interface{}]uint64, extra),
+	}
+}
+
+// WithShadow simulates a cache larger by up to extra entries: the keys of
+// the last extra entries evicted for capacity are remembered, and a miss
+// on one of them counts as a hit of a cache larger by the number of
+// entries evicted since. This approximates the hits to gain by growing
+// the cache, see ShadowHits and RecommendedSize, for about the memory of
+// extra keys.
+func WithShadow(extra int) Option {
+	return func(c *LRU) {
+		if extra > 0 {
+			c.shadow = newShadow(extra)
+		}
+	}
+}
+
+// ShadowHits returns the number of misses a cache larger by extra entries
+// would have hit, as simulated by WithShadow, rounded down to the ranges
+// of extra capacity they are counted in. It returns 0 if the shadow is
+// not enabled.
+func (c *LRU) ShadowHits(extra int) uint64 {
+	if c.shadow == nil {
+		return 0
+	}
+	var hits uint64
+	for b := 0; b < shadowBuckets && c.shadow.bucketEnd(b) <= extra; b++ {
+		hits += c.shadow.hits[b]
+	}
+	return hits
+}
+
+// RecommendedSize returns the smallest size, up to the current size plus
+// the extra entries simulated by WithShadow, beyond which growing the
+// cache further would gain less than minGain of the lookups as hits. ok
+// is false if the shadow is not enabled or no lookups were made since
+// the stats were reset.
+func (c *LRU) RecommendedSize(minGain float64) (size int, ok bool) {
+	lookups := c.stats.Hits + c.stats.Misses
+	if c.shadow == nil || lookups == 0 {
+		return 0, false
+	}
+	var rest uint64
+	b := shadowBuckets
+	for ; b > 0; b-- {
+		rest += c.shadow.hits[b-1]
+		if float64(rest) >= minGain*float64(lookups) {
+			break
+		}
+	}
+	if b == 0 {
+		return c.size, true
+	}
+	return c.size + c.shadow.bucketEnd(b-1), true
+}
+
+// bucketEnd returns the extra capacity hitting all the keys counted in
+// bucket b.
+func (s *shadow) bucketEnd(b int) int {
+	return ((b+1)*len(s.ring) + shadowBuckets - 1) / shadowBuckets
+}
+
+// record remembers a key evicted for capacity
+func (s *shadow) record(key interface{}) {
+	s.seq++
+	slot := &s.ring[s.seq%uint64(len(s.ring))]
+	if slot.seq != 0 && s.seqs[slot.key] == slot.seq {
+		delete(s.seqs, slot.key)
+	}
+	slot.key, slot.seq = key, s.seq
+	s.seqs[key] = s.seq
+}
+
+// miss counts a miss on an absent key at the extra capacity needed to
+// have kept it
+func (s *shadow) miss(key interface{}) {
+	seq, ok := s.seqs[key]
+	if !ok {
+		return
+	}
+	delete(s.seqs, key)
+	// The key was evicted distance evictions ago, a cache larger by more
+	// entries would still hold it
+	distance := int(s.seq - seq)
+	s.hits[distance*shadowBuckets/len(s.ring)]++
+}
@@ -0,0 +1,57 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// WithSlidingExpire makes each Get of an entry restart its expire time,
+// so that it expires after d without being accessed. Without a default
+// expire, see WithExpire, entries added without one expire after d too.
+func WithSlidingExpire(d time.Duration) Option {
+	return func(c *LRU) {
+		c.sliding = d
+	}
+}
+
+// Touch marks a live key as recently used without returning its value,
+// restarting its expire time if the cache has a sliding expire. Returns
+// false if the key is not in the cache or expired.
+func (c *LRU) Touch(key interface{}) bool {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) {
+		return false
+	}
+	c.touch(ent)
+	return true
+}
+
+// SetTTL sets a live key to expire after d, clamped to the TTL bounds of
+// the cache, without changing its value. A d <= 0 applies the default
+// expire of the cache. Returns false if the key is not in the cache or
+// expired.
+func (c *LRU) SetTTL(key interface{}, d time.Duration) bool {
+	ent, ok := c.find(key)
+	if !ok || c.expired(ent.Value.(*entry)) {
+		return false
+	}
+	c.setExpire(ent.Value.(*entry), d)
+	return true
+}
+
+// setExpire sets an entry to expire after d, clamped to the TTL bounds.
+func (c *LRU) setExpire(kv *entry, d time.Duration) {
+	c.setExpireAt(kv, d, time.Time{})
+}
+
+// setExpireAt is setExpire counting from now, or from time.Now if now is
+// zero.
+func (c *LRU) setExpireAt(kv *entry, d time.Duration, now time.Time) {
+	kv.expire = nil
+	if d = c.ttl(d); d > 0 {
+		if now.IsZero() {
+			now = c.now()
+		}
+		ex := now.Add(d)
+		kv.expire = &ex
+	}
+}
@@ -0,0 +1,75 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_SlidingExpire(t *testing.T) {
+	l, _ := NewLRUWithOptions(4, nil, WithExpire(time.Hour), WithSlidingExpire(20*time.Millisecond))
+	l.Add(1, 1)
+	if _, expire, _ := l.PeekWithExpireTime(1); time.Until(*expire) < 59*time.Minute {
+		t.Fatalf("Add should use the default expire: %v", expire)
+	}
+	l.Get(1)
+	_, first, _ := l.PeekWithExpireTime(1)
+	if time.Until(*first) > 20*time.Millisecond {
+		t.Fatalf("Get should slide the expire: %v", first)
+	}
+
+	for i := 0; i < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if _, ok := l.Get(1); !ok {
+			t.Fatalf("accessed entry should not expire")
+		}
+	}
+	if _, expire, _ := l.PeekWithExpireTime(1); !expire.After(*first) {
+		t.Fatalf("expire should have moved forward")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if l.Contains(1) {
+		t.Fatalf("idle entry should expire")
+	}
+}
+
+func TestLRU_SlidingExpireUnread(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l, _ := NewLRUWithOptions(4, nil, WithClock(clock), WithSlidingExpire(time.Minute))
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Hour)
+	clock.Advance(2 * time.Minute)
+	if l.Contains(1) {
+		t.Fatalf("entry never read should expire")
+	}
+	if !l.Contains(2) {
+		t.Fatalf("explicit expire should apply")
+	}
+}
+
+func TestLRU_TouchSetTTL(t *testing.T) {
+	l, _ := NewLRU(2, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if !l.Touch(1) || l.Touch(3) {
+		t.Fatalf("bad touch")
+	}
+	if k, _, _ := l.GetOldest(); k != 2 {
+		t.Fatalf("touch should update recent-ness: %v", k)
+	}
+	if s := l.Stats(); s.Hits != 0 {
+		t.Fatalf("touch should not count as a hit: %+v", s)
+	}
+
+	v := &struct{}{}
+	l.Add(3, v)
+	if !l.SetTTL(3, time.Millisecond) || l.SetTTL(4, time.Millisecond) {
+		t.Fatalf("bad set ttl")
+	}
+	if got, _ := l.Peek(3); got != v {
+		t.Fatalf("SetTTL should keep the value")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if l.Contains(3) || l.SetTTL(3, time.Hour) {
+		t.Fatalf("entry should have expired")
+	}
+}
@@ -0,0 +1,65 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// WithStaleTTL keeps expired entries readable with GetStale for d after
+// they expire, so DeleteExpired and the janitor only reap entries past
+// that window. Expired entries can still be evicted to make room.
+func WithStaleTTL(d time.Duration) Option {
+	return func(c *LRU) {
+		c.staleTTL = d
+	}
+}
+
+// AddExSoft adds a value to the cache with two expire times: past the
+// soft TTL, Get still returns it but GetStale flags it as expired, so
+// that callers such as GetOrComputeStale refresh it while serving it;
+// past the hard TTL, it expires as with AddEx. A hard TTL <= 0 uses the
+// default expire, and a soft TTL <= 0 or not below the hard TTL is
+// ignored. Returns true if an eviction occurred.
+func (c *LRU) AddExSoft(key, value interface{}, soft, hard time.Duration) bool {
+	return c.add(key, value, hard, addOptions{
+		priority: PriorityNormal,
+		cost:     c.costOf(key, value),
+		soft:     soft,
+	})
+}
+
+// GetStale looks up a key's value like Get, but also returns the value of
+// an expired entry still in the cache, flagged as expired. An expired
+// entry is not promoted and counts as a miss. Past the window set by
+// WithStaleTTL, it is removed instead; without a window, it is returned
+// until evicted or reaped. An entry past its soft TTL, see AddExSoft, is
+// flagged as expired too, but is promoted and counts as a hit.
+func (c *LRU) GetStale(key interface{}) (value interface{}, expired, ok bool) {
+	if ent, ok := c.lookup(key); ok {
+		c.touch(ent)
+		kv := ent.Value.(*entry)
+		return kv.load(), c.softExpired(kv), true
+	}
+	ent, ok := c.find(key)
+	if !ok {
+		return nil, false, false
+	}
+	kv := ent.Value.(*entry)
+	if c.staleTTL > 0 && !c.inStaleWindow(kv) {
+		if c.metrics != nil {
+			c.metrics.OnExpire(kv.key)
+		}
+		c.removeElement(ent, EvictExpired)
+		return nil, false, false
+	}
+	return kv.load(), true, true
+}
+
+// inStaleWindow returns if an expired entry is kept for GetStale
+func (c *LRU) inStaleWindow(kv *entry) bool {
+	return c.staleTTL > 0 && !c.now().After(kv.expire.Add(c.staleTTL))
+}
+
+// softExpired returns if a live entry is past its soft TTL
+func (c *LRU) softExpired(kv *entry) bool {
+	return kv.softBefore > 0 && !c.now().Before(kv.expire.Add(-kv.softBefore))
+}
@@ -0,0 +1,88 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_GetStale(t *testing.T) {
+	l, _ := NewLRU(10, nil)
+	l.Add(1, 1)
+	l.AddEx(2, 2, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	if v, expired, ok := l.GetStale(1); !ok || expired || v != 1 {
+		t.Fatalf("bad fresh value: %v %v %v", v, expired, ok)
+	}
+	if v, expired, ok := l.GetStale(2); !ok || !expired || v != 2 {
+		t.Fatalf("bad stale value: %v %v %v", v, expired, ok)
+	}
+	if _, _, ok := l.GetStale(3); ok {
+		t.Fatalf("should miss")
+	}
+	if s := l.Stats(); s.Hits != 1 || s.Misses != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	// Without a stale window the janitor reaps as usual
+	if n := l.DeleteExpired(); n != 1 {
+		t.Fatalf("bad removed: %v", n)
+	}
+}
+
+func TestLRU_StaleTTL(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithStaleTTL(20*time.Millisecond))
+	l.AddEx(1, 1, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if l.Contains(1) {
+		t.Fatalf("expired entry should not be contained")
+	}
+	if n := l.DeleteExpired(); n != 0 {
+		t.Fatalf("stale entry should be kept: %v", n)
+	}
+	if _, expired, ok := l.GetStale(1); !ok || !expired {
+		t.Fatalf("bad stale value")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, _, ok := l.GetStale(1); ok || l.Len() != 0 {
+		t.Fatalf("entry past the window should be dropped")
+	}
+}
+
+func TestLRU_SoftTTL(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l, _ := NewLRUWithOptions(10, nil, WithClock(clock))
+	l.AddExSoft(1, 1, time.Minute, time.Hour)
+	if _, expired, ok := l.GetStale(1); !ok || expired {
+		t.Fatalf("entry should be fresh")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("stale entry should still be served")
+	}
+	if _, expired, ok := l.GetStale(1); !ok || !expired {
+		t.Fatalf("entry should be stale")
+	}
+	if s := l.Stats(); s.Hits != 3 || s.Misses != 0 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	clock.Advance(time.Hour)
+	if _, ok := l.Get(1); ok {
+		t.Fatalf("entry should be expired")
+	}
+
+	// A plain Add clears the soft TTL, and a soft TTL not below the hard
+	// one is ignored
+	l.AddExSoft(2, 2, time.Minute, time.Hour)
+	l.AddEx(2, 2, time.Hour)
+	l.AddExSoft(3, 3, time.Hour, time.Hour)
+	clock.Advance(2 * time.Minute)
+	for _, k := range []interface{}{2, 3} {
+		if _, expired, ok := l.GetStale(k); !ok || expired {
+			t.Fatalf("entry %v should be fresh", k)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package simplelru
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrNegativeTTL reports an add with a negative expire in strict mode.
+	ErrNegativeTTL = errors.New("negative expire")
+
+	// ErrInvalidSize reports a Resize to a size <= 0 in strict mode.
+	ErrInvalidSize = errors.New("size must be positive")
+
+	// ErrUnhashableKey reports a key of a type that cannot be a map key.
+	ErrUnhashableKey = errors.New("key is not comparable")
+)
+
+// MisuseError is the value strict mode panics with, see WithStrict.
+type MisuseError struct {
+	Op  string // Op is the method misused
+	Err error  // Err describes the misuse
+}
+
+func (e *MisuseError) Error() string {
+	return fmt.Sprintf("lru: %s: %v", e.Op, e.Err)
+}
+
+func (e *MisuseError) Unwrap() error {
+	return e.Err
+}
+
+// WithStrict makes misuse panic with a *MisuseError at once, instead of
+// being tolerated: a negative expire, which otherwise means the default
+// expire, a Resize to a size <= 0, which otherwise makes the cache drop
+// every Add, and keys that cannot be map keys. lru.Cache also panics on
+// adds after Shutdown, which are otherwise ignored. Meant for development
+// and test builds.
+func WithStrict() Option {
+	return func(c *LRU) {
+		c.strict = true
+	}
+}
+
+// Strict returns if strict mode is enabled.
+func (c *LRU) Strict() bool {
+	return c.strict
+}
+
+// checkAdd panics if an add is misused in strict mode
+func (c *LRU) checkAdd(key interface{}, expire time.Duration) {
+	if !c.strict {
+		return
+	}
+	if expire < 0 {
+		panic(&MisuseError{Op: "Add", Err: ErrNegativeTTL})
+	}
+	if t := reflect.TypeOf(key); t != nil && !t.Comparable() {
+		panic(&MisuseError{Op: "Add", Err: fmt.Errorf("%w: %v", ErrUnhashableKey, t)})
+	}
+}
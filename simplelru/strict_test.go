@@ -0,0 +1,51 @@
+package simplelru
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func expectMisuse(t *testing.T, want error, f func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		err, ok := recover().(*MisuseError)
+		if !ok || !errors.Is(err, want) {
+			t.Fatalf("bad panic: %v", err)
+		}
+	}()
+	f()
+}
+
+func TestLRU_Strict(t *testing.T) {
+	l, err := NewLRUWithOptions(4, nil, WithStrict())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !l.Strict() || !l.Clone().Strict() {
+		t.Fatalf("should be strict")
+	}
+	expectMisuse(t, ErrNegativeTTL, func() { l.AddEx(1, 1, -time.Second) })
+	expectMisuse(t, ErrUnhashableKey, func() { l.Add([]int{1}, 1) })
+	expectMisuse(t, ErrInvalidSize, func() { l.Resize(0) })
+	if l.Len() != 0 || l.Cap() != 4 {
+		t.Fatalf("should be unchanged: %d %d", l.Len(), l.Cap())
+	}
+
+	// Valid uses are unaffected
+	l.AddEx(1, 1, 0)
+	l.Add(struct{ a int }{1}, 1)
+	if l.Resize(2) != 0 || l.Len() != 2 {
+		t.Fatalf("bad len: %d", l.Len())
+	}
+}
+
+func TestLRU_NotStrict(t *testing.T) {
+	l, _ := NewLRU(4, nil)
+	l.AddEx(1, 1, -time.Second)
+	if _, ok := l.Get(1); !ok {
+		t.Fatalf("negative expire should mean the default")
+	}
+	l.Resize(0)
+}
@@ -0,0 +1,76 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// tombstones remembers the keys deliberately removed, so that re-adding
+// them shortly after can be caught.
+type tombstones struct {
+	period    time.Duration
+	resurrect func(key interface{}) bool
+	until     map[interface{}]int64
+	prune     int // number of tombstones triggering the next pruning
+}
+
+// WithTombstones makes Remove leave a tombstone for the key, whether it
+// was cached or not, during period. Gets of the key miss as usual, but an
+// Add of the key within the period calls resurrect, which returns whether
+// the Add may proceed, e.g. after flagging it; a nil resurrect rejects
+// all of them. This prevents a concurrent reader from re-populating a key
+// just invalidated with a value it loaded before the invalidation.
+// resurrect is called under the lock of the thread-safe caches and must
+// not call back into the cache.
+func WithTombstones(period time.Duration, resurrect func(key interface{}) bool) Option {
+	return func(c *LRU) {
+		c.tombstones = &tombstones{
+			period:    period,
+			resurrect: resurrect,
+			until:     make(map[interface{}]int64),
+		}
+	}
+}
+
+// IsTombstoned returns if the key was removed less than the tombstone
+// period ago and not added since.
+func (c *LRU) IsTombstoned(key interface{}) bool {
+	return c.tombstones != nil && c.tombstones.live(key, c.now().UnixNano())
+}
+
+// bury leaves a tombstone for a removed key, pruning the stale ones each
+// time the number of tombstones doubles.
+func (t *tombstones) bury(key interface{}, at time.Time) {
+	now := at.UnixNano()
+	if len(t.until) >= t.prune {
+		for k, until := range t.until {
+			if until <= now {
+				delete(t.until, k)
+			}
+		}
+		t.prune = 2*len(t.until) + 16
+	}
+	t.until[key] = now + int64(t.period)
+}
+
+// live returns if the key has a tombstone at now.
+func (t *tombstones) live(key interface{}, now int64) bool {
+	until, ok := t.until[key]
+	if ok && until <= now {
+		delete(t.until, key)
+		return false
+	}
+	return ok
+}
+
+// admit returns whether an Add of the key may proceed, lifting its
+// tombstone if so.
+func (t *tombstones) admit(key interface{}, now time.Time) bool {
+	if !t.live(key, now.UnixNano()) {
+		return true
+	}
+	if t.resurrect == nil || !t.resurrect(key) {
+		return false
+	}
+	delete(t.until, key)
+	return true
+}
@@ -0,0 +1,59 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_Tombstones(t *testing.T) {
+	l, _ := NewLRUWithOptions(4, nil, WithTombstones(5*time.Millisecond, nil))
+	l.Add(1, 1)
+	l.Remove(1)
+	if !l.IsTombstoned(1) {
+		t.Fatalf("removed key should be tombstoned")
+	}
+	if l.Add(1, 2); l.Contains(1) {
+		t.Fatalf("tombstoned key should be rejected")
+	}
+
+	// Keys that were not cached are tombstoned too
+	l.Remove(2)
+	if l.Add(2, 2); l.Contains(2) {
+		t.Fatalf("tombstoned key should be rejected")
+	}
+
+	time.Sleep(6 * time.Millisecond)
+	if l.IsTombstoned(1) {
+		t.Fatalf("tombstone should have expired")
+	}
+	if l.Add(1, 3); !l.Contains(1) {
+		t.Fatalf("key should be added after the period")
+	}
+}
+
+func TestLRU_TombstonesResurrect(t *testing.T) {
+	var flagged []interface{}
+	l, _ := NewLRUWithOptions(4, nil, WithTombstones(time.Hour, func(key interface{}) bool {
+		flagged = append(flagged, key)
+		return key != "reject"
+	}))
+	l.Remove("allow")
+	l.Remove("reject")
+	l.Add("allow", 1)
+	l.Add("reject", 1)
+	if !l.Contains("allow") || l.Contains("reject") || len(flagged) != 2 {
+		t.Fatalf("bad resurrect: %v", flagged)
+	}
+	if l.IsTombstoned("allow") {
+		t.Fatalf("resurrected key should lose its tombstone")
+	}
+
+	// Stale tombstones are pruned as new ones are added
+	l, _ = NewLRUWithOptions(4, nil, WithTombstones(time.Nanosecond, nil))
+	for i := 0; i < 1000; i++ {
+		l.Remove(i)
+	}
+	if n := len(l.tombstones.until); n > 100 {
+		t.Fatalf("stale tombstones should be pruned: %d", n)
+	}
+}
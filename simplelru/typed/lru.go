@@ -0,0 +1,172 @@
+// Package typed provides a generic, type-safe front end to the
+// simplelru package, so callers can use concrete key and value types
+// instead of interface{} and type assertions. It shares the semantics
+// and options of simplelru.LRU.
+package typed
+
+import (
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// EvictCallback is used to get a callback when a cache entry is evicted
+type EvictCallback[K comparable, V any] func(key K, value V)
+
+// LRU implements a non-thread safe fixed size LRU cache
+type LRU[K comparable, V any] struct {
+	lru *simplelru.LRU
+}
+
+// NewLRU constructs an LRU of the given size
+func NewLRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
+	return NewLRUWithOptions(size, onEvict)
+}
+
+// NewLRUWithExpire constructs an LRU of the given size and expire time
+func NewLRUWithExpire[K comparable, V any](size int, expire time.Duration, onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
+	return NewLRUWithOptions(size, onEvict, simplelru.WithExpire(expire))
+}
+
+// NewLRUWithOptions constructs an LRU of the given size configured by
+// the given options.
+func NewLRUWithOptions[K comparable, V any](size int, onEvict EvictCallback[K, V], opts ...simplelru.Option) (*LRU[K, V], error) {
+	var cb simplelru.EvictCallback
+	if onEvict != nil {
+		cb = func(key, value interface{}) {
+			onEvict(key.(K), cast[V](value))
+		}
+	}
+	lru, err := simplelru.NewLRUWithOptions(size, cb, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &LRU[K, V]{lru: lru}, nil
+}
+
+// Purge is used to completely clear the cache
+func (c *LRU[K, V]) Purge() {
+	c.lru.Purge()
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *LRU[K, V]) Add(key K, value V) bool {
+	return c.lru.Add(key, value)
+}
+
+// AddEx adds a value to the cache with expire.  Returns true if an eviction occurred.
+func (c *LRU[K, V]) AddEx(key K, value V, expire time.Duration) bool {
+	return c.lru.AddEx(key, value, expire)
+}
+
+// Get looks up a key's value from the cache.
+func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	v, ok := c.lru.Get(key)
+	return cast[V](v), ok
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (c *LRU[K, V]) Contains(key K) bool {
+	return c.lru.Contains(key)
+}
+
+// Peek returns the key value (or the zero value if not found) without
+// updating the "recently used"-ness of the key.
+func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	v, ok := c.lru.Peek(key)
+	return cast[V](v), ok
+}
+
+// PeekWithExpireTime returns the key value (or the zero value if not
+// found) and its associated expire time without updating the "recently
+// used"-ness of the key.
+func (c *LRU[K, V]) PeekWithExpireTime(key K) (value V, expire *time.Time, ok bool) {
+	v, expire, ok := c.lru.PeekWithExpireTime(key)
+	return cast[V](v), expire, ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *LRU[K, V]) Remove(key K) bool {
+	return c.lru.Remove(key)
+}
+
+// RemoveIf removes the provided key from the cache if pred holds for its
+// value, returning if it was removed.
+func (c *LRU[K, V]) RemoveIf(key K, pred func(value V) bool) bool {
+	return c.lru.RemoveIf(key, func(v interface{}) bool { return pred(cast[V](v)) })
+}
+
+// RemoveOldest removes the oldest item from the cache.
+func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	k, v, ok := c.lru.RemoveOldest()
+	return cast[K](k), cast[V](v), ok
+}
+
+// GetOldest returns the oldest entry
+func (c *LRU[K, V]) GetOldest() (key K, value V, ok bool) {
+	k, v, ok := c.lru.GetOldest()
+	return cast[K](k), cast[V](v), ok
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *LRU[K, V]) Keys() []K {
+	keys := c.lru.Keys()
+	out := make([]K, len(keys))
+	for i, k := range keys {
+		out[i] = k.(K)
+	}
+	return out
+}
+
+// KeysMRU returns a slice of the keys in the cache, from newest to oldest.
+func (c *LRU[K, V]) KeysMRU() []K {
+	keys := c.lru.KeysMRU()
+	out := make([]K, len(keys))
+	for i, k := range keys {
+		out[i] = k.(K)
+	}
+	return out
+}
+
+// Values returns a slice of the values in the cache, from oldest to newest.
+func (c *LRU[K, V]) Values() []V {
+	values := c.lru.Values()
+	out := make([]V, len(values))
+	for i, v := range values {
+		out[i] = cast[V](v)
+	}
+	return out
+}
+
+// Range calls f for each live key and value in the cache, from oldest to
+// newest, without updating their recent-ness. If f returns false, Range
+// stops the iteration. f must not modify the cache.
+func (c *LRU[K, V]) Range(f func(key K, value V) bool) {
+	c.lru.Range(func(key, value interface{}) bool {
+		return f(key.(K), cast[V](value))
+	})
+}
+
+// Len returns the number of items in the cache.
+func (c *LRU[K, V]) Len() int {
+	return c.lru.Len()
+}
+
+// Cap returns the maximum number of items the cache can hold.
+func (c *LRU[K, V]) Cap() int {
+	return c.lru.Cap()
+}
+
+// Resize changes the cache size.
+func (c *LRU[K, V]) Resize(size int) (evicted int) {
+	return c.lru.Resize(size)
+}
+
+// cast converts a value stored in the underlying LRU back to T, mapping
+// nil to the zero value
+func cast[T any](v interface{}) T {
+	t, _ := v.(T)
+	return t
+}
@@ -0,0 +1,129 @@
+package typed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+func TestLRU(t *testing.T) {
+	evictCounter := 0
+	onEvicted := func(k int, v string) {
+		if v != string(rune('a'+k%26)) {
+			t.Fatalf("bad evicted value: %v, %v", k, v)
+		}
+		evictCounter++
+	}
+	l, err := NewLRU[int, string](128, onEvicted)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		l.Add(i, string(rune('a'+i%26)))
+	}
+	if l.Len() != 128 || l.Cap() != 128 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if evictCounter != 128 {
+		t.Fatalf("bad evict count: %v", evictCounter)
+	}
+
+	for i, k := range l.Keys() {
+		if v, ok := l.Get(k); !ok || k != i+128 || v != string(rune('a'+k%26)) {
+			t.Fatalf("bad key: %v", k)
+		}
+	}
+	if v, ok := l.Get(0); ok || v != "" {
+		t.Fatalf("should be evicted")
+	}
+
+	k, _, ok := l.GetOldest()
+	if !ok || k != 128 {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	k, _, ok = l.RemoveOldest()
+	if !ok || k != 128 || l.Contains(128) {
+		t.Fatalf("bad oldest: %v", k)
+	}
+	if !l.Remove(129) || l.Remove(129) {
+		t.Fatalf("bad remove")
+	}
+
+	if evicted := l.Resize(10); evicted != 116 {
+		t.Fatalf("bad evicted: %v", evicted)
+	}
+	l.Purge()
+	if l.Len() != 0 {
+		t.Fatalf("bad len: %v", l.Len())
+	}
+	if _, _, ok := l.GetOldest(); ok {
+		t.Fatalf("should be empty")
+	}
+}
+
+func TestLRU_Expire(t *testing.T) {
+	l, err := NewLRUWithExpire[string, *int](2, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	one := 1
+	l.Add("a", &one)
+	l.AddEx("b", nil, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if v, exp, ok := l.PeekWithExpireTime("a"); !ok || *v != 1 || exp == nil {
+		t.Fatalf("bad: %v, %v, %v", v, exp, ok)
+	}
+	if v, ok := l.Peek("b"); ok || v != nil {
+		t.Fatalf("b should be expired")
+	}
+}
+
+func TestLRU_Options(t *testing.T) {
+	l, err := NewLRUWithOptions[int, int](2, nil, simplelru.WithEvictionOrder(simplelru.InsertionOrder))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Get(1)
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Fatalf("1 should be evicted")
+	}
+	if _, err := NewLRU[int, int](0, nil); err == nil {
+		t.Fatalf("should fail")
+	}
+}
+
+func TestLRU_Range(t *testing.T) {
+	l, _ := NewLRU[string, int](4, nil)
+	l.Add("a", 1)
+	l.Add("b", 2)
+	sum := 0
+	l.Range(func(k string, v int) bool {
+		sum += v
+		return true
+	})
+	if sum != 3 {
+		t.Fatalf("bad sum: %v", sum)
+	}
+	if v := l.Values(); len(v) != 2 || v[0] != 1 {
+		t.Fatalf("bad values: %v", v)
+	}
+	if k := l.KeysMRU(); len(k) != 2 || k[0] != "b" {
+		t.Fatalf("bad keys: %v", k)
+	}
+}
+
+func TestLRU_RemoveIf(t *testing.T) {
+	l, _ := NewLRU[string, int](4, nil)
+	l.Add("a", 1)
+	l.Add("b", 2)
+	even := func(v int) bool { return v%2 == 0 }
+	if l.RemoveIf("a", even) || !l.RemoveIf("b", even) || l.Len() != 1 {
+		t.Fatalf("bad removal: %v", l.Keys())
+	}
+}
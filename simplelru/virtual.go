@@ -0,0 +1,27 @@
+package simplelru
+
+import (
+	"time"
+)
+
+// AddExAt is AddEx with the expire counted from now instead of the
+// current time, so that replays and simulations can drive the cache with
+// a virtual clock. Returns true if an eviction occurred.
+func (c *LRU) AddExAt(key, value interface{}, expire time.Duration, now time.Time) bool {
+	return c.add(key, value, expire, addOptions{
+		priority: PriorityNormal,
+		cost:     c.costOf(key, value),
+		now:      now,
+	})
+}
+
+// GetAt is Get with the expiration checked at now instead of the current
+// time. A sliding expire restarts at now.
+func (c *LRU) GetAt(key interface{}, now time.Time) (value interface{}, ok bool) {
+	ent, ok := c.lookupAt(key, now)
+	if !ok {
+		return nil, false
+	}
+	c.touchAt(ent, now)
+	return ent.Value.(*entry).load(), true
+}
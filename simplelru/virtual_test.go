@@ -0,0 +1,65 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU_VirtualTime(t *testing.T) {
+	l, _ := NewLRU(10, nil)
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.AddExAt(1, 1, time.Minute, start)
+
+	if v, ok := l.GetAt(1, start.Add(30*time.Second)); !ok || v != 1 {
+		t.Fatalf("bad value: %v", v)
+	}
+	if _, ok := l.GetAt(1, start.Add(2*time.Minute)); ok {
+		t.Fatalf("should be expired in virtual time")
+	}
+	// The entry expired in the past for the wall clock as well
+	if l.Contains(1) {
+		t.Fatalf("should be expired")
+	}
+
+	future := time.Now().Add(time.Hour)
+	l.AddExAt(2, 2, time.Minute, future)
+	if _, ok := l.Get(2); !ok {
+		t.Fatalf("should not be expired yet")
+	}
+	if _, ok := l.GetAt(2, future.Add(2*time.Minute)); ok {
+		t.Fatalf("should be expired in virtual time")
+	}
+}
+
+func TestLRU_VirtualTimeSliding(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithSlidingExpire(time.Minute))
+	now := time.Now().Add(time.Hour)
+	l.AddExAt(1, 1, 0, now)
+	for i := 0; i < 5; i++ {
+		now = now.Add(50 * time.Second)
+		if _, ok := l.GetAt(1, now); !ok {
+			t.Fatalf("sliding expire should restart at %d", i)
+		}
+	}
+	if _, ok := l.GetAt(1, now.Add(61*time.Second)); ok {
+		t.Fatalf("should be expired")
+	}
+}
+
+func TestLRU_VirtualTimeUpdate(t *testing.T) {
+	l, _ := NewLRUWithOptions(10, nil, WithTombstones(time.Minute, nil))
+	now := time.Now().Add(time.Hour)
+	l.AddExAt(1, 1, 0, now)
+	l.Remove(1)
+	// The tombstone of Remove is checked at the virtual time of the add
+	if l.AddExAt(1, 2, 0, now.Add(2*time.Minute)); !l.Contains(1) {
+		t.Fatalf("tombstone should be over in virtual time")
+	}
+	l.AddExAt(1, 3, time.Minute, now.Add(time.Hour))
+	if _, ok := l.GetAt(1, now.Add(time.Hour+30*time.Second)); !ok {
+		t.Fatalf("updated expire should count from the virtual time")
+	}
+	if _, ok := l.GetAt(1, now.Add(time.Hour+2*time.Minute)); ok {
+		t.Fatalf("should be expired")
+	}
+}
@@ -0,0 +1,20 @@
+package simplelru
+
+import (
+	"math/rand"
+)
+
+// GetWithWeight looks up a key's value like Get, but only promotes the
+// entry with probability w, so that background readers such as scanners
+// can pass a low weight and leave the recency signal of the regular
+// traffic mostly intact. A weight of 1 is Get, and 0 never promotes.
+func (c *LRU) GetWithWeight(key interface{}, w float64) (value interface{}, ok bool) {
+	ent, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	if w >= 1 || (w > 0 && rand.Float64() < w) {
+		c.touch(ent)
+	}
+	return ent.Value.(*entry).load(), true
+}
@@ -0,0 +1,43 @@
+package simplelru
+
+import (
+	"testing"
+)
+
+func TestLRU_GetWithWeight(t *testing.T) {
+	l, _ := NewLRU(3, nil)
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+
+	if v, ok := l.GetWithWeight(1, 0); !ok || v != 1 {
+		t.Fatalf("bad value: %v", v)
+	}
+	if k, _, _ := l.GetOldest(); k != 1 {
+		t.Fatalf("zero weight should not promote: %v", k)
+	}
+	l.GetWithWeight(1, 1)
+	if k, _, _ := l.GetOldest(); k != 2 {
+		t.Fatalf("full weight should promote: %v", k)
+	}
+	if _, ok := l.GetWithWeight(4, 1); ok {
+		t.Fatalf("should miss")
+	}
+
+	// A partial weight promotes some of the accesses
+	promoted := 0
+	for i := 0; i < 1000; i++ {
+		l.GetWithWeight(2, 0.5)
+		if k, _, _ := l.GetOldest(); k != 2 {
+			promoted++
+			l.GetWithWeight(3, 1)
+			l.GetWithWeight(1, 1)
+		}
+	}
+	if promoted < 400 || promoted > 600 {
+		t.Fatalf("bad promotions: %v", promoted)
+	}
+	if s := l.Stats(); s.Hits < 1000 {
+		t.Fatalf("weighted reads should count as hits: %+v", s)
+	}
+}
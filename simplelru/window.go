@@ -0,0 +1,87 @@
+package simplelru
+
+import (
+	"time"
+)
+
+const (
+	// windowBucket is the granularity of the windowed stats
+	windowBucket = 10 * time.Second
+	// windowBuckets covers the longest window, an hour
+	windowBuckets = int(time.Hour / windowBucket)
+)
+
+// windowStats counts the lookups of the last hour in a ring of buckets.
+type windowStats struct {
+	buckets [windowBuckets]windowCount
+}
+
+// windowCount holds the counters of the bucket starting at start,
+// in windowBucket units since the Unix epoch
+type windowCount struct {
+	start  int64
+	hits   uint64
+	misses uint64
+}
+
+// WithWindowedStats enables StatsOver, counting the lookups of the last
+// hour in 10 second buckets.
+func WithWindowedStats() Option {
+	return func(c *LRU) {
+		c.window = &windowStats{}
+	}
+}
+
+// StatsOver returns the stats of the cache like Stats, but with the
+// lookup counters restricted to the given window, e.g. the last minute,
+// rounded up to 10 seconds and capped to an hour. It returns the
+// lifetime counters if the cache was not configured with
+// WithWindowedStats.
+func (c *LRU) StatsOver(window time.Duration) Stats {
+	stats := c.Stats()
+	if c.window != nil {
+		stats.Hits, stats.Misses = c.window.sum(window, c.now())
+	}
+	return stats
+}
+
+// ResetStats zeroes the lookup counters, including the windowed ones.
+func (c *LRU) ResetStats() {
+	c.stats = Stats{}
+	if c.window != nil {
+		c.window.buckets = [windowBuckets]windowCount{}
+	}
+	if c.shadow != nil {
+		c.shadow.hits = [shadowBuckets]uint64{}
+	}
+}
+
+// record counts a lookup at now.
+func (w *windowStats) record(hit bool, now time.Time) {
+	start := now.UnixNano() / int64(windowBucket)
+	b := &w.buckets[start%int64(windowBuckets)]
+	if b.start != start {
+		*b = windowCount{start: start}
+	}
+	if hit {
+		b.hits++
+	} else {
+		b.misses++
+	}
+}
+
+// sum returns the lookups counted over the window ending at now.
+func (w *windowStats) sum(window time.Duration, now time.Time) (hits, misses uint64) {
+	n := int64((window + windowBucket - 1) / windowBucket)
+	if n > int64(windowBuckets) {
+		n = int64(windowBuckets)
+	}
+	end := now.UnixNano() / int64(windowBucket)
+	for _, b := range w.buckets {
+		if b.start > end-n && b.start <= end {
+			hits += b.hits
+			misses += b.misses
+		}
+	}
+	return hits, misses
+}
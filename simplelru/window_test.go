@@ -0,0 +1,55 @@
+package simplelru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowStats(t *testing.T) {
+	w := &windowStats{}
+	now := time.Unix(1000000, 0)
+	w.record(true, now.Add(-2*time.Hour))
+	w.record(true, now.Add(-30*time.Minute))
+	w.record(false, now.Add(-3*time.Minute))
+	w.record(true, now.Add(-30*time.Second))
+	w.record(true, now)
+
+	for _, tc := range []struct {
+		window       time.Duration
+		hits, misses uint64
+	}{
+		{time.Minute, 2, 0},
+		{5 * time.Minute, 2, 1},
+		{time.Hour, 3, 1},
+		{24 * time.Hour, 3, 1},
+	} {
+		hits, misses := w.sum(tc.window, now)
+		if hits != tc.hits || misses != tc.misses {
+			t.Fatalf("bad %v window: %d %d", tc.window, hits, misses)
+		}
+	}
+}
+
+func TestLRU_StatsOver(t *testing.T) {
+	l, _ := NewLRUWithOptions(4, nil, WithWindowedStats())
+	l.Add(1, 1)
+	l.Get(1)
+	l.Get(2)
+	if s := l.StatsOver(time.Minute); s.Hits != 1 || s.Misses != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	l.ResetStats()
+	if s := l.StatsOver(time.Minute); s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("bad stats after reset: %+v", s)
+	}
+	if s := l.Stats(); s.Hits != 0 {
+		t.Fatalf("bad lifetime stats after reset: %+v", s)
+	}
+
+	// Without windowed stats the lifetime counters are returned
+	l, _ = NewLRU(4, nil)
+	l.Get(1)
+	if s := l.StatsOver(time.Minute); s.Misses != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
@@ -0,0 +1,97 @@
+// Package sink forwards the eviction events of a cache to downstream
+// systems, such as search indexes or CDNs holding their own copies of
+// the entries, so they can invalidate them.
+package sink
+
+import (
+	"time"
+
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+// Kind is the kind of an event.
+type Kind int
+
+const (
+	// Evicted is sent when an entry leaves the cache.
+	Evicted Kind = iota
+
+	// Expired is sent when an expired entry is removed from the cache.
+	Expired
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case Expired:
+		return "expired"
+	default:
+		return "evicted"
+	}
+}
+
+// Event describes an entry leaving a cache.
+type Event struct {
+	Kind Kind
+	Key  interface{}
+	Time time.Time
+
+	// Cache and Labels identify the cache, see WithCache
+	Cache  string
+	Labels map[string]string
+}
+
+// Sink receives the events of a cache.
+type Sink interface {
+	// Send delivers an event. It is called without the cache lock held,
+	// but on the goroutine evicting the entry, so a slow Send slows down
+	// the cache writes.
+	Send(Event) error
+}
+
+// OnEvict returns an eviction callback for lru.NewWithEvict forwarding
+// the evictions to s. errs, if not nil, is called with the errors
+// returned by s.
+func OnEvict(s Sink, errs func(error)) func(key, value interface{}) {
+	return func(key, value interface{}) {
+		err := s.Send(Event{Kind: Evicted, Key: key, Time: time.Now()})
+		if err != nil && errs != nil {
+			errs(err)
+		}
+	}
+}
+
+// OnEvictWithReason is like OnEvict but returns a callback for
+// simplelru.WithEvictCallbackWithReason, which sends Expired events for
+// the entries that expired. Replaced values are sent as evictions since
+// downstream copies of them are stale too.
+func OnEvictWithReason(s Sink, errs func(error)) simplelru.EvictCallbackWithReason {
+	return func(key, value interface{}, reason simplelru.EvictReason) {
+		kind := Evicted
+		if reason == simplelru.EvictExpired {
+			kind = Expired
+		}
+		err := s.Send(Event{Kind: kind, Key: key, Time: time.Now()})
+		if err != nil && errs != nil {
+			errs(err)
+		}
+	}
+}
+
+// WithCache returns a Sink stamping the events sent to s with the name
+// and labels of a cache, typically those given to simplelru.WithName and
+// simplelru.WithLabels.
+func WithCache(s Sink, name string, labels map[string]string) Sink {
+	return cacheSink{s: s, name: name, labels: labels}
+}
+
+type cacheSink struct {
+	s      Sink
+	name   string
+	labels map[string]string
+}
+
+func (c cacheSink) Send(ev Event) error {
+	ev.Cache, ev.Labels = c.name, c.labels
+	return c.s.Send(ev)
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"testing"
+	"time"
+
+	lru "github.com/hnlq715/golang-lru"
+	"github.com/hnlq715/golang-lru/simplelru"
+)
+
+type events []Event
+
+func (e *events) Send(ev Event) error {
+	*e = append(*e, ev)
+	return nil
+}
+
+func TestOnEvictWithReason(t *testing.T) {
+	var got events
+	l, _ := lru.NewWithOptions(2, nil, simplelru.WithEvictCallbackWithReason(OnEvictWithReason(&got, nil)))
+	l.AddEx(1, 1, time.Millisecond)
+	l.Add(2, 2)
+	time.Sleep(2 * time.Millisecond)
+	l.DeleteExpired()
+	l.Remove(2)
+	if len(got) != 2 || got[0].Kind != Expired || got[1].Kind != Evicted {
+		t.Fatalf("bad events: %v", got)
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	var got events
+	labels := map[string]string{"tier": "edge"}
+	l, _ := lru.NewWithEvict(1, OnEvict(WithCache(&got, "sessions", labels), nil))
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if len(got) != 1 || got[0].Cache != "sessions" || got[0].Labels["tier"] != "edge" {
+		t.Fatalf("bad events: %v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWebhookTimeout is the timeout of the client of a Webhook
+	// created without one.
+	DefaultWebhookTimeout = 5 * time.Second
+
+	// DefaultWebhookQueue is the number of events NewWebhook queues.
+	DefaultWebhookQueue = 1024
+)
+
+// ErrDropped is returned by Webhook.Send when its queue is full, and
+// ErrClosed once the Webhook is closed.
+var (
+	ErrDropped = errors.New("sink: webhook queue full, event dropped")
+	ErrClosed  = errors.New("sink: webhook closed")
+)
+
+// Webhook is a Sink posting each event as a JSON object to a URL. The
+// events are queued and posted by a goroutine of the Webhook, so a slow
+// endpoint never stalls the cache writers; events sent while the queue
+// is full are dropped and counted instead.
+type Webhook struct {
+	url    string
+	client *http.Client
+	errs   func(error)
+
+	// lock guards the sends on queue against its close
+	lock    sync.RWMutex
+	closed  bool
+	queue   chan []byte
+	done    chan struct{}
+	dropped uint64
+	failed  uint64
+}
+
+// webhookEvent is the JSON encoding of an event
+type webhookEvent struct {
+	Kind   string            `json:"kind"`
+	Key    interface{}       `json:"key"`
+	Time   time.Time         `json:"time"`
+	Cache  string            `json:"cache,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NewWebhook creates a Webhook posting to url with client, or with a
+// client timing out after DefaultWebhookTimeout if client is nil, and
+// queuing up to DefaultWebhookQueue events.
+func NewWebhook(url string, client *http.Client) *Webhook {
+	return NewWebhookWithQueue(url, client, DefaultWebhookQueue, nil)
+}
+
+// NewWebhookWithQueue is like NewWebhook but queues up to size events,
+// and calls errs, if not nil, with the errors of the posts. errs is
+// called on the goroutine posting the events.
+func NewWebhookWithQueue(url string, client *http.Client, size int, errs func(error)) *Webhook {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	w := &Webhook{
+		url:    url,
+		client: client,
+		errs:   errs,
+		queue:  make(chan []byte, size),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Send queues the event for posting. Keys must be encodable by
+// encoding/json. It returns ErrDropped if the queue is full, while a
+// response status other than 2xx is reported to the errs of
+// NewWebhookWithQueue.
+func (w *Webhook) Send(ev Event) error {
+	body, err := json.Marshal(webhookEvent{
+		Kind:   ev.Kind.String(),
+		Key:    ev.Key,
+		Time:   ev.Time,
+		Cache:  ev.Cache,
+		Labels: ev.Labels,
+	})
+	if err != nil {
+		return err
+	}
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.closed {
+		return ErrClosed
+	}
+	select {
+	case w.queue <- body:
+		return nil
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+		return ErrDropped
+	}
+}
+
+// Dropped returns the number of events dropped because the queue was
+// full.
+func (w *Webhook) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Failed returns the number of events whose post failed.
+func (w *Webhook) Failed() uint64 {
+	return atomic.LoadUint64(&w.failed)
+}
+
+// Close stops accepting events and waits for the queued ones to be
+// posted.
+func (w *Webhook) Close() {
+	w.lock.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.lock.Unlock()
+	<-w.done
+}
+
+// run posts the queued events until the queue is closed
+func (w *Webhook) run() {
+	defer close(w.done)
+	for body := range w.queue {
+		if err := w.post(body); err != nil {
+			atomic.AddUint64(&w.failed, 1)
+			if w.errs != nil {
+				w.errs(err)
+			}
+		}
+	}
+}
+
+// post posts one event
+func (w *Webhook) post(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sink: webhook returned %s", resp.Status)
+	}
+	return nil
+}
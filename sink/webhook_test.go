@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	lru "github.com/hnlq715/golang-lru"
+)
+
+func TestWebhook(t *testing.T) {
+	var got []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("bad body: %v", err)
+		}
+		got = append(got, ev)
+		if ev["key"] == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	var errs []error
+	hook := NewWebhookWithQueue(srv.URL, nil, 8, func(err error) {
+		errs = append(errs, err)
+	})
+	l, _ := lru.NewWithEvict(1, OnEvict(hook, nil))
+	l.Add("a", 1)
+	l.Add("fail", 2)
+	l.Add("c", 3)
+	hook.Close()
+
+	if len(got) != 2 || got[0]["key"] != "a" || got[0]["kind"] != "evicted" {
+		t.Fatalf("bad events: %v", got)
+	}
+	if len(errs) != 1 || hook.Failed() != 1 {
+		t.Fatalf("bad errors: %v", errs)
+	}
+	if err := hook.Send(Event{Key: "d"}); err != ErrClosed {
+		t.Fatalf("bad err: %v", err)
+	}
+}
+
+func TestWebhook_Overflow(t *testing.T) {
+	received := make(chan struct{}, 4)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+
+	hook := NewWebhookWithQueue(srv.URL, nil, 1, nil)
+	if err := hook.Send(Event{Key: 1}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the event should be posted")
+	}
+	// The post in flight blocks, so the queue holds one more event
+	if err := hook.Send(Event{Key: 2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := hook.Send(Event{Key: 3}); err != ErrDropped || hook.Dropped() != 1 {
+		t.Fatalf("the event should be dropped: %v %v", err, hook.Dropped())
+	}
+	close(release)
+	hook.Close()
+	if len(received) != 1 || hook.Failed() != 0 {
+		t.Fatalf("bad posts: %v %v", len(received), hook.Failed())
+	}
+}
@@ -0,0 +1,66 @@
+package lru
+
+// Map is a bounded LRU cache exposing the method set of sync.Map, so
+// code written against sync.Map can switch to bounded caching without
+// changing its call sites. Unlike sync.Map, entries may disappear when
+// the cache is full.
+type Map struct {
+	c *Cache
+}
+
+// NewMap creates a Map holding up to size entries.
+func NewMap(size int) (*Map, error) {
+	c, err := New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &Map{c: c}, nil
+}
+
+// Load returns the value stored for a key, or nil if no value is present.
+func (m *Map) Load(key interface{}) (value interface{}, ok bool) {
+	return m.c.Get(key)
+}
+
+// Store sets the value for a key.
+func (m *Map) Store(key, value interface{}) {
+	m.c.Add(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value. The loaded result
+// is true if the value was loaded, false if stored.
+func (m *Map) LoadOrStore(key, value interface{}) (actual interface{}, loaded bool) {
+	m.c.lock.Lock()
+	defer m.c.unlock()
+	if v, ok := m.c.lru.Get(key); ok {
+		return v, true
+	}
+	if !m.c.closed {
+		m.c.lru.Add(key, value)
+	}
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous
+// value if any. The loaded result reports whether the key was present.
+func (m *Map) LoadAndDelete(key interface{}) (value interface{}, loaded bool) {
+	m.c.lock.Lock()
+	defer m.c.unlock()
+	value, loaded = m.c.lru.Peek(key)
+	m.c.lru.Remove(key)
+	return value, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *Map) Delete(key interface{}) {
+	m.c.Remove(key)
+}
+
+// Range calls f sequentially for each key and value present in the map,
+// from oldest to newest. If f returns false, range stops the iteration.
+// As with sync.Map, Range does not correspond to a consistent snapshot:
+// entries stored or deleted during the iteration may or may not be seen.
+func (m *Map) Range(f func(key, value interface{}) bool) {
+	m.c.Range(RangeBestEffort, f)
+}
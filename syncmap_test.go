@@ -0,0 +1,59 @@
+package lru
+
+import (
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	m, err := NewMap(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	m.Store(1, 1)
+	if v, ok := m.Load(1); !ok || v != 1 {
+		t.Fatalf("bad: %v, %v", v, ok)
+	}
+	if v, loaded := m.LoadOrStore(1, 10); !loaded || v != 1 {
+		t.Fatalf("bad: %v, %v", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore(2, 2); loaded || v != 2 {
+		t.Fatalf("bad: %v, %v", v, loaded)
+	}
+
+	var keys []interface{}
+	m.Range(func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	})
+	if len(keys) != 2 || keys[0] != 1 || keys[1] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	n := 0
+	m.Range(func(k, v interface{}) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("range should stop: %v", n)
+	}
+
+	if v, loaded := m.LoadAndDelete(1); !loaded || v != 1 {
+		t.Fatalf("bad: %v, %v", v, loaded)
+	}
+	if _, loaded := m.LoadAndDelete(1); loaded {
+		t.Fatalf("should be deleted")
+	}
+	m.Delete(2)
+	if _, ok := m.Load(2); ok {
+		t.Fatalf("should be deleted")
+	}
+
+	// The map is bounded
+	m.Store(1, 1)
+	m.Store(2, 2)
+	m.Store(3, 3)
+	if _, ok := m.Load(1); ok {
+		t.Fatalf("1 should be evicted")
+	}
+}